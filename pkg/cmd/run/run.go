@@ -3,6 +3,7 @@ package run
 import (
 	"context"
 	"fmt"
+	gogithub "github.com/google/go-github/v48/github"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -13,6 +14,7 @@ import (
 	"github.com/spring-financial-group/peacock/pkg/git/comment"
 	"github.com/spring-financial-group/peacock/pkg/git/github"
 	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/sms"
 	"github.com/spring-financial-group/peacock/pkg/releasenotes/delivery/msgclients"
 	"github.com/spring-financial-group/peacock/pkg/releasenotes/usecase"
 	"github.com/spring-financial-group/peacock/pkg/rootcmd"
@@ -20,6 +22,7 @@ import (
 	"github.com/spring-financial-group/peacock/pkg/utils/templates"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Options for the run command
@@ -36,16 +39,27 @@ type Options struct {
 	WebhookURL    string
 	WebhookToken  string
 	WebhookSecret string
+	WebhookFormat string
+
+	SMTP config.Email
+	SMS  config.SMS
+
+	FeathersConfig config.Feathers
 
 	DryRun            bool
 	CommentValidation bool
 	Subject           string
+	// MergeSHA is the commit the PR was merged as, it's the latest commit in the local instance
+	MergeSHA string
 
 	GitServerClient domain.SCM
 	Git             domain.Git
 	NotesUC         domain.ReleaseNotesUseCase
 	FeathersUC      domain.FeathersUseCase
 	Feathers        *models.Feathers
+
+	// pullRequest is the PR that was merged as the latest commit, it's only found when it's not a dry run
+	pullRequest *gogithub.PullRequest
 }
 
 var (
@@ -79,7 +93,7 @@ func NewCmdRun() *cobra.Command {
 	// Command specific flags
 	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "parses the messages and feathers, returning validation as a comment on the pr. Does not send messages. PR number is required for this. Default is false")
 	cmd.Flags().BoolVarP(&o.CommentValidation, "comment-validation", "", false, "posts a comment to the pr with the validation results if successful. Default is false.")
-	cmd.Flags().StringVarP(&o.Subject, "subject", "", "", "a subject to add to the messages for the handlers that require it. If empty then a subject will be generated.")
+	cmd.Flags().StringVarP(&o.Subject, "subject", "", "", "a subject to add to the messages for the handlers that require it, it can contain the same placeholders as the subject in the feathers. If empty then the subject in the feathers is used, or one is generated.")
 	return cmd
 }
 
@@ -97,6 +111,18 @@ func (o *Options) ParseEnvVars(cmd *cobra.Command) (err error) {
 		WebhookURL       string
 		WebhookAuthToken string
 		WebhookSecret    string
+		WebhookFormat    string
+		SMTPHost         string
+		SMTPPort         string
+		SMTPUsername     string
+		SMTPPassword     string
+		SMTPFrom         string
+		SMTPStartTLS     string
+		SMSURL           string
+		SMSAccountSID    string
+		SMSAuthToken     string
+		SMSFrom          string
+		ExtendsOwners    string
 	}{}
 
 	// Flags to overwrite default environment variable keys
@@ -110,6 +136,18 @@ func (o *Options) ParseEnvVars(cmd *cobra.Command) (err error) {
 	cmd.Flags().StringVarP(&keys.WebhookURL, "webhook-URL-key", "", "WEBHOOK_URL", "the environment variable key for the webhook URL")
 	cmd.Flags().StringVarP(&keys.WebhookAuthToken, "webhook-auth-token-key", "", "WEBHOOK_AUTH_TOKEN", "the environment variable key for the webhook auth token")
 	cmd.Flags().StringVarP(&keys.WebhookSecret, "webhook-HMAC-secret-key", "", "WEBHOOK_SECRET", "the environment variable key for the webhook HMAC secret")
+	cmd.Flags().StringVarP(&keys.WebhookFormat, "webhook-format-key", "", "WEBHOOK_FORMAT", "the environment variable key for the payload format of the webhook, one of legacy, cloudevents-structured or cloudevents-binary. If no env var is passed then default is legacy")
+	cmd.Flags().StringVarP(&keys.SMTPHost, "smtp-host-key", "", "SMTP_HOST", "the environment variable key for the host of the SMTP relay used to send emails")
+	cmd.Flags().StringVarP(&keys.SMTPPort, "smtp-port-key", "", "SMTP_PORT", "the environment variable key for the port of the SMTP relay. If no env var is passed then default is 587")
+	cmd.Flags().StringVarP(&keys.SMTPUsername, "smtp-username-key", "", "SMTP_USERNAME", "the environment variable key for the username used to authenticate with the SMTP relay")
+	cmd.Flags().StringVarP(&keys.SMTPPassword, "smtp-password-key", "", "SMTP_PASSWORD", "the environment variable key for the password used to authenticate with the SMTP relay")
+	cmd.Flags().StringVarP(&keys.SMTPFrom, "smtp-from-key", "", "SMTP_FROM", "the environment variable key for the address emails are sent from")
+	cmd.Flags().StringVarP(&keys.SMTPStartTLS, "smtp-starttls-key", "", "SMTP_STARTTLS", "the environment variable key for whether to upgrade the SMTP connection with STARTTLS. If no env var is passed then default is true")
+	cmd.Flags().StringVarP(&keys.SMSURL, "sms-url-key", "", "SMS_API_URL", "the environment variable key for the base URL of the Twilio compatible API used to send text messages. If no env var is passed then default is https://api.twilio.com")
+	cmd.Flags().StringVarP(&keys.SMSAccountSID, "sms-account-sid-key", "", "SMS_ACCOUNT_SID", "the environment variable key for the account SID used to authenticate with the SMS API")
+	cmd.Flags().StringVarP(&keys.SMSAuthToken, "sms-auth-token-key", "", "SMS_AUTH_TOKEN", "the environment variable key for the auth token used to authenticate with the SMS API")
+	cmd.Flags().StringVarP(&keys.SMSFrom, "sms-from-key", "", "SMS_FROM", "the environment variable key for the number text messages are sent from")
+	cmd.Flags().StringVarP(&keys.ExtendsOwners, "feathers-extends-owners-key", "", "FEATHERS_EXTENDS_OWNERS", "the environment variable key for the comma separated owners, besides the owner of the repository, whose feathers can be extended")
 
 	o.PRNumber = -1
 	if prNumber := os.Getenv(keys.PRNumber); prNumber != "" {
@@ -128,6 +166,42 @@ func (o *Options) ParseEnvVars(cmd *cobra.Command) (err error) {
 	o.WebhookURL = os.Getenv(keys.WebhookURL)
 	o.WebhookToken = os.Getenv(keys.WebhookAuthToken)
 	o.WebhookSecret = os.Getenv(keys.WebhookSecret)
+	o.WebhookFormat = os.Getenv(keys.WebhookFormat)
+
+	o.SMTP = config.Email{
+		Host:     os.Getenv(keys.SMTPHost),
+		Port:     587,
+		Username: os.Getenv(keys.SMTPUsername),
+		Password: os.Getenv(keys.SMTPPassword),
+		From:     os.Getenv(keys.SMTPFrom),
+		StartTLS: true,
+	}
+	if port := os.Getenv(keys.SMTPPort); port != "" {
+		o.SMTP.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return err
+		}
+	}
+	if startTLS := os.Getenv(keys.SMTPStartTLS); startTLS != "" {
+		o.SMTP.StartTLS, err = strconv.ParseBool(startTLS)
+		if err != nil {
+			return err
+		}
+	}
+
+	o.SMS = config.SMS{
+		URL:        sms.DefaultAPIURL,
+		AccountSID: os.Getenv(keys.SMSAccountSID),
+		AuthToken:  os.Getenv(keys.SMSAuthToken),
+		From:       os.Getenv(keys.SMSFrom),
+	}
+	if smsURL := os.Getenv(keys.SMSURL); smsURL != "" {
+		o.SMS.URL = smsURL
+	}
+
+	if owners := os.Getenv(keys.ExtendsOwners); owners != "" {
+		o.FeathersConfig.ExtendsOwners = strings.Split(owners, ",")
+	}
 	return nil
 }
 
@@ -151,7 +225,7 @@ func (o *Options) Run() error {
 
 	if o.Feathers == nil {
 		log.Info("Loading feathers from local instance")
-		o.Feathers, err = o.FeathersUC.GetFeathersFromFile()
+		o.Feathers, err = o.FeathersUC.GetFeathersFromFile(ctx, o.RepoOwner)
 		if err != nil {
 			err = errors.Wrapf(err, "failed to load feathers")
 			o.PostErrorToPR(ctx, err)
@@ -160,7 +234,7 @@ func (o *Options) Run() error {
 	}
 
 	log.Info("Parsing messages from pull request body")
-	messages, err := o.NotesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(*prBody, o.Feathers.Teams)
+	messages, err := o.NotesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(*prBody, o.Feathers)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to parse release notes from pull request")
 		o.PostErrorToPR(ctx, err)
@@ -189,17 +263,35 @@ func (o *Options) Run() error {
 		return nil
 	}
 
-	// Some message handlers use subjects, if one isn't passed then we should generate it
+	// Some message handlers use subjects, if one isn't passed then the one in the feathers is used. The placeholders are
+	// filled in by the message handler, which also generates a subject if there still isn't one.
 	if o.Subject == "" {
-		o.GenerateSubject()
+		o.Subject = o.Feathers.Config.Messages.Subject
 	}
 
-	log.Info("Sending messages")
-	err = o.NotesUC.SendReleaseNotes(o.Subject, messages)
+	// The details of the PR are used to fill in the message template & subject, & by the clients that link back to it
+	pr := models.PullRequestSummary{
+		PRNumber:  o.PRNumber,
+		RepoOwner: o.RepoOwner,
+		RepoName:  o.RepoName,
+		Title:     o.pullRequest.GetTitle(),
+		Author:    o.pullRequest.GetUser().GetLogin(),
+		MergedAt:  o.pullRequest.GetMergedAt(),
+		MergeSHA:  o.MergeSHA,
+	}
+	// The subject passed to the command takes the place of the one in the feathers, teams can still override it
+	messagesConfig := o.Feathers.Config.Messages
+	messagesConfig.Subject = o.Subject
+	messages, err = o.NotesUC.WrapReleaseNotes(messagesConfig, messages, pr)
 	if err != nil {
+		err = errors.Wrap(err, "failed to wrap release notes in message template")
+		o.PostErrorToPR(ctx, err)
 		return err
 	}
-	return nil
+
+	log.Info("Sending messages")
+	report := o.NotesUC.SendReleaseNotes(ctx, o.Subject, messages, pr)
+	return report.Err()
 }
 
 func (o *Options) GetPullRequestBody(ctx context.Context) (*string, error) {
@@ -217,7 +309,13 @@ func (o *Options) GetPullRequestBody(ctx context.Context) (*string, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get latest commit sha")
 		}
-		body, err = o.GitServerClient.GetPullRequestBodyFromCommit(ctx, o.RepoOwner, o.RepoName, sha)
+		o.pullRequest, err = o.GitServerClient.GetPullRequestFromCommit(ctx, o.RepoOwner, o.RepoName, sha)
+		// The latest commit is the one the PR was merged as, the number of the PR isn't passed when it's merged
+		o.MergeSHA = sha
+		if err == nil {
+			body = o.pullRequest.Body
+			o.PRNumber = o.pullRequest.GetNumber()
+		}
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get pull request")
@@ -225,10 +323,6 @@ func (o *Options) GetPullRequestBody(ctx context.Context) (*string, error) {
 	return body, nil
 }
 
-func (o *Options) GenerateSubject() {
-	o.Subject = fmt.Sprintf("New Release Notes for %s", o.RepoName)
-}
-
 // GetMessageBreakdown creates a breakdown of the messages found in the pr description if the messages have changed
 // since the last run
 func (o *Options) GetMessageBreakdown(ctx context.Context, messages []models.ReleaseNote) (string, error) {
@@ -239,7 +333,7 @@ func (o *Options) GetMessageBreakdown(ctx context.Context, messages []models.Rel
 	if !changed {
 		return "", nil
 	}
-	return o.NotesUC.GenerateBreakdown(messages, hash, len(o.Feathers.Teams.GetAllTeamNames()))
+	return o.NotesUC.GenerateBreakdown(messages, hash, len(o.Feathers.Teams.GetAllTeamNames()), nil, nil)
 }
 
 // HaveMessagesChanged checks if the messages have changed since the last time the breakdown was posted to the PR
@@ -326,7 +420,7 @@ func (o *Options) initialiseFlagsAndClients() (err error) {
 	}
 
 	if o.NotesUC == nil {
-		msgHandler := msgclients.NewMessageHandler(&config.MessageHandlers{
+		msgHandler, err := msgclients.NewMessageHandler(&config.MessageHandlers{
 			Slack: config.Slack{
 				Token: o.SlackToken,
 			},
@@ -334,13 +428,19 @@ func (o *Options) initialiseFlagsAndClients() (err error) {
 				URL:    o.WebhookURL,
 				Token:  o.WebhookToken,
 				Secret: o.WebhookSecret,
+				Format: o.WebhookFormat,
 			},
+			Email: o.SMTP,
+			SMS:   o.SMS,
 		})
-		o.NotesUC = releasenotesuc.NewUseCase(msgHandler)
+		if err != nil {
+			return err
+		}
+		o.NotesUC = releasenotesuc.NewUseCase(msgHandler, nil)
 	}
 
 	if o.FeathersUC == nil {
-		o.FeathersUC = feathers.NewUseCase()
+		o.FeathersUC = feathers.NewUseCase(&o.FeathersConfig, o.GitServerClient)
 	}
 	return nil
 }
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"github.com/google/go-github/v48/github"
 	"github.com/spring-financial-group/peacock/pkg/cmd/run"
+	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/spring-financial-group/peacock/pkg/domain/mocks"
 	"github.com/spring-financial-group/peacock/pkg/models"
 	"github.com/spring-financial-group/peacock/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 var (
@@ -193,6 +195,7 @@ func TestOptions_Run(t *testing.T) {
 	mockSCM := mocks.NewSCM(t)
 	mockGitClient := mocks.NewGit(t)
 	mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+	mergedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 
 	testCases := []struct {
 		name        string
@@ -220,6 +223,27 @@ func TestOptions_Run(t *testing.T) {
 			},
 			prBody: utils.NewPtr("# Peacock\r\n## ReleaseNote\n### Notify infrastructure\nTest Content"),
 		},
+		{
+			// The number of the PR isn't passed when it's merged so it's taken from the PR of the latest commit
+			name: "NonDryRunWithoutPRNumber",
+			opts: &run.Options{
+				PRNumber:          -1,
+				GitServerURL:      "https://github.com",
+				GitHubToken:       "testGitToken",
+				RepoOwner:         "spring-financial-group",
+				RepoName:          "peacock",
+				DryRun:            false,
+				CommentValidation: true,
+				SlackToken:        "testSlackToken",
+				Git:               mockGitClient,
+				GitServerClient:   mockSCM,
+				NotesUC:           mockNotesUC,
+				Feathers: &models.Feathers{
+					Teams: allTeams,
+				},
+			},
+			prBody: utils.NewPtr("# Peacock\r\n## ReleaseNote\n### Notify infrastructure\nTest Content"),
+		},
 		{
 			name: "DryRun",
 			opts: &run.Options{
@@ -257,20 +281,28 @@ func TestOptions_Run(t *testing.T) {
 
 		if tt.opts.DryRun {
 			mockNotesUC.On("GenerateHash", mockNotes).Return(mockHash, nil)
-			mockNotesUC.On("GenerateBreakdown", mockNotes, mockHash, len(allTeams)).Return(mockBreakdown, nil)
+			mockNotesUC.On("GenerateBreakdown", mockNotes, mockHash, len(allTeams), []string(nil), []domain.FeathersProblem(nil)).Return(mockBreakdown, nil)
 
 			mockSCM.On("GetPullRequestBodyFromPRNumber", mock.Anything, "spring-financial-group", "peacock", 1).Return(tt.prBody, nil).Once()
 			mockSCM.On("CommentOnPR", mock.Anything, "spring-financial-group", "peacock", 1, mock.AnythingOfType("string")).Return(nil).Once()
 			mockSCM.On("GetPRComments", mock.Anything, "spring-financial-group", "peacock", 1).Return(nil, nil)
 		} else {
 			mockGitClient.On("GetLatestCommitSHA", "").Return("SHA", nil)
-			mockSCM.On("GetPullRequestBodyFromCommit", mock.Anything, "spring-financial-group", "peacock", "SHA").Return(tt.prBody, nil).Once()
+			mockSCM.On("GetPullRequestFromCommit", mock.Anything, "spring-financial-group", "peacock", "SHA").Return(&github.PullRequest{
+				Number:   github.Int(1),
+				Title:    github.String("Add a feature"),
+				Body:     tt.prBody,
+				User:     &github.User{Login: github.String("author")},
+				MergedAt: &mergedAt,
+			}, nil).Once()
 		}
 
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", *tt.prBody, allTeams).Return(mockNotes, nil)
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", *tt.prBody, tt.opts.Feathers).Return(mockNotes, nil)
 
 		if !tt.opts.DryRun {
-			mockNotesUC.On("SendReleaseNotes", "New Release Notes for peacock", mockNotes).Return(nil).Once()
+			mockPR := models.PullRequestSummary{PRNumber: 1, RepoOwner: "spring-financial-group", RepoName: "peacock", Title: "Add a feature", Author: "author", MergedAt: mergedAt, MergeSHA: "SHA"}
+			mockNotesUC.On("WrapReleaseNotes", models.Messages{}, mockNotes, mockPR).Return(mockNotes, nil).Once()
+			mockNotesUC.On("SendReleaseNotes", mock.Anything, "", mockNotes, mockPR).Return(nil).Once()
 		}
 
 		t.Run(tt.name, func(t *testing.T) {
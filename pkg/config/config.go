@@ -4,6 +4,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/spring-financial-group/peacock/pkg/utils"
 	"os"
+	"time"
 )
 
 const (
@@ -15,6 +16,8 @@ type Config struct {
 	SCM             SCM
 	MessageHandlers MessageHandlers
 	DataSources     DataSources
+	Outbox          Outbox
+	Feathers        Feathers
 	Cors            Cors `yaml:"cors"`
 }
 
@@ -31,8 +34,11 @@ type SCM struct {
 }
 
 type MessageHandlers struct {
-	Slack   Slack
-	Webhook Webhook
+	Slack    Slack
+	Webhook  Webhook
+	Email    Email
+	SMS      SMS
+	Delivery Delivery
 }
 
 type Slack struct {
@@ -43,6 +49,53 @@ type Webhook struct {
 	URL    string `env:"WEBHOOK_URL"`
 	Token  string `env:"WEBHOOK_TOKEN"`
 	Secret string `env:"WEBHOOK_SECRET"`
+	// Format is the payload format of the requests, one of legacy, cloudevents-structured or cloudevents-binary
+	Format string `env:"WEBHOOK_FORMAT" env-default:"legacy"`
+}
+
+type Email struct {
+	Host     string `env:"SMTP_HOST"`
+	Port     int    `env:"SMTP_PORT" env-default:"587"`
+	Username string `env:"SMTP_USERNAME"`
+	Password string `env:"SMTP_PASSWORD"`
+	From     string `env:"SMTP_FROM"`
+	StartTLS bool   `env:"SMTP_STARTTLS" env-default:"true"`
+}
+
+// SMS configures the Twilio compatible API used to send text messages
+type SMS struct {
+	// URL is the base URL of the API, it can be changed to use another provider with a Twilio compatible API
+	URL        string `env:"SMS_API_URL" env-default:"https://api.twilio.com"`
+	AccountSID string `env:"SMS_ACCOUNT_SID"`
+	AuthToken  string `env:"SMS_AUTH_TOKEN"`
+	// From is the number the messages are sent from in E.164 format
+	From string `env:"SMS_FROM"`
+}
+
+// Delivery is the policy the message clients use when sending messages, zero values fall back to the defaults
+type Delivery struct {
+	// Timeout is how long a single attempt to send a message can take
+	Timeout time.Duration `env:"DELIVERY_TIMEOUT" env-default:"10s"`
+	// MaxAttempts is how many times a message is sent before giving up on a transient failure
+	MaxAttempts int `env:"DELIVERY_MAX_ATTEMPTS" env-default:"4"`
+	// InitialBackoff is the upper bound of the wait before the first retry, it doubles for each retry after that
+	InitialBackoff time.Duration `env:"DELIVERY_INITIAL_BACKOFF" env-default:"1s"`
+	// MaxBackoff caps the wait between retries unless the server asks us to wait longer
+	MaxBackoff time.Duration `env:"DELIVERY_MAX_BACKOFF" env-default:"30s"`
+}
+
+// Outbox configures the background delivery of release notes that failed to send
+type Outbox struct {
+	// PollInterval is how often the outbox is checked for deliveries that are due to be retried
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"30s"`
+	// MaxAttempts is how many times a delivery is attempted before it's dead-lettered
+	MaxAttempts int `env:"OUTBOX_MAX_ATTEMPTS" env-default:"10"`
+}
+
+// Feathers configures how the feathers of repositories are loaded
+type Feathers struct {
+	// ExtendsOwners are the owners, besides the owner of the repository itself, whose feathers can be extended
+	ExtendsOwners []string `env:"FEATHERS_EXTENDS_OWNERS" envSeparator:","`
 }
 
 type Cors struct {
@@ -1,9 +1,58 @@
 package domain
 
-import "github.com/spring-financial-group/peacock/pkg/models"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
+
+// Feathers problem severities
+const (
+	ErrorSeverity   = Severity("error")
+	WarningSeverity = Severity("warning")
+)
+
+// Severity is whether a problem with the feathers stops them from being used
+type Severity string
 
 type FeathersUseCase interface {
-	GetFeathersFromFile() (*models.Feathers, error)
-	GetFeathersFromBytes(data []byte) (*models.Feathers, error)
+	// GetFeathersFromFile loads the feathers of the local repository, merging them over any feathers they extend. The
+	// owner is the owner of the repository, feathers can only extend the feathers of the same owner or of the owners
+	// allowed in the config.
+	GetFeathersFromFile(ctx context.Context, owner string) (*models.Feathers, error)
+	// GetFeathersFromBytes parses the feathers of a repository of the owner, merging them over any feathers they extend.
+	// The warnings found when validating the feathers are returned with them.
+	GetFeathersFromBytes(ctx context.Context, owner string, data []byte) (*models.Feathers, []FeathersProblem, error)
 	ValidateFeathers(f *models.Feathers) error
 }
+
+// FeathersProblem is a problem found when validating feathers. The line & column are its position in the feathers file,
+// they're zero when the position isn't known, e.g. when the problem is in feathers that are extended.
+type FeathersProblem struct {
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+func (p FeathersProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", p.Line, p.Column, p.Severity, p.Message)
+}
+
+// ErrInvalidFeathers is returned when feathers have at least one error, it holds every problem found with them
+type ErrInvalidFeathers struct {
+	Problems []FeathersProblem
+}
+
+func (e *ErrInvalidFeathers) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return fmt.Sprintf("invalid feathers:\n%s", strings.Join(problems, "\n"))
+}
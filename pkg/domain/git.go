@@ -3,10 +3,11 @@ package domain
 import (
 	"context"
 	"github.com/google/go-github/v48/github"
+	"github.com/spring-financial-group/peacock/pkg/models"
 )
 
 const (
-	GitHubURL = "https://github.com"
+	GitHubURL = models.GitHubURL
 )
 
 // Repository status constants
@@ -53,10 +54,20 @@ type SCM interface {
 	DeleteUsersComments(ctx context.Context, owner, repoName string, prNumber int) error
 	// CreatePeacockCommitStatus creates a commit status on a commit
 	CreatePeacockCommitStatus(ctx context.Context, owner, repoName, ref string, state State, statusContext string) error
+	// CreatePeacockCommitStatusWithDescription creates a commit status on a commit with a description in place of the
+	// default for the status context
+	CreatePeacockCommitStatusWithDescription(ctx context.Context, owner, repoName, ref string, state State, statusContext, description string) error
 	// GetLatestCommitSHAInBranch returns the most recent commit in a branch
 	GetLatestCommitSHAInBranch(ctx context.Context, owner, repoName, branch string) (string, error)
 	// HandleError handles an error by commenting on the PR and creating a commit status on the given SHA
 	HandleError(ctx context.Context, statusContext, owner, repoName string, prNumber int, headSHA, prOwner string, err error) error
+	// GetPullRequest returns a pull request from pr number
+	GetPullRequest(ctx context.Context, owner, repoName string, prNumber int) (*github.PullRequest, error)
+	// GetPullRequestFromCommit returns the pull request containing a commit, if more than one does then the most recently
+	// merged is returned
+	GetPullRequestFromCommit(ctx context.Context, owner, repoName, sha string) (*github.PullRequest, error)
+	// GetUserPermission returns the permission level (admin, write, read or none) of a user in a repository
+	GetUserPermission(ctx context.Context, owner, repoName, user string) (string, error)
 	// GetFilesChangedFromPR returns the files changed files in the given pr
 	GetFilesChangedFromPR(ctx context.Context, owner string, repoName string, prNumber int) ([]*github.CommitFile, error)
 }
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
+
+type DeliveryLedgerUseCase interface {
+	// Claim adds an entry to the ledger for each address the release notes are to be delivered to before they're sent,
+	// so that a redelivered webhook that's handled at the same time doesn't send them too. The notes limited to the
+	// addresses that were claimed are returned along with the entries of the deliveries that were already made or claimed.
+	Claim(ctx context.Context, notes []models.ReleaseNote, pr models.PullRequestSummary) ([]models.ReleaseNote, []models.LedgerEntry, error)
+	// Record marks the delivered messages of the release notes as delivered in the ledger
+	Record(ctx context.Context, notes []models.ReleaseNote, pr models.PullRequestSummary, refs []models.MessageReference) error
+	// Release removes the claims of the messages of the release notes that won't be delivered, so that they can be sent
+	// again
+	Release(ctx context.Context, notes []models.ReleaseNote, pr models.PullRequestSummary, refs []models.MessageReference) error
+}
+
+type DeliveryLedgerRepository interface {
+	// Claim adds the entries that aren't already in the ledger, returning the entries that were added
+	Claim(ctx context.Context, entries []models.LedgerEntry) ([]models.LedgerEntry, error)
+	// Save marks the entries as delivered, entries that aren't in the ledger are added
+	Save(ctx context.Context, entries []models.LedgerEntry) error
+	// Delete removes the entries that haven't been delivered from the ledger
+	Delete(ctx context.Context, entries []models.LedgerEntry) error
+	// GetByPullRequest returns the entries of all the deliveries made or claimed for a pull request
+	GetByPullRequest(ctx context.Context, pr models.PullRequestSummary) ([]models.LedgerEntry, error)
+}
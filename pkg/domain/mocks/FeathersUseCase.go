@@ -3,8 +3,13 @@
 package mocks
 
 import (
-	models "github.com/spring-financial-group/peacock/pkg/models"
+	context "context"
+
+	domain "github.com/spring-financial-group/peacock/pkg/domain"
+
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/spring-financial-group/peacock/pkg/models"
 )
 
 // FeathersUseCase is an autogenerated mock type for the FeathersUseCase type
@@ -12,39 +17,48 @@ type FeathersUseCase struct {
 	mock.Mock
 }
 
-// GetFeathersFromBytes provides a mock function with given fields: data
-func (_m *FeathersUseCase) GetFeathersFromBytes(data []byte) (*models.Feathers, error) {
-	ret := _m.Called(data)
+// GetFeathersFromBytes provides a mock function with given fields: ctx, owner, data
+func (_m *FeathersUseCase) GetFeathersFromBytes(ctx context.Context, owner string, data []byte) (*models.Feathers, []domain.FeathersProblem, error) {
+	ret := _m.Called(ctx, owner, data)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetFeathersFromBytes")
 	}
 
 	var r0 *models.Feathers
-	var r1 error
-	if rf, ok := ret.Get(0).(func([]byte) (*models.Feathers, error)); ok {
-		return rf(data)
+	var r1 []domain.FeathersProblem
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (*models.Feathers, []domain.FeathersProblem, error)); ok {
+		return rf(ctx, owner, data)
 	}
-	if rf, ok := ret.Get(0).(func([]byte) *models.Feathers); ok {
-		r0 = rf(data)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) *models.Feathers); ok {
+		r0 = rf(ctx, owner, data)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Feathers)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func([]byte) error); ok {
-		r1 = rf(data)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) []domain.FeathersProblem); ok {
+		r1 = rf(ctx, owner, data)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]domain.FeathersProblem)
+		}
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, string, []byte) error); ok {
+		r2 = rf(ctx, owner, data)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// GetFeathersFromFile provides a mock function with no fields
-func (_m *FeathersUseCase) GetFeathersFromFile() (*models.Feathers, error) {
-	ret := _m.Called()
+// GetFeathersFromFile provides a mock function with given fields: ctx, owner
+func (_m *FeathersUseCase) GetFeathersFromFile(ctx context.Context, owner string) (*models.Feathers, error) {
+	ret := _m.Called(ctx, owner)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetFeathersFromFile")
@@ -52,19 +66,19 @@ func (_m *FeathersUseCase) GetFeathersFromFile() (*models.Feathers, error) {
 
 	var r0 *models.Feathers
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (*models.Feathers, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Feathers, error)); ok {
+		return rf(ctx, owner)
 	}
-	if rf, ok := ret.Get(0).(func() *models.Feathers); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Feathers); ok {
+		r0 = rf(ctx, owner)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Feathers)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2,26 +2,52 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/spring-financial-group/peacock/pkg/models"
+)
 
 // MessageClient is an autogenerated mock type for the MessageClient type
 type MessageClient struct {
 	mock.Mock
 }
 
-// Send provides a mock function with given fields: content, subject, addresses
-func (_m *MessageClient) Send(content string, subject string, addresses []string) error {
-	ret := _m.Called(content, subject, addresses)
+// MaxContentLength provides a mock function with no fields
+func (_m *MessageClient) MaxContentLength() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for MaxContentLength")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Send provides a mock function with given fields: ctx, msg, addresses
+func (_m *MessageClient) Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult {
+	ret := _m.Called(ctx, msg, addresses)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Send")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, []string) error); ok {
-		r0 = rf(content, subject, addresses)
+	var r0 []models.DeliveryResult
+	if rf, ok := ret.Get(0).(func(context.Context, models.Message, []string) []models.DeliveryResult); ok {
+		r0 = rf(ctx, msg, addresses)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DeliveryResult)
+		}
 	}
 
 	return r0
@@ -3,8 +3,11 @@
 package mocks
 
 import (
-	models "github.com/spring-financial-group/peacock/pkg/models"
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/spring-financial-group/peacock/pkg/models"
 )
 
 // MessageHandler is an autogenerated mock type for the MessageHandler type
@@ -12,17 +15,17 @@ type MessageHandler struct {
 	mock.Mock
 }
 
-// IsInitialised provides a mock function with given fields: contactType
-func (_m *MessageHandler) IsInitialised(contactType string) bool {
-	ret := _m.Called(contactType)
+// IsInitialised provides a mock function with given fields: channel
+func (_m *MessageHandler) IsInitialised(channel models.Channel) bool {
+	ret := _m.Called(channel)
 
 	if len(ret) == 0 {
 		panic("no return value specified for IsInitialised")
 	}
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(contactType)
+	if rf, ok := ret.Get(0).(func(models.Channel) bool); ok {
+		r0 = rf(channel)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
@@ -30,24 +33,56 @@ func (_m *MessageHandler) IsInitialised(contactType string) bool {
 	return r0
 }
 
-// SendReleaseNotes provides a mock function with given fields: subject, notes
-func (_m *MessageHandler) SendReleaseNotes(subject string, notes []models.ReleaseNote) error {
-	ret := _m.Called(subject, notes)
+// SendReleaseNotes provides a mock function with given fields: ctx, subject, notes, pr
+func (_m *MessageHandler) SendReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) models.DeliveryReport {
+	ret := _m.Called(ctx, subject, notes, pr)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SendReleaseNotes")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, []models.ReleaseNote) error); ok {
-		r0 = rf(subject, notes)
+	var r0 models.DeliveryReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, models.PullRequestSummary) models.DeliveryReport); ok {
+		r0 = rf(ctx, subject, notes, pr)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(models.DeliveryReport)
+		}
 	}
 
 	return r0
 }
 
+// UpdateReleaseNotes provides a mock function with given fields: ctx, subject, notes, refs, pr
+func (_m *MessageHandler) UpdateReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, refs []models.MessageReference, pr models.PullRequestSummary) ([]models.MessageReference, error) {
+	ret := _m.Called(ctx, subject, notes, refs, pr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateReleaseNotes")
+	}
+
+	var r0 []models.MessageReference
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, []models.MessageReference, models.PullRequestSummary) ([]models.MessageReference, error)); ok {
+		return rf(ctx, subject, notes, refs, pr)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, []models.MessageReference, models.PullRequestSummary) []models.MessageReference); ok {
+		r0 = rf(ctx, subject, notes, refs, pr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.MessageReference)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []models.ReleaseNote, []models.MessageReference, models.PullRequestSummary) error); ok {
+		r1 = rf(ctx, subject, notes, refs, pr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewMessageHandler creates a new instance of MessageHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMessageHandler(t interface {
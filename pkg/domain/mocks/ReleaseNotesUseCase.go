@@ -3,8 +3,13 @@
 package mocks
 
 import (
-	models "github.com/spring-financial-group/peacock/pkg/models"
+	context "context"
+
+	domain "github.com/spring-financial-group/peacock/pkg/domain"
+
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/spring-financial-group/peacock/pkg/models"
 )
 
 // ReleaseNotesUseCase is an autogenerated mock type for the ReleaseNotesUseCase type
@@ -12,6 +17,24 @@ type ReleaseNotesUseCase struct {
 	mock.Mock
 }
 
+// AddSentMessages provides a mock function with given fields: ctx, pr, refs
+func (_m *ReleaseNotesUseCase) AddSentMessages(ctx context.Context, pr models.PullRequestSummary, refs []models.MessageReference) error {
+	ret := _m.Called(ctx, pr, refs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddSentMessages")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PullRequestSummary, []models.MessageReference) error); ok {
+		r0 = rf(ctx, pr, refs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AppendReleaseNotesToExistingMarkdown provides a mock function with given fields: existingMarkdown, releaseNotesToAppend
 func (_m *ReleaseNotesUseCase) AppendReleaseNotesToExistingMarkdown(existingMarkdown string, releaseNotesToAppend []models.ReleaseNote) (string, error) {
 	ret := _m.Called(existingMarkdown, releaseNotesToAppend)
@@ -40,9 +63,27 @@ func (_m *ReleaseNotesUseCase) AppendReleaseNotesToExistingMarkdown(existingMark
 	return r0, r1
 }
 
-// GenerateBreakdown provides a mock function with given fields: notes, hash, totalTeams
-func (_m *ReleaseNotesUseCase) GenerateBreakdown(notes []models.ReleaseNote, hash string, totalTeams int) (string, error) {
-	ret := _m.Called(notes, hash, totalTeams)
+// CreateSentReleaseNotes provides a mock function with given fields: ctx, subject, notes, pr
+func (_m *ReleaseNotesUseCase) CreateSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) error {
+	ret := _m.Called(ctx, subject, notes, pr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSentReleaseNotes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, models.PullRequestSummary) error); ok {
+		r0 = rf(ctx, subject, notes, pr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GenerateBreakdown provides a mock function with given fields: notes, hash, totalTeams, missingTeams, warnings
+func (_m *ReleaseNotesUseCase) GenerateBreakdown(notes []models.ReleaseNote, hash string, totalTeams int, missingTeams []string, warnings []domain.FeathersProblem) (string, error) {
+	ret := _m.Called(notes, hash, totalTeams, missingTeams, warnings)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateBreakdown")
@@ -50,17 +91,17 @@ func (_m *ReleaseNotesUseCase) GenerateBreakdown(notes []models.ReleaseNote, has
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func([]models.ReleaseNote, string, int) (string, error)); ok {
-		return rf(notes, hash, totalTeams)
+	if rf, ok := ret.Get(0).(func([]models.ReleaseNote, string, int, []string, []domain.FeathersProblem) (string, error)); ok {
+		return rf(notes, hash, totalTeams, missingTeams, warnings)
 	}
-	if rf, ok := ret.Get(0).(func([]models.ReleaseNote, string, int) string); ok {
-		r0 = rf(notes, hash, totalTeams)
+	if rf, ok := ret.Get(0).(func([]models.ReleaseNote, string, int, []string, []domain.FeathersProblem) string); ok {
+		r0 = rf(notes, hash, totalTeams, missingTeams, warnings)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func([]models.ReleaseNote, string, int) error); ok {
-		r1 = rf(notes, hash, totalTeams)
+	if rf, ok := ret.Get(1).(func([]models.ReleaseNote, string, int, []string, []domain.FeathersProblem) error); ok {
+		r1 = rf(notes, hash, totalTeams, missingTeams, warnings)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -114,9 +155,9 @@ func (_m *ReleaseNotesUseCase) GetMarkdownFromReleaseNotes(notes []models.Releas
 	return r0
 }
 
-// GetReleaseNotesFromMarkdownAndTeamsInFeathers provides a mock function with given fields: markdown, teamsInFeathers
-func (_m *ReleaseNotesUseCase) GetReleaseNotesFromMarkdownAndTeamsInFeathers(markdown string, teamsInFeathers models.Teams) ([]models.ReleaseNote, error) {
-	ret := _m.Called(markdown, teamsInFeathers)
+// GetReleaseNotesFromMarkdownAndTeamsInFeathers provides a mock function with given fields: markdown, feathers
+func (_m *ReleaseNotesUseCase) GetReleaseNotesFromMarkdownAndTeamsInFeathers(markdown string, feathers *models.Feathers) ([]models.ReleaseNote, error) {
+	ret := _m.Called(markdown, feathers)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetReleaseNotesFromMarkdownAndTeamsInFeathers")
@@ -124,19 +165,19 @@ func (_m *ReleaseNotesUseCase) GetReleaseNotesFromMarkdownAndTeamsInFeathers(mar
 
 	var r0 []models.ReleaseNote
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, models.Teams) ([]models.ReleaseNote, error)); ok {
-		return rf(markdown, teamsInFeathers)
+	if rf, ok := ret.Get(0).(func(string, *models.Feathers) ([]models.ReleaseNote, error)); ok {
+		return rf(markdown, feathers)
 	}
-	if rf, ok := ret.Get(0).(func(string, models.Teams) []models.ReleaseNote); ok {
-		r0 = rf(markdown, teamsInFeathers)
+	if rf, ok := ret.Get(0).(func(string, *models.Feathers) []models.ReleaseNote); ok {
+		r0 = rf(markdown, feathers)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.ReleaseNote)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, models.Teams) error); ok {
-		r1 = rf(markdown, teamsInFeathers)
+	if rf, ok := ret.Get(1).(func(string, *models.Feathers) error); ok {
+		r1 = rf(markdown, feathers)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -181,17 +222,17 @@ func (_m *ReleaseNotesUseCase) ParseReleaseNoteFromMarkdown(markdown string, san
 	return r0, r1, r2
 }
 
-// PopulateTeamsInReleaseNotes provides a mock function with given fields: releaseNotes, teamsInFeathers
-func (_m *ReleaseNotesUseCase) PopulateTeamsInReleaseNotes(releaseNotes []models.ReleaseNote, teamsInFeathers models.Teams) error {
-	ret := _m.Called(releaseNotes, teamsInFeathers)
+// PopulateTeamsInReleaseNotes provides a mock function with given fields: releaseNotes, feathers
+func (_m *ReleaseNotesUseCase) PopulateTeamsInReleaseNotes(releaseNotes []models.ReleaseNote, feathers *models.Feathers) error {
+	ret := _m.Called(releaseNotes, feathers)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PopulateTeamsInReleaseNotes")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]models.ReleaseNote, models.Teams) error); ok {
-		r0 = rf(releaseNotes, teamsInFeathers)
+	if rf, ok := ret.Get(0).(func([]models.ReleaseNote, *models.Feathers) error); ok {
+		r0 = rf(releaseNotes, feathers)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -199,17 +240,55 @@ func (_m *ReleaseNotesUseCase) PopulateTeamsInReleaseNotes(releaseNotes []models
 	return r0
 }
 
-// SendReleaseNotes provides a mock function with given fields: subject, notes
-func (_m *ReleaseNotesUseCase) SendReleaseNotes(subject string, notes []models.ReleaseNote) error {
-	ret := _m.Called(subject, notes)
+// SaveSentReleaseNotes provides a mock function with given fields: ctx, subject, notes, refs, pr
+func (_m *ReleaseNotesUseCase) SaveSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, refs []models.MessageReference, pr models.PullRequestSummary) error {
+	ret := _m.Called(ctx, subject, notes, refs, pr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSentReleaseNotes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, []models.MessageReference, models.PullRequestSummary) error); ok {
+		r0 = rf(ctx, subject, notes, refs, pr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendReleaseNotes provides a mock function with given fields: ctx, subject, notes, pr
+func (_m *ReleaseNotesUseCase) SendReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) models.DeliveryReport {
+	ret := _m.Called(ctx, subject, notes, pr)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SendReleaseNotes")
 	}
 
+	var r0 models.DeliveryReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, models.PullRequestSummary) models.DeliveryReport); ok {
+		r0 = rf(ctx, subject, notes, pr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(models.DeliveryReport)
+		}
+	}
+
+	return r0
+}
+
+// UpdateSentReleaseNotes provides a mock function with given fields: ctx, subject, notes, pr
+func (_m *ReleaseNotesUseCase) UpdateSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) error {
+	ret := _m.Called(ctx, subject, notes, pr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSentReleaseNotes")
+	}
+
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, []models.ReleaseNote) error); ok {
-		r0 = rf(subject, notes)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []models.ReleaseNote, models.PullRequestSummary) error); ok {
+		r0 = rf(ctx, subject, notes, pr)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -217,6 +296,36 @@ func (_m *ReleaseNotesUseCase) SendReleaseNotes(subject string, notes []models.R
 	return r0
 }
 
+// WrapReleaseNotes provides a mock function with given fields: messages, notes, pr
+func (_m *ReleaseNotesUseCase) WrapReleaseNotes(messages models.Messages, notes []models.ReleaseNote, pr models.PullRequestSummary) ([]models.ReleaseNote, error) {
+	ret := _m.Called(messages, notes, pr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WrapReleaseNotes")
+	}
+
+	var r0 []models.ReleaseNote
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.Messages, []models.ReleaseNote, models.PullRequestSummary) ([]models.ReleaseNote, error)); ok {
+		return rf(messages, notes, pr)
+	}
+	if rf, ok := ret.Get(0).(func(models.Messages, []models.ReleaseNote, models.PullRequestSummary) []models.ReleaseNote); ok {
+		r0 = rf(messages, notes, pr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ReleaseNote)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.Messages, []models.ReleaseNote, models.PullRequestSummary) error); ok {
+		r1 = rf(messages, notes, pr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewReleaseNotesUseCase creates a new instance of ReleaseNotesUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewReleaseNotesUseCase(t interface {
@@ -70,6 +70,24 @@ func (_m *SCM) CreatePeacockCommitStatus(ctx context.Context, owner string, repo
 	return r0
 }
 
+// CreatePeacockCommitStatusWithDescription provides a mock function with given fields: ctx, owner, repoName, ref, state, statusContext, description
+func (_m *SCM) CreatePeacockCommitStatusWithDescription(ctx context.Context, owner string, repoName string, ref string, state domain.State, statusContext string, description string) error {
+	ret := _m.Called(ctx, owner, repoName, ref, state, statusContext, description)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePeacockCommitStatusWithDescription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, domain.State, string, string) error); ok {
+		r0 = rf(ctx, owner, repoName, ref, state, statusContext, description)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteUsersComments provides a mock function with given fields: ctx, owner, repoName, prNumber
 func (_m *SCM) DeleteUsersComments(ctx context.Context, owner string, repoName string, prNumber int) error {
 	ret := _m.Called(ctx, owner, repoName, prNumber)
@@ -275,6 +293,36 @@ func (_m *SCM) GetPRCommentsByUser(ctx context.Context, owner string, repoName s
 	return r0, r1
 }
 
+// GetPullRequest provides a mock function with given fields: ctx, owner, repoName, prNumber
+func (_m *SCM) GetPullRequest(ctx context.Context, owner string, repoName string, prNumber int) (*github.PullRequest, error) {
+	ret := _m.Called(ctx, owner, repoName, prNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPullRequest")
+	}
+
+	var r0 *github.PullRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) (*github.PullRequest, error)); ok {
+		return rf(ctx, owner, repoName, prNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *github.PullRequest); ok {
+		r0 = rf(ctx, owner, repoName, prNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.PullRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, owner, repoName, prNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPullRequestBodyFromCommit provides a mock function with given fields: ctx, owner, repoName, sha
 func (_m *SCM) GetPullRequestBodyFromCommit(ctx context.Context, owner string, repoName string, sha string) (*string, error) {
 	ret := _m.Called(ctx, owner, repoName, sha)
@@ -335,6 +383,64 @@ func (_m *SCM) GetPullRequestBodyFromPRNumber(ctx context.Context, owner string,
 	return r0, r1
 }
 
+// GetPullRequestFromCommit provides a mock function with given fields: ctx, owner, repoName, sha
+func (_m *SCM) GetPullRequestFromCommit(ctx context.Context, owner string, repoName string, sha string) (*github.PullRequest, error) {
+	ret := _m.Called(ctx, owner, repoName, sha)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPullRequestFromCommit")
+	}
+
+	var r0 *github.PullRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*github.PullRequest, error)); ok {
+		return rf(ctx, owner, repoName, sha)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *github.PullRequest); ok {
+		r0 = rf(ctx, owner, repoName, sha)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.PullRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, owner, repoName, sha)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserPermission provides a mock function with given fields: ctx, owner, repoName, user
+func (_m *SCM) GetUserPermission(ctx context.Context, owner string, repoName string, user string) (string, error) {
+	ret := _m.Called(ctx, owner, repoName, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserPermission")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (string, error)); ok {
+		return rf(ctx, owner, repoName, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = rf(ctx, owner, repoName, user)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, owner, repoName, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // HandleError provides a mock function with given fields: ctx, statusContext, owner, repoName, prNumber, headSHA, prOwner, err
 func (_m *SCM) HandleError(ctx context.Context, statusContext string, owner string, repoName string, prNumber int, headSHA string, prOwner string, err error) error {
 	ret := _m.Called(ctx, statusContext, owner, repoName, prNumber, headSHA, prOwner, err)
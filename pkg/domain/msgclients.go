@@ -1,13 +1,53 @@
 package domain
 
-import "github.com/spring-financial-group/peacock/pkg/models"
+import (
+	"context"
+
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
 
 type MessageHandler interface {
-	SendReleaseNotes(subject string, notes []models.ReleaseNote) error
-	IsInitialised(contactType string) bool
+	// SendReleaseNotes sends each release note to the addresses of its teams, reporting the outcome for every address
+	SendReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) models.DeliveryReport
+	// UpdateReleaseNotes edits previously sent messages, messages for a note in notes are updated with its content and
+	// all others are deleted. The references of the messages that still exist are returned.
+	UpdateReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, refs []models.MessageReference, pr models.PullRequestSummary) ([]models.MessageReference, error)
+	// IsInitialised returns whether release notes can be sent via the channel, i.e. its communication method has been
+	// configured
+	IsInitialised(channel models.Channel) bool
 }
 
 type MessageClient interface {
-	// Send sends a message to multiple addresses with a subject
-	Send(content, subject string, addresses []string) error
+	// Send sends a message to multiple addresses, returning a result for each address in the same order. A failure to
+	// send to one address doesn't stop the message being sent to the others.
+	Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult
+	// MaxContentLength is the longest content that can be sent in a single message, 0 if there is no limit
+	MaxContentLength() int
+}
+
+// ContentSplitter is implemented by a MessageClient with limits on the content of a message other than its length
+type ContentSplitter interface {
+	// SplitContent splits the content into chunks that can each be sent in a single message, content that already fits
+	// is returned unchanged as the only chunk
+	SplitContent(content string) []string
+}
+
+// ThreadedMessageClient is a MessageClient that can group messages into a thread under a parent message
+type ThreadedMessageClient interface {
+	MessageClient
+	// SendThread posts the parent message to each address followed by the replies in its thread. The results are
+	// returned per address with the parent first and then the replies in order, if the parent fails then so do the
+	// replies.
+	SendThread(ctx context.Context, parent models.Message, replies []models.Message, addresses []string) []models.DeliveryResult
+	// Reply posts a message in the thread of a sent message
+	Reply(ctx context.Context, msg models.Message, parent models.MessageReference) models.DeliveryResult
+}
+
+// EditableMessageClient is a MessageClient whose messages can be changed after they have been sent
+type EditableMessageClient interface {
+	MessageClient
+	// Update replaces the content of a sent message
+	Update(ctx context.Context, msg models.Message, ref models.MessageReference) error
+	// Delete removes a sent message
+	Delete(ctx context.Context, ref models.MessageReference) error
 }
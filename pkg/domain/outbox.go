@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
+
+// ErrLeaseLost is returned when a job is updated by a worker whose lock has expired & been claimed by another worker
+var ErrLeaseLost = errors.New("lease of the job has been lost")
+
+type OutboxUseCase interface {
+	// Deliver writes a job to the outbox for each contact type used by the release notes and then makes the first
+	// attempt to send them. Jobs that fail with transient errors are left in the outbox to be retried. If the outbox
+	// can't be written to the notes are still sent once, without retries.
+	Deliver(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) (models.DeliveryReport, error)
+	// DeliverDue sends the jobs in the outbox that are due to be retried, returning how many were attempted
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+type OutboxRepository interface {
+	// Insert adds the jobs to the outbox
+	Insert(ctx context.Context, jobs []models.DeliveryJob) error
+	// ClaimNext locks the pending job that has been due the longest so that no other worker sends it, giving it a new
+	// lease token, or returns nil if there are no jobs due
+	ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*models.DeliveryJob, error)
+	// Update replaces a job in the outbox if it still has the lease token it was claimed with, otherwise it returns
+	// ErrLeaseLost
+	Update(ctx context.Context, job models.DeliveryJob) error
+}
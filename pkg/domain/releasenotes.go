@@ -1,25 +1,52 @@
 package domain
 
 import (
+	"context"
+
 	"github.com/spring-financial-group/peacock/pkg/models"
 )
 
 type ReleaseNotesUseCase interface {
 	// GetReleaseNotesFromMarkdownAndTeamsInFeathers parses release notes from a markdown string attaching the corresponding teams from feathers
-	GetReleaseNotesFromMarkdownAndTeamsInFeathers(markdown string, teamsInFeathers models.Teams) ([]models.ReleaseNote, error)
-	// PopulateTeamsInReleaseNotes populates the teams in the release notes with the corresponding teams in feathers
-	PopulateTeamsInReleaseNotes(releaseNotes []models.ReleaseNote, teamsInFeathers models.Teams) error
+	GetReleaseNotesFromMarkdownAndTeamsInFeathers(markdown string, feathers *models.Feathers) ([]models.ReleaseNote, error)
+	// PopulateTeamsInReleaseNotes populates the teams in the release notes with the corresponding teams in feathers,
+	// groups are expanded to the teams in them
+	PopulateTeamsInReleaseNotes(releaseNotes []models.ReleaseNote, feathers *models.Feathers) error
 	// ParseReleaseNoteFromMarkdown parses release notes from a markdown string
 	ParseReleaseNoteFromMarkdown(markdown string, sanitise bool) (preamble string, notes []models.ReleaseNote, err error)
 	// GetMarkdownFromReleaseNotes generates a markdown string from a slice of release notes
 	GetMarkdownFromReleaseNotes(notes []models.ReleaseNote) string
 	// GenerateHash generates a SHA256 hash of the json of a slice of release notes
 	GenerateHash(messages []models.ReleaseNote) (string, error)
-	// GenerateBreakdown generates a markdown string breaking down the release notes
-	GenerateBreakdown(notes []models.ReleaseNote, hash string, totalTeams int) (string, error)
-	// SendReleaseNotes sends release notes to their respective teams
-	SendReleaseNotes(subject string, notes []models.ReleaseNote) error
+	// GenerateBreakdown generates a markdown string breaking down the release notes, suggesting a Notify header for the
+	// missing teams if there are any & listing any warnings about the feathers
+	GenerateBreakdown(notes []models.ReleaseNote, hash string, totalTeams int, missingTeams []string, warnings []FeathersProblem) (string, error)
+	// WrapReleaseNotes wraps the content of each release note with the message template from the feathers
+	WrapReleaseNotes(messages models.Messages, notes []models.ReleaseNote, pr models.PullRequestSummary) ([]models.ReleaseNote, error)
+	// SendReleaseNotes sends release notes to their respective teams, reporting the outcome for each message
+	SendReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) models.DeliveryReport
+	// CreateSentReleaseNotes records the release notes about to be sent for a pull request, a record that already exists
+	// is kept along with the messages it has
+	CreateSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) error
+	// SaveSentReleaseNotes records the messages that release notes were sent as so that they can be edited later
+	SaveSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, refs []models.MessageReference, pr models.PullRequestSummary) error
+	// AddSentMessages adds the messages to the record of the release notes sent for a pull request
+	AddSentMessages(ctx context.Context, pr models.PullRequestSummary, refs []models.MessageReference) error
+	// UpdateSentReleaseNotes edits the messages previously sent for a pull request to match its current release notes,
+	// updating notes whose content has changed and deleting notes that have been removed
+	UpdateSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) error
 	// AppendReleaseNotesToExistingMarkdown appends release notes to an existing markdown string merging notes by team if possible.
 	// If a note is not mergable, it will be appended as a new note. Order of the existing notes is preserved.
 	AppendReleaseNotesToExistingMarkdown(existingMarkdown string, releaseNotesToAppend []models.ReleaseNote) (string, error)
 }
+
+type ReleaseNotesRepository interface {
+	// Save creates or replaces the record of the release notes sent for a pull request
+	Save(ctx context.Context, sent models.SentReleaseNotes) error
+	// Create creates the record of the release notes sent for a pull request, unless one already exists
+	Create(ctx context.Context, sent models.SentReleaseNotes) error
+	// AddMessages appends the messages to the record of the release notes sent for a pull request
+	AddMessages(ctx context.Context, pr models.PullRequestSummary, refs []models.MessageReference) error
+	// GetByPullRequest returns the release notes sent for a pull request, or nil if none have been sent
+	GetByPullRequest(ctx context.Context, pr models.PullRequestSummary) (*models.SentReleaseNotes, error)
+}
@@ -0,0 +1,248 @@
+package feathers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// resolveFeathers parses the feathers of a repository of the owner & merges them over the feathers that they extend. The
+// chain is the sources of the feathers already being resolved, so that feathers that extend themselves are found. The
+// warnings are the fields of the extended feathers that are overridden.
+func (uc *UseCase) resolveFeathers(ctx context.Context, owner string, data []byte, chain []string) (*models.Feathers, []domain.FeathersProblem, error) {
+	feathers := new(models.Feathers)
+	err := yaml.Unmarshal(data, &feathers)
+	if err != nil {
+		return nil, nil, err
+	}
+	if feathers.Extends == "" {
+		return feathers, nil, nil
+	}
+
+	source, err := models.ParseFeathersSource(feathers.Extends)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The position of problems is only known for the feathers of the repository, not those that are extended
+	var root *yaml.Node
+	if len(chain) == 0 {
+		var document yaml.Node
+		if err = yaml.Unmarshal(data, &document); err == nil {
+			root = documentRoot(&document)
+		}
+	}
+	// Peacock's token can read the repositories of other owners, so feathers can't use it to read any of them
+	if !uc.canExtend(owner, source) {
+		problem := domain.FeathersProblem{
+			Severity: domain.ErrorSeverity,
+			Message:  fmt.Sprintf("extends %s is not allowed, feathers can only extend the feathers of %s", source, strings.Join(append([]string{owner}, uc.extendsOwners...), ", ")),
+		}
+		if node := lookup(root, "extends"); node != nil {
+			problem.Line, problem.Column = node.Line, node.Column
+		}
+		return nil, nil, &domain.ErrInvalidFeathers{Problems: []domain.FeathersProblem{problem}}
+	}
+	if utils.ExistsInSlice(source.String(), chain) {
+		return nil, nil, errors.Errorf("feathers extend themselves: %s", strings.Join(append(chain, source.String()), " -> "))
+	}
+	if uc.scm == nil {
+		return nil, nil, errors.Errorf("failed to get extended feathers %s, no git server configured", source)
+	}
+
+	baseData, err := uc.scm.GetFileFromBranch(ctx, source.Owner, source.Repo, source.Branch, source.Path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get extended feathers %s", source)
+	}
+	base, warnings, err := uc.resolveFeathers(ctx, owner, baseData, append(chain, source.String()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, overridden, err := mergeFeathers(base, feathers)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to merge feathers with %s", source)
+	}
+
+	// Overriding the extended feathers is allowed but is easily done by mistake, e.g. by reusing the name of a team
+	v := &validator{root: root}
+	var in string
+	if len(chain) > 0 {
+		in = " in " + chain[len(chain)-1]
+	}
+	for _, o := range overridden {
+		v.warnf(lookupKey(lookup(root, o.parent...), o.key), "%s%s overrides %s in %s", o.name, in, o.field, source)
+	}
+	return merged, append(warnings, v.problems...), nil
+}
+
+// override is a field of the extended feathers that's overridden by the local feathers. The key is the field in the
+// local feathers, found at the parent keys.
+type override struct {
+	name   string
+	field  string
+	parent []any
+	key    string
+}
+
+// canExtend returns whether the feathers of a repository of the owner can extend the feathers at the source
+func (uc *UseCase) canExtend(owner string, source models.FeathersSource) bool {
+	// Owners on GitHub are case-insensitive
+	for _, allowed := range append([]string{owner}, uc.extendsOwners...) {
+		if strings.EqualFold(source.Owner, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFeathers merges the local feathers over the feathers that they extend. Teams with the same name are merged field
+// by field, the fields set in the local team taking precedence & lists, such as the addresses, being replaced rather
+// than appended to. Groups & paths with the same name are replaced by the local ones. The fields of the extended feathers
+// that are replaced with a different value are returned.
+func mergeFeathers(base, local *models.Feathers) (*models.Feathers, []override, error) {
+	merged := &models.Feathers{
+		Extends: local.Extends,
+		Groups:  mergeMaps(base.Groups, local.Groups),
+		Paths:   mergeMaps(base.Paths, local.Paths),
+		Config: models.Config{
+			Messages: base.Config.Messages.Override(&local.Config.Messages),
+			Paths: models.PathsConfig{
+				Required: base.Config.Paths.Required || local.Config.Paths.Required,
+			},
+		},
+	}
+
+	// The extended teams keep their order, with the teams only in the local feathers after them
+	var overridden []override
+	for _, team := range base.Teams {
+		for i, localTeam := range local.Teams {
+			if localTeam.Name != team.Name {
+				continue
+			}
+			var fields []string
+			var err error
+			team, fields, err = mergeTeam(team, localTeam)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, field := range fields {
+				parent := []any{"teams", i}
+				key := field
+				// The fields of the team's messages are overridden one by one
+				if before, after, ok := strings.Cut(field, "."); ok {
+					parent, key = append(parent, before), after
+				}
+				overridden = append(overridden, override{name: "team " + team.Name, field: field, parent: parent, key: key})
+			}
+			break
+		}
+		merged.Teams = append(merged.Teams, team)
+	}
+	for _, team := range local.Teams {
+		if len(base.Teams.GetTeamsByNames(team.Name)) == 0 {
+			merged.Teams = append(merged.Teams, team)
+		}
+	}
+
+	for _, key := range sortedKeys(local.Groups) {
+		if members, ok := base.Groups[key]; ok && !reflect.DeepEqual(members, local.Groups[key]) {
+			overridden = append(overridden, override{name: "group " + key, field: "its members", parent: []any{"groups"}, key: key})
+		}
+	}
+	for _, key := range sortedKeys(local.Paths) {
+		if owners, ok := base.Paths[key]; ok && !reflect.DeepEqual(owners, local.Paths[key]) {
+			overridden = append(overridden, override{name: "path " + key, field: "its owners", parent: []any{"paths"}, key: key})
+		}
+	}
+	return merged, overridden, nil
+}
+
+// mergeTeam merges the local team over the extended team of the same name, returning the fields of the extended team
+// that are replaced with a different value
+func mergeTeam(base, local models.Team) (models.Team, []string, error) {
+	// The channels are used in place of the contact type & addresses, so the two can't be mixed between the feathers
+	if len(local.Channels) > 0 && hasContactType(base) || hasContactType(local) && len(base.Channels) > 0 {
+		return models.Team{}, nil, errors.Errorf("conflict in team %s, one of the feathers sets channels & the other a contactType", base.Name)
+	}
+
+	var overridden []string
+	set := func(field string, base, local any) {
+		if !reflect.ValueOf(base).IsZero() && !reflect.DeepEqual(base, local) {
+			overridden = append(overridden, field)
+		}
+	}
+	if local.APIKey != "" {
+		set("apiKey", base.APIKey, local.APIKey)
+		base.APIKey = local.APIKey
+	}
+	if local.ContactType != "" {
+		set("contactType", base.ContactType, local.ContactType)
+		base.ContactType = local.ContactType
+	}
+	if local.Addresses != nil {
+		set("addresses", base.Addresses, local.Addresses)
+		base.Addresses = local.Addresses
+	}
+	if local.DeliveryMode != "" {
+		set("deliveryMode", base.DeliveryMode, local.DeliveryMode)
+		base.DeliveryMode = local.DeliveryMode
+	}
+	if local.Mentions != nil {
+		set("mentions", base.Mentions, local.Mentions)
+		base.Mentions = local.Mentions
+	}
+	if local.Webhook != nil {
+		set("webhook", base.Webhook, local.Webhook)
+		base.Webhook = local.Webhook
+	}
+	if local.Messages != nil {
+		messages := local.Messages
+		if base.Messages != nil {
+			// The messages are merged field by field too
+			if local.Messages.Subject != "" {
+				set("messages.subject", base.Messages.Subject, local.Messages.Subject)
+			}
+			if local.Messages.Template != "" {
+				set("messages.template", base.Messages.Template, local.Messages.Template)
+			}
+			if local.Messages.Metadata != nil {
+				set("messages.metadata", base.Messages.Metadata, local.Messages.Metadata)
+			}
+			merged := base.Messages.Override(local.Messages)
+			messages = &merged
+		}
+		base.Messages = messages
+	}
+	if local.Channels != nil {
+		set("channels", base.Channels, local.Channels)
+		base.Channels = local.Channels
+	}
+	return base, overridden, nil
+}
+
+// hasContactType returns whether the team sets the single contact type form rather than channels
+func hasContactType(team models.Team) bool {
+	return team.ContactType != "" || len(team.Addresses) > 0
+}
+
+// mergeMaps returns the entries of both maps, the entries of local replacing those in base with the same key
+func mergeMaps[V any](base, local map[string]V) map[string]V {
+	if len(base) == 0 && len(local) == 0 {
+		return nil
+	}
+	merged := make(map[string]V, len(base)+len(local))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range local {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,412 @@
+package feathers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/domain/mocks"
+	"github.com/spring-financial-group/peacock/pkg/feathers"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sharedFeathersSource = "org/peacock-config@main:teams.yaml"
+	sharedFeathers       = `
+teams:
+  - name: FrontEnd
+    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e
+    contactType: slack
+    addresses:
+      - C02BA9QHMD0
+  - name: BackEnd
+    apiKey: eb7c0ee7-4ec2-474c-855f-51ab9c181cfa
+    contactType: slack
+    addresses:
+      - C02BA9QHMD1
+    messages:
+      subject: Backend Release
+groups:
+  AllDevs:
+    - FrontEnd
+    - BackEnd
+config:
+  messages:
+    subject: New Release Notes
+`
+)
+
+func TestUseCase_GetFeathersFromBytes_Extends(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name             string
+		inputFeathers    string
+		extendedFeathers map[string]string
+		fetchErr         error
+		expectedFeathers *models.Feathers
+		expectedWarnings []domain.FeathersProblem
+		shouldError      bool
+	}{
+		{
+			name: "Extends",
+			inputFeathers: `
+extends: org/peacock-config@main:teams.yaml
+teams:
+  - name: BackEnd
+    addresses:
+      - C02BA9QHMD2
+    messages:
+      template: "{{ .Content }}"
+  - name: QA
+    apiKey: 2d6a8f3e-5a1c-4b8e-9d3f-7c6b5a4e3d2c
+    contactType: slack
+    addresses:
+      - C02BA9QHMD3
+groups:
+  AllDevs:
+    - FrontEnd
+    - BackEnd
+    - QA
+config:
+  messages:
+    subject: Release Notes for {{ .Repo }}
+`,
+			extendedFeathers: map[string]string{
+				sharedFeathersSource: sharedFeathers,
+			},
+			expectedFeathers: &models.Feathers{
+				Extends: sharedFeathersSource,
+				Teams: models.Teams{
+					{
+						Name:        "FrontEnd",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD2"},
+						Messages: &models.Messages{
+							Subject:  "Backend Release",
+							Template: "{{ .Content }}",
+						},
+					},
+					{
+						Name:        "QA",
+						APIKey:      "2d6a8f3e-5a1c-4b8e-9d3f-7c6b5a4e3d2c",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD3"},
+					},
+				},
+				Groups: map[string][]string{
+					"AllDevs": {"FrontEnd", "BackEnd", "QA"},
+				},
+				Config: models.Config{
+					Messages: models.Messages{
+						Subject: "Release Notes for {{ .Repo }}",
+					},
+				},
+			},
+			expectedWarnings: []domain.FeathersProblem{
+				{Line: 5, Column: 5, Severity: domain.WarningSeverity, Message: "team BackEnd overrides addresses in org/peacock-config@main:teams.yaml"},
+				{Line: 15, Column: 3, Severity: domain.WarningSeverity, Message: "group AllDevs overrides its members in org/peacock-config@main:teams.yaml"},
+			},
+		},
+		{
+			name:          "ExtendsOnly",
+			inputFeathers: "extends: org/peacock-config@main:teams.yaml",
+			extendedFeathers: map[string]string{
+				sharedFeathersSource: sharedFeathers,
+			},
+			expectedFeathers: &models.Feathers{
+				Extends: sharedFeathersSource,
+				Teams: models.Teams{
+					{
+						Name:        "FrontEnd",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD1"},
+						Messages: &models.Messages{
+							Subject: "Backend Release",
+						},
+					},
+				},
+				Groups: map[string][]string{
+					"AllDevs": {"FrontEnd", "BackEnd"},
+				},
+				Config: models.Config{
+					Messages: models.Messages{
+						Subject: "New Release Notes",
+					},
+				},
+			},
+		},
+		{
+			name:          "ExtendsChain",
+			inputFeathers: "extends: org/peacock-config@main:backend.yaml",
+			extendedFeathers: map[string]string{
+				"org/peacock-config@main:backend.yaml": `
+extends: org/peacock-config@main:teams.yaml
+teams:
+  - name: FrontEnd
+    addresses:
+      - C02BA9QHMD4
+`,
+				sharedFeathersSource: sharedFeathers,
+			},
+			expectedFeathers: &models.Feathers{
+				Extends: "org/peacock-config@main:backend.yaml",
+				Teams: models.Teams{
+					{
+						Name:        "FrontEnd",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD4"},
+					},
+					{
+						Name:        "BackEnd",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD1"},
+						Messages: &models.Messages{
+							Subject: "Backend Release",
+						},
+					},
+				},
+				Groups: map[string][]string{
+					"AllDevs": {"FrontEnd", "BackEnd"},
+				},
+				Config: models.Config{
+					Messages: models.Messages{
+						Subject: "New Release Notes",
+					},
+				},
+			},
+			// The position is only known in the feathers of the repository
+			expectedWarnings: []domain.FeathersProblem{
+				{Severity: domain.WarningSeverity, Message: "team FrontEnd in org/peacock-config@main:backend.yaml overrides addresses in org/peacock-config@main:teams.yaml"},
+			},
+		},
+		{
+			name: "Overrides",
+			inputFeathers: `
+extends: org/peacock-config@main:teams.yaml
+teams:
+  - name: FrontEnd
+    addresses:
+      - C02BA9QHMD0
+  - name: BackEnd
+    apiKey: 2d6a8f3e-5a1c-4b8e-9d3f-7c6b5a4e3d2c
+    messages:
+      subject: Release
+paths:
+  src/**:
+    - FrontEnd
+`,
+			extendedFeathers: map[string]string{
+				sharedFeathersSource: sharedFeathers + `
+paths:
+  src/**:
+    - BackEnd
+`,
+			},
+			expectedFeathers: &models.Feathers{
+				Extends: sharedFeathersSource,
+				Teams: models.Teams{
+					{
+						Name:        "FrontEnd",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						APIKey:      "2d6a8f3e-5a1c-4b8e-9d3f-7c6b5a4e3d2c",
+						ContactType: models.Slack,
+						Addresses:   []string{"C02BA9QHMD1"},
+						Messages: &models.Messages{
+							Subject: "Release",
+						},
+					},
+				},
+				Groups: map[string][]string{
+					"AllDevs": {"FrontEnd", "BackEnd"},
+				},
+				Paths: map[string][]string{
+					"src/**": {"FrontEnd"},
+				},
+				Config: models.Config{
+					Messages: models.Messages{
+						Subject: "New Release Notes",
+					},
+				},
+			},
+			// Fields set to the same value as in the extended feathers don't override anything
+			expectedWarnings: []domain.FeathersProblem{
+				{Line: 8, Column: 5, Severity: domain.WarningSeverity, Message: "team BackEnd overrides apiKey in org/peacock-config@main:teams.yaml"},
+				{Line: 10, Column: 7, Severity: domain.WarningSeverity, Message: "team BackEnd overrides messages.subject in org/peacock-config@main:teams.yaml"},
+				{Line: 12, Column: 3, Severity: domain.WarningSeverity, Message: "path src/** overrides its owners in org/peacock-config@main:teams.yaml"},
+			},
+		},
+		{
+			name:          "ExtendsThemselves",
+			inputFeathers: "extends: org/peacock-config@main:a.yaml",
+			extendedFeathers: map[string]string{
+				"org/peacock-config@main:a.yaml": "extends: org/peacock-config@main:b.yaml",
+				"org/peacock-config@main:b.yaml": "extends: org/peacock-config@main:a.yaml",
+			},
+			shouldError: true,
+		},
+		{
+			name: "ChannelsAndContactTypeConflict",
+			inputFeathers: `
+extends: org/peacock-config@main:teams.yaml
+teams:
+  - name: FrontEnd
+    channels:
+      - contactType: email
+        addresses:
+          - frontend@example.com
+`,
+			extendedFeathers: map[string]string{
+				sharedFeathersSource: sharedFeathers,
+			},
+			shouldError: true,
+		},
+		{
+			name: "InvalidAfterMerge",
+			inputFeathers: `
+extends: org/peacock-config@main:teams.yaml
+teams:
+  - name: QA
+    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e
+    contactType: slack
+    addresses:
+      - C02BA9QHMD3
+`,
+			extendedFeathers: map[string]string{
+				sharedFeathersSource: sharedFeathers,
+			},
+			shouldError: true,
+		},
+		{
+			name:          "InvalidSource",
+			inputFeathers: "extends: org/peacock-config:teams.yaml",
+			shouldError:   true,
+		},
+		{
+			name:          "FailedToGetExtendedFeathers",
+			inputFeathers: "extends: org/peacock-config@main:teams.yaml",
+			fetchErr:      errors.New("not found"),
+			shouldError:   true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSCM := mocks.NewSCM(t)
+			uc := feathers.NewUseCase(&config.Feathers{}, mockSCM)
+
+			for source, data := range tt.extendedFeathers {
+				s, err := models.ParseFeathersSource(source)
+				assert.NoError(t, err)
+				mockSCM.On("GetFileFromBranch", ctx, s.Owner, s.Repo, s.Branch, s.Path).Return([]byte(data), nil).Once()
+			}
+			if tt.fetchErr != nil {
+				mockSCM.On("GetFileFromBranch", ctx, "org", "peacock-config", "main", "teams.yaml").Return(nil, tt.fetchErr).Once()
+			}
+
+			actualFeathers, actualWarnings, err := uc.GetFeathersFromBytes(ctx, "org", []byte(tt.inputFeathers))
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFeathers, actualFeathers)
+			assert.Equal(t, tt.expectedWarnings, actualWarnings)
+		})
+	}
+}
+
+func TestUseCase_GetFeathersFromBytes_ExtendsWithoutSCM(t *testing.T) {
+	uc := feathers.NewUseCase(&config.Feathers{}, nil)
+
+	_, _, err := uc.GetFeathersFromBytes(context.Background(), "org", []byte("extends: org/peacock-config@main:teams.yaml"))
+	assert.Error(t, err)
+}
+
+func TestUseCase_GetFeathersFromBytes_ExtendsOwners(t *testing.T) {
+	ctx := context.Background()
+	teams := []byte("teams:\n  - name: BackEnd\n    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e\n    contactType: slack\n    addresses:\n      - C02BA9QHMD2\n")
+
+	testCases := []struct {
+		name             string
+		inputFeathers    string
+		extendedFeathers map[string][]byte
+		expectedProblem  *domain.FeathersProblem
+	}{
+		{
+			name:             "SameOwner",
+			inputFeathers:    "extends: ORG/peacock-config@main:teams.yaml",
+			extendedFeathers: map[string][]byte{"ORG/peacock-config@main:teams.yaml": teams},
+		},
+		{
+			name:             "AllowedOwner",
+			inputFeathers:    "extends: shared/peacock-config@main:teams.yaml",
+			extendedFeathers: map[string][]byte{"shared/peacock-config@main:teams.yaml": teams},
+		},
+		{
+			name:          "OwnerNotAllowed",
+			inputFeathers: "extends: other/peacock-config@main:teams.yaml",
+			expectedProblem: &domain.FeathersProblem{
+				Line:     1,
+				Column:   10,
+				Severity: domain.ErrorSeverity,
+				Message:  "extends other/peacock-config@main:teams.yaml is not allowed, feathers can only extend the feathers of org, shared",
+			},
+		},
+		{
+			name:             "ExtendedOwnerNotAllowed",
+			inputFeathers:    "extends: shared/peacock-config@main:teams.yaml",
+			extendedFeathers: map[string][]byte{"shared/peacock-config@main:teams.yaml": []byte("extends: other/peacock-config@main:teams.yaml")},
+			expectedProblem: &domain.FeathersProblem{
+				Severity: domain.ErrorSeverity,
+				Message:  "extends other/peacock-config@main:teams.yaml is not allowed, feathers can only extend the feathers of org, shared",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSCM := mocks.NewSCM(t)
+			uc := feathers.NewUseCase(&config.Feathers{ExtendsOwners: []string{"shared"}}, mockSCM)
+
+			for source, data := range tt.extendedFeathers {
+				s, err := models.ParseFeathersSource(source)
+				assert.NoError(t, err)
+				mockSCM.On("GetFileFromBranch", ctx, s.Owner, s.Repo, s.Branch, s.Path).Return(data, nil).Once()
+			}
+
+			_, _, err := uc.GetFeathersFromBytes(ctx, "org", []byte(tt.inputFeathers))
+			if tt.expectedProblem == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var errInvalid *domain.ErrInvalidFeathers
+			assert.True(t, errors.As(err, &errInvalid))
+			assert.Equal(t, []domain.FeathersProblem{*tt.expectedProblem}, errInvalid.Problems)
+		})
+	}
+}
@@ -1,28 +1,58 @@
 package feathers
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/spring-financial-group/peacock/pkg/models"
 	"github.com/spring-financial-group/peacock/pkg/utils"
 	"gopkg.in/yaml.v3"
+	"net/mail"
+	"net/url"
 	"os"
+	"path"
+	"reflect"
 	"regexp"
+	"sort"
+	"strings"
 )
 
 const (
 	feathersPath        = ".peacock/feathers.yaml"
 	slackChannelIDRegex = "^[A-Z0-9]{9,11}$"
+	// slackMentionIDRegex matches the IDs of Slack user groups (S) and users (U or W)
+	slackMentionIDRegex = "^[SUW][A-Z0-9]{8,10}$"
+	// envVarNameRegex matches the names of the environment variables that webhook tokens & secrets are read from
+	envVarNameRegex = "^[A-Za-z_][A-Za-z0-9_]*$"
+	// phoneNumberRegex matches phone numbers in E.164 format, e.g. +447700900123
+	phoneNumberRegex = `^\+[1-9][0-9]{1,14}$`
+)
+
+var (
+	slackChannelIDRe = regexp.MustCompile(slackChannelIDRegex)
+	slackMentionIDRe = regexp.MustCompile(slackMentionIDRegex)
+	envVarNameRe     = regexp.MustCompile(envVarNameRegex)
+	phoneNumberRe    = regexp.MustCompile(phoneNumberRegex)
 )
 
 type UseCase struct {
+	// scm is optional, without it feathers can't extend the feathers of other repositories
+	scm domain.SCM
+	// extendsOwners are the owners whose feathers can be extended by the feathers of any owner
+	extendsOwners []string
 }
 
-func NewUseCase() *UseCase {
-	return &UseCase{}
+func NewUseCase(cfg *config.Feathers, scm domain.SCM) *UseCase {
+	return &UseCase{
+		scm:           scm,
+		extendsOwners: cfg.ExtendsOwners,
+	}
 }
 
-func (uc *UseCase) GetFeathersFromFile() (*models.Feathers, error) {
+func (uc *UseCase) GetFeathersFromFile(ctx context.Context, owner string) (*models.Feathers, error) {
 	exists, err := utils.Exists(feathersPath)
 	if err != nil {
 		return nil, err
@@ -35,87 +65,340 @@ func (uc *UseCase) GetFeathersFromFile() (*models.Feathers, error) {
 	if err != nil {
 		return nil, err
 	}
-	return uc.GetFeathersFromBytes(data)
+	feathers, warnings, err := uc.GetFeathersFromBytes(ctx, owner, data)
+	logWarnings(warnings)
+	return feathers, err
 }
 
-func (uc *UseCase) GetFeathersFromBytes(data []byte) (*models.Feathers, error) {
-	feathers := new(models.Feathers)
-	err := yaml.Unmarshal(data, &feathers)
+func (uc *UseCase) GetFeathersFromBytes(ctx context.Context, owner string, data []byte) (*models.Feathers, []domain.FeathersProblem, error) {
+	feathers, overridden, err := uc.resolveFeathers(ctx, owner, data, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return feathers, uc.ValidateFeathers(feathers)
+
+	// The feathers have already been parsed, the nodes are only needed for the positions of any problems with them
+	var document yaml.Node
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	warnings, err := validate(feathers, &document, overridden)
+	return feathers, warnings, err
 }
 
+// ValidateFeathers checks that the feathers are set up correctly, the error returned is a *domain.ErrInvalidFeathers
+// with every problem found
 func (uc *UseCase) ValidateFeathers(f *models.Feathers) error {
-	if f.Teams == nil {
-		return errors.New("no teams found in feathers")
+	warnings, err := validate(f, nil, nil)
+	logWarnings(warnings)
+	return err
+}
+
+// validate checks the feathers, locating the problems found in the yaml document that they were parsed from if there
+// is one. The problems already found, e.g. when resolving the feathers, are reported with them. If there are no errors
+// the warnings are returned, otherwise they're returned in the error alongside them.
+func validate(f *models.Feathers, document *yaml.Node, problems []domain.FeathersProblem) ([]domain.FeathersProblem, error) {
+	v := &validator{
+		feathers: f,
+		root:     documentRoot(document),
+		problems: problems,
+	}
+	v.validateFeathers()
+
+	for _, p := range v.problems {
+		if p.Severity == domain.ErrorSeverity {
+			return nil, &domain.ErrInvalidFeathers{Problems: v.problems}
+		}
+	}
+	return v.problems, nil
+}
+
+func logWarnings(warnings []domain.FeathersProblem) {
+	for _, w := range warnings {
+		log.Warnf("feathers %s", w)
+	}
+}
+
+// validator collects the problems with feathers. The nodes passed with each problem are where it's reported in the
+// yaml, they're nil when there's no yaml for the problem.
+type validator struct {
+	feathers *models.Feathers
+	// root is the mapping node of the feathers yaml, it's nil when the feathers weren't parsed from yaml
+	root     *yaml.Node
+	problems []domain.FeathersProblem
+}
+
+func (v *validator) errorf(node *yaml.Node, format string, args ...any) {
+	v.addProblem(node, domain.ErrorSeverity, format, args...)
+}
+
+func (v *validator) warnf(node *yaml.Node, format string, args ...any) {
+	v.addProblem(node, domain.WarningSeverity, format, args...)
+}
+
+func (v *validator) addProblem(node *yaml.Node, severity domain.Severity, format string, args ...any) {
+	problem := domain.FeathersProblem{
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if node != nil {
+		problem.Line, problem.Column = node.Line, node.Column
 	}
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) validateFeathers() {
+	v.validateFields(v.root, reflect.TypeOf(models.Feathers{}))
 
-	const name, apiKey = "Name", "APIKey"
-	unique := map[string]map[string]bool{
-		name:   make(map[string]bool),
-		apiKey: make(map[string]bool),
+	f := v.feathers
+	if f.Teams == nil {
+		v.errorf(v.root, "no teams found in feathers")
 	}
+	v.validateMessages(f.Config.Messages, nearest(v.root, "config", "messages"), "")
 
-	for _, team := range f.Teams {
+	names, apiKeys := make(map[string]bool), make(map[string]bool)
+	for i, team := range f.Teams {
+		node := v.teamNode(i)
 		// Check that the individual teams are set up correctly
-		err := uc.validateTeam(team)
-		if err != nil {
-			return err
-		}
+		v.validateTeam(team, node)
 
 		// Check the team names and API keys are unique
-		if _, exists := unique[name][team.Name]; exists {
-			return fmt.Errorf("duplicate team name found: %s", team.Name)
+		if team.Name != "" && names[team.Name] {
+			v.errorf(nearest(node, "name"), "duplicate team name found: %s", team.Name)
 		}
-		if _, exists := unique[apiKey][team.APIKey]; exists {
-			return fmt.Errorf("duplicate apiKey found: %s", team.APIKey)
+		if team.APIKey != "" && apiKeys[team.APIKey] {
+			v.errorf(nearest(node, "apiKey"), "duplicate apiKey found: %s", team.APIKey)
 		}
-		unique[name][team.Name] = true
-		unique[apiKey][team.APIKey] = true
+		names[team.Name] = true
+		apiKeys[team.APIKey] = true
+	}
+	v.validateGroups()
+	v.validatePaths()
+}
+
+// teamNode returns the node of the team in the yaml. The feathers may have been merged over extended feathers, so the
+// team is found by its name, counting the teams before it with the same name.
+func (v *validator) teamNode(index int) *yaml.Node {
+	name := v.feathers.Teams[index].Name
+	var occurrence int
+	for _, team := range v.feathers.Teams[:index] {
+		if team.Name == name {
+			occurrence++
+		}
+	}
+
+	teams := lookup(v.root, "teams")
+	if teams == nil || teams.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, node := range teams.Content {
+		var nodeName string
+		if nameNode := lookup(node, "name"); nameNode != nil {
+			nodeName = nameNode.Value
+		}
+		if nodeName != name {
+			continue
+		}
+		if occurrence == 0 {
+			return node
+		}
+		occurrence--
 	}
 	return nil
 }
 
-// validate checks that a team is set up correctly and contains all the required fields
-func (uc *UseCase) validateTeam(t models.Team) error {
+// validateGroups checks that the groups are made up of teams or other groups, and that they don't contain themselves
+func (v *validator) validateGroups() {
+	f := v.feathers
+	teamNames := f.Teams.GetAllTeamNames()
+	for _, group := range sortedKeys(f.Groups) {
+		node := lookupKey(lookup(v.root, "groups"), group)
+		if utils.ExistsInSlice(group, teamNames) {
+			v.errorf(node, "group %s has the same name as a team", group)
+		}
+		if len(f.Groups[group]) == 0 {
+			v.errorf(node, "no members for group %s", group)
+		}
+		for i, member := range f.Groups[group] {
+			if _, isGroup := f.Groups[member]; !isGroup && !utils.ExistsInSlice(member, teamNames) {
+				v.errorf(nearest(v.root, "groups", group, i), "member %s of group %s is not a team or group in feathers", member, group)
+			}
+		}
+		if _, err := f.ExpandTeamNames(group); err != nil {
+			v.errorf(node, "%s", err)
+		}
+	}
+}
+
+// validatePaths checks that the globs of the paths are valid & that they're owned by teams or groups in the feathers
+func (v *validator) validatePaths() {
+	f := v.feathers
+	teamNames := f.Teams.GetAllTeamNames()
+	for _, glob := range sortedKeys(f.Paths) {
+		node := lookupKey(lookup(v.root, "paths"), glob)
+		if _, err := path.Match(glob, ""); err != nil {
+			v.errorf(node, "invalid glob for path %s: %s", glob, err)
+		}
+		if len(f.Paths[glob]) == 0 {
+			v.errorf(node, "no owners for path %s", glob)
+		}
+		for i, owner := range f.Paths[glob] {
+			if _, isGroup := f.Groups[owner]; !isGroup && !utils.ExistsInSlice(owner, teamNames) {
+				v.errorf(nearest(v.root, "paths", glob, i), "owner %s of path %s is not a team or group in feathers", owner, glob)
+			}
+		}
+	}
+}
+
+// validateMessages checks that the templates of the messages config are valid, the team is empty for the global config
+func (v *validator) validateMessages(m models.Messages, node *yaml.Node, team string) {
+	prefix := ""
+	if team != "" {
+		prefix = "team " + team + ": "
+	}
+	if m.Template != "" {
+		if _, err := m.ParseTemplate(); err != nil {
+			v.errorf(nearest(node, "template"), "%sinvalid message template: %s", prefix, err)
+		}
+	}
+	if _, err := m.ParseSubject(); err != nil {
+		v.errorf(nearest(node, "subject"), "%sinvalid message subject: %s", prefix, err)
+	}
+}
+
+// validateTeam checks that a team is set up correctly and contains all the required fields
+func (v *validator) validateTeam(t models.Team, node *yaml.Node) {
 	// Check that none of the required fields are empty
 	if t.Name == "" {
-		return errors.New("no team name found")
+		v.errorf(node, "no team name found")
 	}
-	if t.ContactType == "" {
-		return errors.Errorf("no contactType for team %s", t.Name)
-	}
-	if len(t.Addresses) == 0 && t.ContactType != models.None {
-		return errors.Errorf("no addresses for team %s", t.Name)
+	if len(t.Channels) > 0 {
+		// The channels are used in place of the team's own contact type, so both can't be set
+		if t.ContactType != "" || len(t.Addresses) > 0 || t.DeliveryMode != "" || len(t.Mentions) > 0 || t.Webhook != nil {
+			v.errorf(nearest(node, "channels"), "team %s has channels so the contact type & its options should be set on the channels", t.Name)
+		}
 	}
-	if len(t.Addresses) > 0 && t.ContactType == models.None {
-		return errors.Errorf("addresses found for team %s with contactType of none", t.Name)
+	for i, c := range t.GetChannels() {
+		channelNode := node
+		if len(t.Channels) > 0 {
+			channelNode = nearest(node, "channels", i)
+		}
+		v.validateChannel(t.Name, c, channelNode)
 	}
 
 	if t.APIKey == "" {
-		return errors.Errorf("no APIKey for team %s", t.Name)
+		v.errorf(node, "no APIKey for team %s", t.Name)
+	}
+
+	if t.Messages != nil {
+		v.validateMessages(*t.Messages, nearest(node, "messages"), t.Name)
+	}
+}
+
+// validateChannel checks that a channel of a team is set up correctly, the addresses should conform to the contact type
+func (v *validator) validateChannel(team string, c models.Channel, node *yaml.Node) {
+	if c.ContactType == "" {
+		v.errorf(node, "no contactType for team %s", team)
+	}
+	if len(c.Addresses) == 0 && c.ContactType != models.None {
+		v.errorf(nearest(node, "addresses"), "no addresses for team %s", team)
+	}
+	if len(c.Addresses) > 0 && c.ContactType == models.None {
+		v.errorf(nearest(node, "addresses"), "addresses found for team %s with contactType of none", team)
 	}
 
 	// We should check that Peacock actually supports the contact type
-	if exists := utils.ExistsInSlice(t.ContactType, models.Valid); !exists {
-		return errors.Errorf("team %s has an invalid contact type of %s", t.Name, t.ContactType)
+	if c.ContactType != "" && !utils.ExistsInSlice(c.ContactType, models.Valid) {
+		v.errorf(nearest(node, "contactType"), "team %s has an invalid contact type of %s", team, c.ContactType)
 	}
 
-	// We should check that the addresses conform to the contact type
-	slackRegex, err := regexp.Compile(slackChannelIDRegex)
-	if err != nil {
-		return err
+	if c.DeliveryMode != "" {
+		if !utils.ExistsInSlice(c.DeliveryMode, models.ValidDeliveryModes) {
+			v.errorf(nearest(node, "deliveryMode"), "team %s has an invalid delivery mode of %s", team, c.DeliveryMode)
+		} else if c.DeliveryMode == models.ThreadDelivery && c.ContactType != models.Slack {
+			v.errorf(nearest(node, "deliveryMode"), "team %s has a delivery mode of %s which is only supported by slack", team, c.DeliveryMode)
+		}
+	}
+
+	if len(c.Mentions) > 0 {
+		if c.ContactType != models.Slack {
+			v.errorf(nearest(node, "mentions"), "team %s has mentions which are only supported by slack", team)
+		}
+		for i, id := range c.Mentions {
+			if !slackMentionIDRe.MatchString(id) {
+				v.errorf(nearest(node, "mentions", i), "failed to parse slack user or user group ID %s for team %s", id, team)
+			}
+		}
 	}
-	for _, address := range t.Addresses {
-		switch t.ContactType {
+
+	if c.Webhook != nil {
+		v.validateWebhookEndpoint(team, c, nearest(node, "webhook"))
+	}
+
+	// We should check that the addresses conform to the contact type
+	for i, address := range c.Addresses {
+		addressNode := nearest(node, "addresses", i)
+		switch c.ContactType {
 		case models.Slack:
-			match := slackRegex.MatchString(address)
-			if !match {
-				return errors.Errorf("failed to parse slack channel ID %s for team %s", address, t.Name)
+			if !slackChannelIDRe.MatchString(address) {
+				v.errorf(addressNode, "failed to parse slack channel ID %s for team %s", address, team)
+			}
+		case models.MSTeams:
+			u, err := url.ParseRequestURI(address)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				v.errorf(addressNode, "failed to parse teams webhook URL %s for team %s", address, team)
+			}
+		case models.Email:
+			if _, err := mail.ParseAddress(address); err != nil {
+				v.errorf(addressNode, "failed to parse email address %s for team %s", address, team)
+			}
+		case models.SMS:
+			if !phoneNumberRe.MatchString(address) {
+				v.errorf(addressNode, "failed to parse phone number %s for team %s, it should be in E.164 format", address, team)
 			}
 		}
 	}
-	return nil
+}
+
+// validateWebhookEndpoint checks that the endpoint of a webhook team has a URL & that its token & secret reference
+// environment variables
+func (v *validator) validateWebhookEndpoint(team string, c models.Channel, node *yaml.Node) {
+	if c.ContactType != models.Webhook {
+		v.errorf(node, "team %s has a webhook endpoint which is only supported by webhook", team)
+	}
+	// The token is sent to the endpoint, so it has to be over https
+	u, err := url.ParseRequestURI(c.Webhook.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		v.errorf(nearest(node, "url"), "failed to parse webhook URL %s for team %s, it should be an https URL", c.Webhook.URL, team)
+	}
+
+	if c.Webhook.SecretRef == "" {
+		v.errorf(node, "no secretRef for the webhook of team %s", team)
+	} else {
+		v.validateEnvVarRef("secretRef", c.Webhook.SecretRef, team, nearest(node, "secretRef"))
+	}
+	if c.Webhook.TokenRef != "" {
+		v.validateEnvVarRef("tokenRef", c.Webhook.TokenRef, team, nearest(node, "tokenRef"))
+	}
+	if c.Webhook.Format != "" && !utils.ExistsInSlice(c.Webhook.Format, models.ValidPayloadFormats) {
+		v.errorf(nearest(node, "format"), "team %s has an invalid webhook format of %s", team, c.Webhook.Format)
+	}
+}
+
+// validateEnvVarRef checks that the ref of a webhook endpoint names an environment variable that it's allowed to read
+func (v *validator) validateEnvVarRef(field, ref, team string, node *yaml.Node) {
+	if !envVarNameRe.MatchString(ref) {
+		v.errorf(node, "webhook %s %s for team %s is not a valid environment variable name", field, ref, team)
+	} else if !strings.HasPrefix(ref, models.WebhookEnvPrefix) {
+		v.errorf(node, "webhook %s %s for team %s should start with %s", field, ref, team, models.WebhookEnvPrefix)
+	}
+}
+
+// sortedKeys returns the keys of the map in order, so that the problems are always reported in the same order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
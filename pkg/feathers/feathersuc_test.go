@@ -1,6 +1,10 @@
 package feathers_test
 
 import (
+	"context"
+	"errors"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/spring-financial-group/peacock/pkg/feathers"
 	"github.com/spring-financial-group/peacock/pkg/models"
 	"github.com/spring-financial-group/peacock/pkg/utils"
@@ -223,6 +227,587 @@ func Test_GetFeathersFromFile_Validate(t *testing.T) {
 			},
 			shouldError: true,
 		},
+		{
+			name: "MSTeamsWebhookURL",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "business",
+						ContactType: "msteams",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"https://example.webhook.office.com/webhookb2/some-id"},
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "MSTeamsInvalidWebhookURL",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "business",
+						ContactType: "msteams",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "SMSPhoneNumber",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "on-call",
+						ContactType: "sms",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"+447700900123"},
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "SMSInvalidPhoneNumber",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "on-call",
+						ContactType: "sms",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"07700 900123"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "SlackThreadDelivery",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:         "infrastructure",
+						ContactType:  "slack",
+						Addresses:    []string{"C02BA9QHMD0"},
+						APIKey:       "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						DeliveryMode: "thread",
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "ThreadDeliveryNotSupported",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:         "business",
+						ContactType:  "msteams",
+						Addresses:    []string{"https://example.webhook.office.com/webhookb2/abc"},
+						APIKey:       "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						DeliveryMode: "thread",
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "SlackMentions",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+						Mentions:    []string{"S012ABCDEF", "U012ABCDEF"},
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "InvalidSlackMention",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+						Mentions:    []string{"@oncall"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "InvalidDeliveryMode",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:         "infrastructure",
+						ContactType:  "slack",
+						Addresses:    []string{"C02BA9QHMD0"},
+						APIKey:       "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						DeliveryMode: "carrier-pigeon",
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "WebhookEndpoint",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "payments",
+						ContactType: "webhook",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"payments-alerts"},
+						Webhook: &models.WebhookEndpoint{
+							URL:       "https://payments.example.com/peacock",
+							TokenRef:  "PEACOCK_WEBHOOK_PAYMENTS_TOKEN",
+							SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET",
+						},
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "WebhookEndpointWithoutSecretRef",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "payments",
+						ContactType: "webhook",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"payments-alerts"},
+						Webhook:     &models.WebhookEndpoint{URL: "https://payments.example.com/peacock"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "WebhookEndpointInvalidURL",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "payments",
+						ContactType: "webhook",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"payments-alerts"},
+						Webhook:     &models.WebhookEndpoint{URL: "payments.example.com", SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "WebhookEndpointPlainHTTP",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "payments",
+						ContactType: "webhook",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"payments-alerts"},
+						Webhook:     &models.WebhookEndpoint{URL: "http://payments.example.com/peacock", SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "WebhookEndpointTokenRefWithoutPrefix",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "payments",
+						ContactType: "webhook",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"payments-alerts"},
+						Webhook:     &models.WebhookEndpoint{URL: "https://payments.example.com/peacock", TokenRef: "GITHUB_TOKEN", SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "WebhookEndpointSecretRefWithoutPrefix",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "payments",
+						ContactType: "webhook",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"payments-alerts"},
+						Webhook:     &models.WebhookEndpoint{URL: "https://payments.example.com/peacock", SecretRef: "SMTP_PASSWORD"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "WebhookEndpointNotSupported",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+						Webhook:     &models.WebhookEndpoint{URL: "https://payments.example.com/peacock", SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "MessageTemplate",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+				},
+				Config: models.Config{
+					Messages: models.Messages{Template: "*{{ .Repo }}* {{ .PRTitle }} ({{ join .Teams \", \" }})\n{{ .Content }}"},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "MessageTemplateUnknownVariable",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+				},
+				Config: models.Config{
+					Messages: models.Messages{Template: "{{ .Service }}\n{{ .Content }}"},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "MessageTemplateInvalidSyntax",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+				},
+				Config: models.Config{
+					Messages: models.Messages{Template: "{{ .Content "},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "MessageSubjectPlaceholders",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+				},
+				Config: models.Config{
+					Messages: models.Messages{Subject: "{{ .Repo }} released to {{ .Environment }}"},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "TeamChannels",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:      "qa",
+						APIKey:    "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses: []string{},
+						Channels: []models.Channel{
+							{ContactType: "slack", Addresses: []string{"C02BA9QHMD0"}, Mentions: []string{"S012ABCDEF"}},
+							{ContactType: "email", Addresses: []string{"qa@example.com"}},
+						},
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "TeamChannelsAndContactType",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "qa",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+						Channels: []models.Channel{
+							{ContactType: "email", Addresses: []string{"qa@example.com"}},
+						},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "TeamChannelInvalidAddress",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:      "qa",
+						APIKey:    "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses: []string{},
+						Channels: []models.Channel{
+							{ContactType: "slack", Addresses: []string{"C02BA9QHMD0"}},
+							{ContactType: "email", Addresses: []string{"not an email"}},
+						},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "Groups",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}, "Everyone": {"AllDevs"}},
+			},
+			shouldError: false,
+		},
+		{
+			name: "GroupUnknownMember",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "Mobile"}},
+			},
+			shouldError: true,
+		},
+		{
+			name: "GroupCycle",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "Web"}, "Web": {"AllDevs"}},
+			},
+			shouldError: true,
+		},
+		{
+			name: "GroupSameNameAsTeam",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"FrontEnd": {"BackEnd"}},
+			},
+			shouldError: true,
+		},
+		{
+			name: "Paths",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}},
+				Paths:  map[string][]string{"api/**": {"BackEnd"}, "Dockerfile": {"AllDevs"}},
+				Config: models.Config{
+					Paths: models.PathsConfig{Required: true},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "PathUnknownOwner",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}},
+				Paths:  map[string][]string{"api/**": {"BackEnd", "Mobile"}},
+			},
+			shouldError: true,
+		},
+		{
+			name: "PathInvalidGlob",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}},
+				Paths:  map[string][]string{"api/[**": {"BackEnd"}},
+			},
+			shouldError: true,
+		},
+		{
+			name: "PathNoOwners",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "FrontEnd",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+					{
+						Name:        "BackEnd",
+						ContactType: "slack",
+						APIKey:      "eb7c0ee7-4ec2-474c-855f-51ab9c181cfa",
+						Addresses:   []string{"C02BA9QHMD1"},
+					},
+				},
+				Groups: map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}},
+				Paths:  map[string][]string{"api/**": {}},
+			},
+			shouldError: true,
+		},
+		{
+			name: "TeamMessages",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "business",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+						Messages:    &models.Messages{Subject: "{{ .Repo }} has been released", Metadata: utils.NewPtr(false)},
+					},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "TeamMessagesInvalidTemplate",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "qa",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+						Messages:    &models.Messages{Template: "{{ .Service }}\n{{ .Content }}"},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "MessageSubjectUnknownVariable",
+			expectedConfig: models.Feathers{
+				Teams: []models.Team{
+					{
+						Name:        "infrastructure",
+						ContactType: "slack",
+						APIKey:      "9e7a455e-39f4-489b-b9ee-dd54d03c576e",
+						Addresses:   []string{"C02BA9QHMD0"},
+					},
+				},
+				Config: models.Config{
+					Messages: models.Messages{Subject: "{{ .Service }} released"},
+				},
+			},
+			shouldError: true,
+		},
 	}
 
 	baseDir, fullPath, err := utils.CreateTestDir(".peacock")
@@ -237,7 +822,7 @@ func Test_GetFeathersFromFile_Validate(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			uc := feathers.NewUseCase()
+			uc := feathers.NewUseCase(&config.Feathers{}, nil)
 
 			bytes, err := yaml.Marshal(tt.expectedConfig)
 			if err != nil {
@@ -248,7 +833,7 @@ func Test_GetFeathersFromFile_Validate(t *testing.T) {
 				panic(err)
 			}
 
-			actualConfig, err := uc.GetFeathersFromFile()
+			actualConfig, err := uc.GetFeathersFromFile(context.Background(), "org")
 			if tt.shouldError {
 				assert.Error(t, err)
 			} else {
@@ -268,3 +853,91 @@ func Test_GetFeathersFromFile_Validate(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestUseCase_GetFeathersFromBytes_Problems(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputFeathers    string
+		expectedProblems []domain.FeathersProblem
+		expectedWarnings []domain.FeathersProblem
+	}{
+		{
+			name: "Errors",
+			inputFeathers: `teams:
+  - name: QA
+    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e
+    contactType: slack
+    addresses:
+      - not-a-slack-id
+  - name: QA
+    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e
+    contactType: carrier-pigeon
+    addreses:
+      - C02BA9QHMD0
+config:
+  messages:
+    subject: Release
+`,
+			expectedProblems: []domain.FeathersProblem{
+				{Line: 10, Column: 5, Severity: domain.WarningSeverity, Message: "unknown field addreses"},
+				{Line: 6, Column: 9, Severity: domain.ErrorSeverity, Message: "failed to parse slack channel ID not-a-slack-id for team QA"},
+				{Line: 7, Column: 5, Severity: domain.ErrorSeverity, Message: "no addresses for team QA"},
+				{Line: 9, Column: 18, Severity: domain.ErrorSeverity, Message: "team QA has an invalid contact type of carrier-pigeon"},
+				{Line: 7, Column: 11, Severity: domain.ErrorSeverity, Message: "duplicate team name found: QA"},
+				{Line: 8, Column: 13, Severity: domain.ErrorSeverity, Message: "duplicate apiKey found: 9e7a455e-39f4-489b-b9ee-dd54d03c576e"},
+			},
+		},
+		{
+			name: "GroupsAndPaths",
+			inputFeathers: `teams:
+  - name: QA
+    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e
+    contactType: slack
+    addresses:
+      - C02BA9QHMD0
+groups:
+  QA:
+    - Mobile
+paths:
+  api/**:
+    - BackEnd
+`,
+			expectedProblems: []domain.FeathersProblem{
+				{Line: 8, Column: 3, Severity: domain.ErrorSeverity, Message: "group QA has the same name as a team"},
+				{Line: 9, Column: 7, Severity: domain.ErrorSeverity, Message: "member Mobile of group QA is not a team or group in feathers"},
+				{Line: 12, Column: 7, Severity: domain.ErrorSeverity, Message: "owner BackEnd of path api/** is not a team or group in feathers"},
+			},
+		},
+		{
+			name: "OnlyWarnings",
+			inputFeathers: `teams:
+  - name: QA
+    apiKey: 9e7a455e-39f4-489b-b9ee-dd54d03c576e
+    contactType: slack
+    addresses:
+      - C02BA9QHMD0
+    colour: blue
+`,
+			// Warnings alone don't stop the feathers from being used, so they're returned with the feathers
+			expectedWarnings: []domain.FeathersProblem{
+				{Line: 7, Column: 5, Severity: domain.WarningSeverity, Message: "unknown field colour"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := feathers.NewUseCase(&config.Feathers{}, nil)
+
+			_, warnings, err := uc.GetFeathersFromBytes(context.Background(), "org", []byte(tt.inputFeathers))
+			if tt.expectedProblems == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedWarnings, warnings)
+				return
+			}
+			var errInvalidFeathers *domain.ErrInvalidFeathers
+			assert.True(t, errors.As(err, &errInvalidFeathers))
+			assert.Equal(t, tt.expectedProblems, errInvalidFeathers.Problems)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package feathers
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// documentRoot returns the top level node of the yaml document, or nil if there isn't a document
+func documentRoot(document *yaml.Node) *yaml.Node {
+	if document == nil || document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil
+	}
+	return document.Content[0]
+}
+
+// lookup returns the node at the keys below the node, the keys are the names of fields or the indexes of list items.
+// It returns nil if there's no node at the keys.
+func lookup(node *yaml.Node, keys ...any) *yaml.Node {
+	for _, key := range keys {
+		if node == nil {
+			return nil
+		}
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		switch k := key.(type) {
+		case string:
+			if keyNode := lookupKey(node, k); keyNode != nil {
+				node = valueOf(node, keyNode)
+			} else {
+				node = nil
+			}
+		case int:
+			if node.Kind != yaml.SequenceNode || k >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[k]
+		}
+	}
+	return node
+}
+
+// nearest returns the node at the keys below the node, or the deepest node on the way to them if there's no node at the
+// keys. Problems with fields that are missing, or that came from extended feathers, are reported at the nearest node.
+func nearest(node *yaml.Node, keys ...any) *yaml.Node {
+	for i := range keys {
+		child := lookup(node, keys[i])
+		if child == nil {
+			return node
+		}
+		node = child
+	}
+	return node
+}
+
+// lookupKey returns the key node of the field in the mapping node, or nil if the node doesn't have the field
+func lookupKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// valueOf returns the value node of the key node in the mapping node
+func valueOf(node, keyNode *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i] == keyNode {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// validateFields warns about the fields in the yaml that aren't fields of the type that it's parsed into, as they're
+// otherwise ignored, e.g. a misspelt contactType
+func (v *validator) validateFields(node *yaml.Node, t reflect.Type) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			// Merge keys add the fields of an anchor to the mapping
+			if key.Tag == "!!merge" {
+				v.validateFields(value, t)
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				v.warnf(key, "unknown field %s", key.Value)
+				continue
+			}
+			v.validateFields(value, field)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			v.validateFields(item, t.Elem())
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			v.validateFields(node.Content[i], t.Elem())
+		}
+	}
+}
+
+// yamlFields returns the types of the fields of the struct by their names in yaml
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -3,6 +3,11 @@ package comment
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spring-financial-group/peacock/pkg/domain"
 )
 
 const (
@@ -26,3 +31,33 @@ func GetMetadataFromComment(comment string) (hash string, commentType string) {
 func AddMetadataToComment(comment, hash, commentType string) string {
 	return fmt.Sprintf("%s\n<!-- hash: %s type: %s -->\n", comment, hash, commentType)
 }
+
+// FeathersProblemsTable returns a markdown table of the problems in the order they appear in the feathers, the position
+// of problems without one is left blank & they're listed last
+func FeathersProblemsTable(problems []domain.FeathersProblem) string {
+	sorted := make([]domain.FeathersProblem, len(problems))
+	copy(sorted, problems)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	var table strings.Builder
+	table.WriteString("| Line | Column | Severity | Problem |\n")
+	table.WriteString("|------|--------|----------|---------|\n")
+	for _, p := range sorted {
+		line, column := "-", "-"
+		if p.Line != 0 {
+			line, column = strconv.Itoa(p.Line), strconv.Itoa(p.Column)
+		}
+		message := strings.ReplaceAll(strings.ReplaceAll(p.Message, "|", "\\|"), "\n", " ")
+		table.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", line, column, p.Severity, message))
+	}
+	return table.String()
+}
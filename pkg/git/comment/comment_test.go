@@ -1,6 +1,7 @@
 package comment
 
 import (
+	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -59,3 +60,39 @@ func TestAddMetadataToComment(t *testing.T) {
 		})
 	}
 }
+
+func TestFeathersProblemsTable(t *testing.T) {
+	// The problems are listed in the order they're found rather than the order they're in the feathers
+	problems := []domain.FeathersProblem{
+		{
+			Severity: domain.ErrorSeverity,
+			Message:  "invalid message subject: unexpected \"|\" in operand",
+		},
+		{
+			Line:     7,
+			Column:   5,
+			Severity: domain.WarningSeverity,
+			Message:  "unknown field contacttype",
+		},
+		{
+			Line:     4,
+			Column:   12,
+			Severity: domain.ErrorSeverity,
+			Message:  "failed to parse slack channel ID C456 for team QA",
+		},
+		{
+			Line:     4,
+			Column:   9,
+			Severity: domain.ErrorSeverity,
+			Message:  "failed to parse slack channel ID C123 for team QA",
+		},
+	}
+
+	expected := "| Line | Column | Severity | Problem |\n" +
+		"|------|--------|----------|---------|\n" +
+		"| 4 | 9 | error | failed to parse slack channel ID C123 for team QA |\n" +
+		"| 4 | 12 | error | failed to parse slack channel ID C456 for team QA |\n" +
+		"| 7 | 5 | warning | unknown field contacttype |\n" +
+		"| - | - | error | invalid message subject: unexpected \"\\|\" in operand |\n"
+	assert.Equal(t, expected, FeathersProblemsTable(problems))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/git/comment"
 	"github.com/spring-financial-group/peacock/pkg/utils"
 	"golang.org/x/oauth2"
 	"net/http"
@@ -52,6 +53,14 @@ func NewClient(user, token string) *Client {
 }
 
 func (c *Client) GetPullRequestBodyFromCommit(ctx context.Context, owner, repoName, sha string) (*string, error) {
+	pr, err := c.GetPullRequestFromCommit(ctx, owner, repoName, sha)
+	if err != nil {
+		return nil, err
+	}
+	return pr.Body, nil
+}
+
+func (c *Client) GetPullRequestFromCommit(ctx context.Context, owner, repoName, sha string) (*github.PullRequest, error) {
 	prsWithCommit, _, err := c.github.PullRequests.ListPullRequestsWithCommit(ctx, owner, repoName, sha, nil)
 	if err != nil {
 		return nil, err
@@ -63,9 +72,9 @@ func (c *Client) GetPullRequestBodyFromCommit(ctx context.Context, owner, repoNa
 
 	// If there is only one PR then that must be it
 	if len(prsWithCommit) == 1 {
-		return prsWithCommit[0].Body, nil
+		return prsWithCommit[0], nil
 	}
-	return c.findPRByMergedTime(prsWithCommit).Body, nil
+	return c.findPRByMergedTime(prsWithCommit), nil
 }
 
 func (c *Client) GetPullRequestBodyFromPRNumber(ctx context.Context, owner, repoName string, prNumber int) (*string, error) {
@@ -98,6 +107,12 @@ func (c *Client) CommentError(ctx context.Context, owner, repoName string, prNum
 		tagString = fmt.Sprintf("@%s: ", prOwner)
 	}
 	errorMsg := fmt.Sprintf("%sValidation failed for the release notes in this PR:\n%s", tagString, err.Error())
+
+	// Problems with the feathers are listed in a table so that they can all be fixed at once
+	var errInvalidFeathers *domain.ErrInvalidFeathers
+	if errors.As(err, &errInvalidFeathers) {
+		errorMsg = fmt.Sprintf("%sValidation failed for the feathers in this PR:\n\n%s", tagString, comment.FeathersProblemsTable(errInvalidFeathers.Problems))
+	}
 	return c.CommentOnPR(ctx, owner, repoName, prNumber, errorMsg)
 }
 
@@ -160,16 +175,24 @@ func (c *Client) GetFileFromBranch(ctx context.Context, owner, repoName, branch,
 }
 
 func (c *Client) GetFilesChangedFromPR(ctx context.Context, owner string, repoName string, prNumber int) ([]*github.CommitFile, error) {
-	commitFiles, resp, err := c.github.PullRequests.ListFiles(ctx, owner, repoName, prNumber, nil)
-	if err != nil {
-		return nil, err
-	}
+	var commitFiles []*github.CommitFile
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := c.github.PullRequests.ListFiles(ctx, owner, repoName, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
 
-	if resp.StatusCode != 200 {
-		return nil, errors.New("failed to retrieve changed files from pull request")
-	}
+		if resp.StatusCode != 200 {
+			return nil, errors.New("failed to retrieve changed files from pull request")
+		}
 
-	return commitFiles, nil
+		commitFiles = append(commitFiles, files...)
+		if resp.NextPage == 0 {
+			return commitFiles, nil
+		}
+		opts.Page = resp.NextPage
+	}
 }
 
 func (c *Client) DeleteUsersComments(ctx context.Context, owner, repoName string, prNumber int) error {
@@ -187,16 +210,40 @@ func (c *Client) DeleteUsersComments(ctx context.Context, owner, repoName string
 }
 
 func (c *Client) CreatePeacockCommitStatus(ctx context.Context, owner, repoName, ref string, state domain.State, statusContext string) error {
-	status := RepoStatus[statusContext]
+	return c.CreatePeacockCommitStatusWithDescription(ctx, owner, repoName, ref, state, statusContext, "")
+}
+
+func (c *Client) CreatePeacockCommitStatusWithDescription(ctx context.Context, owner, repoName, ref string, state domain.State, statusContext, description string) error {
+	// Copy the base status so that the shared one isn't modified
+	status := *RepoStatus[statusContext]
 	status.State = utils.NewPtr(string(state))
+	if description != "" {
+		status.Description = utils.NewPtr(description)
+	}
 
-	_, _, err := c.github.Repositories.CreateStatus(ctx, owner, repoName, ref, status)
+	_, _, err := c.github.Repositories.CreateStatus(ctx, owner, repoName, ref, &status)
 	if err != nil {
 		return errors.Wrap(err, "failed to create commit status")
 	}
 	return nil
 }
 
+func (c *Client) GetPullRequest(ctx context.Context, owner, repoName string, prNumber int) (*github.PullRequest, error) {
+	pr, _, err := c.github.PullRequests.Get(ctx, owner, repoName, prNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get pull request")
+	}
+	return pr, nil
+}
+
+func (c *Client) GetUserPermission(ctx context.Context, owner, repoName, user string) (string, error) {
+	level, _, err := c.github.Repositories.GetPermissionLevel(ctx, owner, repoName, user)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get permission level of user")
+	}
+	return level.GetPermission(), nil
+}
+
 func (c *Client) GetLatestCommitSHAInBranch(ctx context.Context, owner, repoName, branch string) (string, error) {
 	commit, _, err := c.github.Repositories.GetCommit(ctx, owner, repoName, branch, nil)
 	if err != nil {
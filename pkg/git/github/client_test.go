@@ -123,3 +123,31 @@ func TestGit_GetPullRequestBodyWithCommit(t *testing.T) {
 		})
 	}
 }
+
+func TestGit_GetFilesChangedFromPR(t *testing.T) {
+	mockedHTTPClient := ghmock.NewMockedHTTPClient(
+		ghmock.WithRequestMatchPages(
+			ghmock.GetReposPullsFilesByOwnerByRepoByPullNumber,
+			[]*github.CommitFile{
+				{Filename: utils.NewPtr("first.txt")},
+				{Filename: utils.NewPtr("second.txt")},
+			},
+			[]*github.CommitFile{
+				{Filename: utils.NewPtr("third.txt")},
+			},
+		),
+	)
+	client := Client{
+		github: github.NewClient(mockedHTTPClient),
+		user:   "mqube-bot",
+	}
+
+	files, err := client.GetFilesChangedFromPR(context.Background(), "spring-financial-group", "peacock", 1)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.GetFilename())
+	}
+	assert.Equal(t, []string{"first.txt", "second.txt", "third.txt"}, names)
+}
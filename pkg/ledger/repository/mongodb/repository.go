@@ -0,0 +1,164 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// duplicateKeyCode is the code of the write error returned when an entry is rejected by the unique index
+const duplicateKeyCode = 11000
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+// NewRepository creates the repository, ensuring the unique index that entries are claimed with exists
+func NewRepository(client mongo.Client) (domain.DeliveryLedgerRepository, error) {
+	db := *client.Database("Peacock")
+	collection := db.Collection("DeliveryLedger")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "pullRequest.repoowner", Value: 1},
+			{Key: "pullRequest.reponame", Value: 1},
+			{Key: "pullRequest.prnumber", Value: 1},
+			{Key: "noteHash", Value: 1},
+			{Key: "contactType", Value: 1},
+			{Key: "address", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create index of the delivery ledger")
+	}
+
+	return &repository{
+		collection: collection,
+	}, nil
+}
+
+func (r *repository) Claim(ctx context.Context, entries []models.LedgerEntry) ([]models.LedgerEntry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	docs := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		docs[i] = entry
+	}
+	// The insert is unordered so that the entries rejected by the unique index don't stop the rest being claimed
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	taken := make(map[int]bool)
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			return nil, err
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			if writeErr.Code != duplicateKeyCode {
+				return nil, err
+			}
+			taken[writeErr.Index] = true
+		}
+	}
+
+	claimed := make([]models.LedgerEntry, 0, len(entries))
+	for i, entry := range entries {
+		if !taken[i] {
+			claimed = append(claimed, entry)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *repository) Save(ctx context.Context, entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Entries that were claimed are marked as delivered, keeping the time of the first delivery
+	writes := make([]mongo.WriteModel, len(entries))
+	for i, entry := range entries {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(entryFilter(entry)).
+			SetUpdate(bson.M{
+				"$setOnInsert": bson.M{
+					"pullRequest": entry.PullRequest,
+					"noteKey":     entry.NoteKey,
+					"claimedAt":   entry.ClaimedAt,
+				},
+				"$min": bson.M{"deliveredAt": entry.DeliveredAt},
+			}).
+			SetUpsert(true)
+	}
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Only claims are removed, an earlier delivery of the note stays in the ledger
+	filters := make(bson.A, len(entries))
+	for i, entry := range entries {
+		filters[i] = entryFilter(entry)
+	}
+	filter := bson.M{
+		"$or":         filters,
+		"deliveredAt": bson.M{"$exists": false},
+	}
+	_, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) GetByPullRequest(ctx context.Context, pr models.PullRequestSummary) ([]models.LedgerEntry, error) {
+	filter := bson.M{
+		"pullRequest.repoowner": pr.RepoOwner,
+		"pullRequest.reponame":  pr.RepoName,
+		"pullRequest.prnumber":  pr.PRNumber,
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		_ = cursor.Close(ctx)
+	}(cursor, ctx)
+
+	var entries []models.LedgerEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func entryFilter(entry models.LedgerEntry) bson.M {
+	return bson.M{
+		"pullRequest.repoowner": entry.PullRequest.RepoOwner,
+		"pullRequest.reponame":  entry.PullRequest.RepoName,
+		"pullRequest.prnumber":  entry.PullRequest.PRNumber,
+		"noteHash":              entry.NoteHash,
+		"contactType":           entry.ContactType,
+		"address":               entry.Address,
+	}
+}
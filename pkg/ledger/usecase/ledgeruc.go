@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
+
+type useCase struct {
+	repository domain.DeliveryLedgerRepository
+	notesUC    domain.ReleaseNotesUseCase
+}
+
+func NewUseCase(repository domain.DeliveryLedgerRepository, notesUC domain.ReleaseNotesUseCase) domain.DeliveryLedgerUseCase {
+	return &useCase{
+		repository: repository,
+		notesUC:    notesUC,
+	}
+}
+
+// delivery identifies the delivery of a release note to an address, as the unique index of the ledger does
+type delivery struct{ hash, contactType, address string }
+
+func (uc *useCase) Claim(ctx context.Context, notes []models.ReleaseNote, pr models.PullRequestSummary) ([]models.ReleaseNote, []models.LedgerEntry, error) {
+	entries, err := uc.repository.GetByPullRequest(ctx, pr)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get delivery ledger")
+	}
+	existing := make(map[delivery]models.LedgerEntry, len(entries))
+	for _, entry := range entries {
+		existing[delivery{entry.NoteHash, entry.ContactType, entry.Address}] = entry
+	}
+
+	now := time.Now()
+	hashes := make([]string, len(notes))
+	seen := make(map[delivery]bool)
+	var claims, duplicates []models.LedgerEntry
+	for i, note := range notes {
+		if hashes[i], err = uc.hash(note); err != nil {
+			return nil, nil, err
+		}
+		for _, team := range note.Teams {
+			for _, c := range team.GetChannels() {
+				for _, address := range c.Addresses {
+					key := delivery{hashes[i], c.ContactType, address}
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					if entry, ok := existing[key]; ok {
+						duplicates = append(duplicates, entry)
+						continue
+					}
+					claims = append(claims, models.LedgerEntry{
+						PullRequest: pr,
+						NoteHash:    hashes[i],
+						NoteKey:     note.Key(),
+						ContactType: c.ContactType,
+						Address:     address,
+						ClaimedAt:   now,
+					})
+				}
+			}
+		}
+	}
+
+	claimed, err := uc.repository.Claim(ctx, claims)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to claim deliveries in the ledger")
+	}
+	ours := make(map[delivery]bool, len(claimed))
+	for _, entry := range claimed {
+		ours[delivery{entry.NoteHash, entry.ContactType, entry.Address}] = true
+	}
+	// Deliveries that were claimed by another run since the ledger was read are duplicates too
+	for _, entry := range claims {
+		if !ours[delivery{entry.NoteHash, entry.ContactType, entry.Address}] {
+			duplicates = append(duplicates, entry)
+		}
+	}
+
+	var remaining []models.ReleaseNote
+	for i, note := range notes {
+		filtered := note.FilterAddresses(func(c models.Channel, address string) bool {
+			return ours[delivery{hashes[i], c.ContactType, address}]
+		})
+		if filtered.HasAddresses() {
+			remaining = append(remaining, filtered)
+		}
+	}
+	return remaining, duplicates, nil
+}
+
+func (uc *useCase) Record(ctx context.Context, notes []models.ReleaseNote, pr models.PullRequestSummary, refs []models.MessageReference) error {
+	// Only the first part of a note is recorded, the overflow of long notes isn't a note itself
+	var firstParts []models.MessageReference
+	for _, ref := range refs {
+		if ref.Part == 0 {
+			firstParts = append(firstParts, ref)
+		}
+	}
+	entries, err := uc.entries(notes, pr, firstParts)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range entries {
+		entries[i].ClaimedAt = now
+		entries[i].DeliveredAt = now
+	}
+	return uc.repository.Save(ctx, entries)
+}
+
+func (uc *useCase) Release(ctx context.Context, notes []models.ReleaseNote, pr models.PullRequestSummary, refs []models.MessageReference) error {
+	entries, err := uc.entries(notes, pr, refs)
+	if err != nil {
+		return err
+	}
+	return uc.repository.Delete(ctx, entries)
+}
+
+// entries returns the ledger entries of the references to the release notes, thread parents aren't notes so they don't
+// have entries
+func (uc *useCase) entries(notes []models.ReleaseNote, pr models.PullRequestSummary, refs []models.MessageReference) ([]models.LedgerEntry, error) {
+	hashes := make(map[string]string, len(notes))
+	for _, note := range notes {
+		hash, err := uc.hash(note)
+		if err != nil {
+			return nil, err
+		}
+		hashes[note.Key()] = hash
+	}
+
+	var entries []models.LedgerEntry
+	for _, ref := range refs {
+		hash, ok := hashes[ref.NoteKey]
+		if !ok {
+			continue
+		}
+		entries = append(entries, models.LedgerEntry{
+			PullRequest: pr,
+			NoteHash:    hash,
+			NoteKey:     ref.NoteKey,
+			ContactType: ref.ContactType,
+			Address:     ref.Address,
+		})
+	}
+	return entries, nil
+}
+
+func (uc *useCase) hash(note models.ReleaseNote) (string, error) {
+	hash, err := uc.notesUC.GenerateHash([]models.ReleaseNote{note})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate hash of release note for %s", note.Key())
+	}
+	return hash, nil
+}
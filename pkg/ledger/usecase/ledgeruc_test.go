@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spring-financial-group/peacock/pkg/domain/mocks"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	infraTeam = models.Team{
+		Name:        "infrastructure",
+		ContactType: models.Slack,
+		Addresses:   []string{"C1", "C2"},
+	}
+	supportTeam = models.Team{
+		Name:        "support",
+		ContactType: models.Webhook,
+		Addresses:   []string{"Webhook1"},
+	}
+	infraNote   = models.ReleaseNote{Teams: models.Teams{infraTeam}, Content: "Infra"}
+	supportNote = models.ReleaseNote{Teams: models.Teams{supportTeam}, Content: "Support"}
+	mockPR      = models.PullRequestSummary{
+		PRNumber:  1,
+		RepoOwner: "spring-financial-group",
+		RepoName:  "peacock",
+	}
+)
+
+func newTestUseCase(t *testing.T) (*useCase, *mocks.DeliveryLedgerRepository) {
+	mockRepo := mocks.NewDeliveryLedgerRepository(t)
+	mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+	mockNotesUC.On("GenerateHash", []models.ReleaseNote{infraNote}).Return("infra-hash", nil).Maybe()
+	mockNotesUC.On("GenerateHash", []models.ReleaseNote{supportNote}).Return("support-hash", nil).Maybe()
+	return NewUseCase(mockRepo, mockNotesUC).(*useCase), mockRepo
+}
+
+func TestUseCase_Claim(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name               string
+		entries            []models.LedgerEntry
+		taken              []string
+		expectedClaims     []string
+		expectedNotes      []models.ReleaseNote
+		expectedDuplicates int
+	}{
+		{
+			name:           "NothingDelivered",
+			expectedClaims: []string{"C1", "C2", "Webhook1"},
+			expectedNotes:  []models.ReleaseNote{infraNote, supportNote},
+		},
+		{
+			name: "SomeAddressesDelivered",
+			entries: []models.LedgerEntry{
+				{NoteHash: "infra-hash", ContactType: models.Slack, Address: "C1"},
+			},
+			expectedClaims: []string{"C2", "Webhook1"},
+			expectedNotes: []models.ReleaseNote{
+				{Teams: models.Teams{{Name: "infrastructure", ContactType: models.Slack, Addresses: []string{"C2"}}}, Content: "Infra"},
+				supportNote,
+			},
+			expectedDuplicates: 1,
+		},
+		{
+			name: "NoteDelivered",
+			entries: []models.LedgerEntry{
+				{NoteHash: "support-hash", ContactType: models.Webhook, Address: "Webhook1"},
+			},
+			expectedClaims:     []string{"C1", "C2"},
+			expectedNotes:      []models.ReleaseNote{infraNote},
+			expectedDuplicates: 1,
+		},
+		{
+			name: "EditedNoteNotDelivered",
+			entries: []models.LedgerEntry{
+				{NoteHash: "old-infra-hash", ContactType: models.Slack, Address: "C1"},
+			},
+			expectedClaims: []string{"C1", "C2", "Webhook1"},
+			expectedNotes:  []models.ReleaseNote{infraNote, supportNote},
+		},
+		{
+			name:           "ClaimedByAnotherRun",
+			taken:          []string{"C1", "Webhook1"},
+			expectedClaims: []string{"C1", "C2", "Webhook1"},
+			expectedNotes: []models.ReleaseNote{
+				{Teams: models.Teams{{Name: "infrastructure", ContactType: models.Slack, Addresses: []string{"C2"}}}, Content: "Infra"},
+			},
+			expectedDuplicates: 2,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, mockRepo := newTestUseCase(t)
+			mockRepo.On("GetByPullRequest", ctx, mockPR).Return(tt.entries, nil).Once()
+
+			var claims []string
+			mockRepo.On("Claim", ctx, mock.Anything).Return(func(_ context.Context, entries []models.LedgerEntry) []models.LedgerEntry {
+				var claimed []models.LedgerEntry
+				for _, entry := range entries {
+					claims = append(claims, entry.Address)
+					if !utils.ExistsInSlice(entry.Address, tt.taken) {
+						claimed = append(claimed, entry)
+					}
+				}
+				return claimed
+			}, nil).Once()
+
+			notes, duplicates, err := uc.Claim(ctx, []models.ReleaseNote{infraNote, supportNote}, mockPR)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedClaims, claims)
+			assert.Equal(t, tt.expectedNotes, notes)
+			assert.Len(t, duplicates, tt.expectedDuplicates)
+		})
+	}
+}
+
+func TestUseCase_Record(t *testing.T) {
+	ctx := context.Background()
+	uc, mockRepo := newTestUseCase(t)
+
+	refs := []models.MessageReference{
+		{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C1", ID: "1"},
+		// The overflow of a long note & thread parents aren't recorded
+		{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C1", ID: "2", Part: 1},
+		{ContactType: models.Slack, Address: "C1", ID: "0"},
+		{ContactType: models.Webhook, NoteKey: "support", Address: "Webhook1"},
+	}
+
+	var saved []models.LedgerEntry
+	mockRepo.On("Save", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(1).([]models.LedgerEntry)
+	}).Return(nil).Once()
+
+	err := uc.Record(ctx, []models.ReleaseNote{infraNote, supportNote}, mockPR, refs)
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	assert.Equal(t, "infra-hash", saved[0].NoteHash)
+	assert.Equal(t, "C1", saved[0].Address)
+	assert.False(t, saved[0].DeliveredAt.IsZero())
+	assert.Equal(t, "support-hash", saved[1].NoteHash)
+	assert.Equal(t, mockPR, saved[1].PullRequest)
+}
+
+func TestUseCase_Release(t *testing.T) {
+	ctx := context.Background()
+	uc, mockRepo := newTestUseCase(t)
+
+	refs := []models.MessageReference{
+		// A note whose overflow wasn't delivered is released too
+		{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C2", Part: 1},
+		{ContactType: models.Slack, Address: "C1"},
+	}
+
+	var deleted []models.LedgerEntry
+	mockRepo.On("Delete", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		deleted = args.Get(1).([]models.LedgerEntry)
+	}).Return(nil).Once()
+
+	err := uc.Release(ctx, []models.ReleaseNote{infraNote, supportNote}, mockPR, refs)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "infra-hash", deleted[0].NoteHash)
+	assert.Equal(t, models.Slack, deleted[0].ContactType)
+	assert.Equal(t, "C2", deleted[0].Address)
+}
@@ -3,6 +3,7 @@ package markdown_test
 import (
 	"testing"
 
+	"github.com/slack-go/slack"
 	"github.com/spring-financial-group/peacock/pkg/markdown"
 	"github.com/stretchr/testify/assert"
 )
@@ -139,3 +140,312 @@ func TestMarkdown_Converters(t *testing.T) {
 		})
 	}
 }
+
+func TestMarkdown_ConvertToPlainText(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputMarkdown string
+		expectedText  string
+	}{
+		{
+			name:          "HeadingsAndEmphasis",
+			inputMarkdown: "# Release\r\n## **Promoted Services**\r\nSome _important_ ~~old~~ `code`",
+			expectedText:  "Release\n\nPromoted Services\n\nSome important old code",
+		},
+		{
+			name:          "Links",
+			inputMarkdown: "See the [docs](https://github.com/spring-financial-group/peacock) or https://example.com",
+			expectedText:  "See the docs (https://github.com/spring-financial-group/peacock) or https://example.com",
+		},
+		{
+			name:          "NestedLists",
+			inputMarkdown: "1. New queries added:\n    * By product class\n    * By date\n2. Bug fixes",
+			expectedText:  "1. New queries added:\n  * By product class\n  * By date\n2. Bug fixes",
+		},
+		{
+			name:          "CodeBlock",
+			inputMarkdown: "Run:\n```shell\nmake build\nmake test\n```",
+			expectedText:  "Run:\n\n  make build\n  make test",
+		},
+		{
+			name:          "Table",
+			inputMarkdown: "| Service | Version |\n|---|---|\n| api | 1.2 |\n| web | 3.4 |",
+			expectedText:  "Service | Version\n-----------------\napi | 1.2\nweb | 3.4",
+		},
+		{
+			name:          "HTMLAndEntities",
+			inputMarkdown: "<!-- Write your notes below -->\nFixes &amp; improvements<br>",
+			expectedText:  "Fixes & improvements",
+		},
+		{
+			name:          "DetailsBlock",
+			inputMarkdown: "<details>\n<summary>Click to expand</summary>\n\nHidden content here\n\n</details>",
+			expectedText:  "Click to expand\n\nHidden content here",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedText, markdown.ConvertToPlainText(tt.inputMarkdown))
+		})
+	}
+}
+
+func TestMarkdown_ConvertToAdaptiveCard(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputMarkdown    string
+		expectedElements []markdown.AdaptiveCardElement
+	}{
+		{
+			name:          "Headings",
+			inputMarkdown: "# Heading\n## **Subheading**\n### Minor",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "Heading", Wrap: true, Size: "Large", Weight: "Bolder"},
+				{Type: "TextBlock", Text: "**Subheading**", Wrap: true, Size: "Medium", Weight: "Bolder"},
+				{Type: "TextBlock", Text: "Minor", Wrap: true, Weight: "Bolder"},
+			},
+		},
+		{
+			name:          "ParagraphWithLinkAndEmphasis",
+			inputMarkdown: "Some _important_ [docs](https://github.com/spring-financial-group/peacock)\nSecond line",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "Some _important_ [docs](https://github.com/spring-financial-group/peacock)\nSecond line", Wrap: true},
+			},
+		},
+		{
+			name:          "NestedBulletList",
+			inputMarkdown: "* New queries added:\n   * By product class\n   * By date\n* Bug fixes",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "- New queries added:\n    - By product class\n    - By date\n- Bug fixes", Wrap: true},
+			},
+		},
+		{
+			name:          "OrderedList",
+			inputMarkdown: "1. First\n2. Second",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "1. First\n2. Second", Wrap: true},
+			},
+		},
+		{
+			name:          "CodeBlock",
+			inputMarkdown: "```bash\nmake install\n```",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "make install", Wrap: true, FontType: "Monospace"},
+			},
+		},
+		{
+			name:          "DividerBetweenNotes",
+			inputMarkdown: "First note\n\n---\n\nSecond note",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "First note", Wrap: true},
+				{Type: "TextBlock", Text: "Second note", Wrap: true, Separator: true},
+			},
+		},
+		{
+			name:          "DetailsBlock",
+			inputMarkdown: "<details>\n<summary>Click to expand</summary>\n\nHidden content here\n\n</details>",
+			expectedElements: []markdown.AdaptiveCardElement{
+				{Type: "TextBlock", Text: "Click to expand", Wrap: true, Size: "Medium", Weight: "Bolder"},
+				{Type: "TextBlock", Text: "Hidden content here", Wrap: true},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := markdown.ConvertToAdaptiveCard(tt.inputMarkdown)
+			assert.Equal(t, tt.expectedElements, actual)
+		})
+	}
+}
+
+func TestMarkdown_ConvertToSlackBlocks(t *testing.T) {
+	header := func(text string) slack.Block {
+		return slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, text, true, false))
+	}
+	section := func(text string) slack.Block {
+		return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+	}
+
+	testCases := []struct {
+		name           string
+		inputMarkdown  string
+		expectedBlocks []slack.Block
+	}{
+		{
+			name:           "HeadingWithEmbolden",
+			inputMarkdown:  "### **Promoted Services**",
+			expectedBlocks: []slack.Block{header("Promoted Services")},
+		},
+		{
+			name:           "ParagraphWithInlineMarkup",
+			inputMarkdown:  "**Bold** and _italic_ with `code` & a [link](https://github.com/spring-financial-group/peacock)",
+			expectedBlocks: []slack.Block{section("*Bold* and _italic_ with `code` &amp; a <https://github.com/spring-financial-group/peacock|link>")},
+		},
+		{
+			name:          "NestedBulletsAndTasks",
+			inputMarkdown: "* Queries added:\n   * By product class\n- [ ] Not done\n- [x] Done",
+			expectedBlocks: []slack.Block{
+				section("• Queries added:\n    • By product class"),
+				section("☐ Not done\n☒ Done"),
+			},
+		},
+		{
+			name:           "CodeFence",
+			inputMarkdown:  "```go\nfmt.Println(\"hello\")\n```",
+			expectedBlocks: []slack.Block{section("```\nfmt.Println(\"hello\")\n```")},
+		},
+		{
+			name:          "DividerBetweenMergedNotes",
+			inputMarkdown: "# First\nSome text\n\n---\n\n# Second",
+			expectedBlocks: []slack.Block{
+				header("First"),
+				section("Some text"),
+				slack.NewDividerBlock(),
+				header("Second"),
+			},
+		},
+		{
+			name:           "Table",
+			inputMarkdown:  "| Service | Version |\n|---|---|\n| peacock | 1.0.0 |",
+			expectedBlocks: []slack.Block{section("```\nService | Version\npeacock | 1.0.0\n```")},
+		},
+		{
+			name:           "GithubLinkReplacement",
+			inputMarkdown:  "spring-financial-group/mqube-property-service#770",
+			expectedBlocks: []slack.Block{section("<https://github.com/spring-financial-group/mqube-property-service/pull/770|spring-financial-group/mqube-property-service#770>")},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := markdown.ConvertToSlackBlocks(tt.inputMarkdown)
+			assert.Equal(t, tt.expectedBlocks, actual)
+		})
+	}
+}
+
+func TestMarkdown_SplitBlocks(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputMarkdown  string
+		limit          int
+		expectedChunks []string
+	}{
+		{
+			name:           "UnderLimit",
+			inputMarkdown:  "# Title\nSome text",
+			limit:          100,
+			expectedChunks: []string{"# Title\nSome text"},
+		},
+		{
+			name:           "NoLimit",
+			inputMarkdown:  "# Title\nSome text",
+			limit:          0,
+			expectedChunks: []string{"# Title\nSome text"},
+		},
+		{
+			name:          "SplitBetweenBlocks",
+			inputMarkdown: "# Title\nFirst paragraph\n\nSecond paragraph\n\nThird paragraph",
+			limit:         34,
+			expectedChunks: []string{
+				"# Title\n\nFirst paragraph",
+				"Second paragraph\n\nThird paragraph",
+			},
+		},
+		{
+			name:          "ListsKeptWhole",
+			inputMarkdown: "Intro\n* one\n* two\n* three\n\nOutro",
+			limit:         24,
+			expectedChunks: []string{
+				"Intro",
+				"* one\n* two\n* three",
+				"Outro",
+			},
+		},
+		{
+			name:          "LongCodeFenceReopened",
+			inputMarkdown: "```sql\nSELECT 1;\nSELECT 2;\nSELECT 3;\n```",
+			limit:         30,
+			expectedChunks: []string{
+				"```sql\nSELECT 1;\nSELECT 2;\n```",
+				"```sql\nSELECT 3;\n```",
+			},
+		},
+		{
+			name:          "LinkReferencesKept",
+			inputMarkdown: "See [the docs][docs]\n\n[docs]: https://example.com\n\nAnother paragraph",
+			limit:         50,
+			expectedChunks: []string{
+				"See [the docs][docs]\n\n[docs]: https://example.com",
+				"Another paragraph",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actualChunks := markdown.SplitBlocks(tt.inputMarkdown, tt.limit)
+			assert.Equal(t, tt.expectedChunks, actualChunks)
+			for _, chunk := range actualChunks {
+				if tt.limit > 0 {
+					assert.LessOrEqual(t, len(chunk), tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdown_SplitSlackBlocks(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputMarkdown  string
+		limit          int
+		expectedChunks []string
+	}{
+		{
+			name:           "UnderLimit",
+			inputMarkdown:  "# Title\nSome text",
+			limit:          2,
+			expectedChunks: []string{"# Title\nSome text"},
+		},
+		{
+			name:           "NoLimit",
+			inputMarkdown:  "# One\n# Two\n# Three",
+			limit:          0,
+			expectedChunks: []string{"# One\n# Two\n# Three"},
+		},
+		{
+			name:          "SplitBetweenBlocks",
+			inputMarkdown: "# One\nFirst\n\n---\n# Two\nSecond",
+			limit:         2,
+			expectedChunks: []string{
+				"# One\n\nFirst",
+				"---\n\n# Two",
+				"Second",
+			},
+		},
+		{
+			name:          "ListsKeptWhole",
+			inputMarkdown: "Intro\n* one\n* two\n* three\n\nOutro",
+			limit:         2,
+			expectedChunks: []string{
+				"Intro\n\n* one\n* two\n* three",
+				"Outro",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actualChunks := markdown.SplitSlackBlocks(tt.inputMarkdown, tt.limit)
+			assert.Equal(t, tt.expectedChunks, actualChunks)
+			for _, chunk := range actualChunks {
+				if tt.limit > 0 {
+					assert.LessOrEqual(t, len(markdown.ConvertToSlackBlocks(chunk)), tt.limit)
+				}
+			}
+		})
+	}
+}
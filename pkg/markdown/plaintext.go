@@ -0,0 +1,38 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+	"github.com/spring-financial-group/peacock/pkg/utils/templates"
+)
+
+// plainTextExtensions are the GitHub flavoured extensions understood when converting to plain text. Newlines are kept
+// as line breaks to match the HTML conversion.
+const plainTextExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+	blackfriday.EXTENSION_TABLES |
+	blackfriday.EXTENSION_FENCED_CODE |
+	blackfriday.EXTENSION_AUTOLINK |
+	blackfriday.EXTENSION_STRIKETHROUGH |
+	blackfriday.EXTENSION_HARD_LINE_BREAK
+
+var (
+	trailingSpaceRegex = regexp.MustCompile(`(?m)[ \t]+$`)
+	blankLinesRegex    = regexp.MustCompile(`\n{3,}`)
+)
+
+// ConvertToPlainText converts the Markdown syntax into readable plain text for channels that can't render any markup.
+// Links are written as their text followed by the URL, lists are indented by their level and raw HTML is dropped.
+func ConvertToPlainText(markdown string) string {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	markdown = stripDetailsTags(markdown)
+
+	renderer := &templates.ASCIIRenderer{Indentation: templates.Indentation, SkipHTML: true}
+	text := string(blackfriday.Markdown([]byte(markdown), renderer, plainTextExtensions))
+
+	// The renderer separates every block with line breaks, so tidy up the whitespace it leaves behind
+	text = trailingSpaceRegex.ReplaceAllString(text, "")
+	text = blankLinesRegex.ReplaceAllString(text, "\n\n")
+	return strings.Trim(text, "\n")
+}
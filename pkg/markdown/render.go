@@ -0,0 +1,153 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+
+	md "gitlab.com/golang-commonmark/markdown"
+)
+
+// inlineSyntax describes how a target format marks up the inline elements of a Markdown document
+type inlineSyntax struct {
+	strong        string
+	emphasis      string
+	strikethrough string
+	code          string
+	// link renders a hyperlink from its text and destination
+	link func(text, href string) string
+	// escape is applied to all literal text, it may be nil if the format needs no escaping
+	escape func(text string) string
+}
+
+// renderInline renders the children of an md.Inline token using the given syntax
+func renderInline(tokens []md.Token, syntax inlineSyntax) string {
+	var sb strings.Builder
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i].(type) {
+		case *md.Text:
+			sb.WriteString(syntax.escapeText(tok.Content))
+		case *md.StrongOpen, *md.StrongClose:
+			sb.WriteString(syntax.strong)
+		case *md.EmphasisOpen, *md.EmphasisClose:
+			sb.WriteString(syntax.emphasis)
+		case *md.StrikethroughOpen, *md.StrikethroughClose:
+			sb.WriteString(syntax.strikethrough)
+		case *md.CodeInline:
+			sb.WriteString(syntax.code + tok.Content + syntax.code)
+		case *md.Softbreak, *md.Hardbreak:
+			sb.WriteString("\n")
+		case *md.LinkOpen:
+			// Render everything up to the matching close as the text of the link
+			end := i + 1
+			for ; end < len(tokens); end++ {
+				if _, ok := tokens[end].(*md.LinkClose); ok {
+					break
+				}
+			}
+			text := renderInline(tokens[i+1:min(end, len(tokens))], syntax)
+			sb.WriteString(syntax.link(text, tok.Href))
+			i = end
+		case *md.Image:
+			sb.WriteString(syntax.link(renderInline(tok.Tokens, syntax), tok.Src))
+		}
+	}
+	return sb.String()
+}
+
+func (s inlineSyntax) escapeText(text string) string {
+	if s.escape == nil {
+		return text
+	}
+	return s.escape(text)
+}
+
+// parseTokens normalises the Markdown and parses it into a flat stream of block tokens
+func parseTokens(markdown string) []md.Token {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	markdown = stripDetailsTags(markdown)
+	return md.New(md.HTML(true), md.Typographer(false)).Parse([]byte(markdown))
+}
+
+// closingIndex returns the index of the token closing the block opened at tokens[start]
+func closingIndex(tokens []md.Token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch {
+		case tokens[i].Opening():
+			depth++
+		case tokens[i].Closing():
+			depth--
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+	return len(tokens) - 1
+}
+
+// renderInlineTokens renders all the md.Inline tokens found in a slice of tokens
+func renderInlineTokens(tokens []md.Token, syntax inlineSyntax) string {
+	var lines []string
+	for _, tok := range tokens {
+		if inline, ok := tok.(*md.Inline); ok {
+			lines = append(lines, renderInline(inline.Children, syntax))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderList renders a (possibly nested) list as one line per item, indenting nested items. Bullet lists use the
+// bullet marker and ordered lists are numbered.
+func renderList(tokens []md.Token, syntax inlineSyntax, bullet string) string {
+	var lines []string
+	// counters holds the next number for each level of nesting, bullet lists are represented by -1
+	var counters []int
+	var itemStart bool
+	for _, tok := range tokens {
+		switch t := tok.(type) {
+		case *md.BulletListOpen:
+			counters = append(counters, -1)
+		case *md.OrderedListOpen:
+			counters = append(counters, t.Order)
+		case *md.BulletListClose, *md.OrderedListClose:
+			counters = counters[:len(counters)-1]
+		case *md.ListItemOpen:
+			itemStart = true
+		case *md.Inline:
+			depth := len(counters) - 1
+			indent := strings.Repeat("    ", depth)
+			marker := "  "
+			if itemStart {
+				marker = bullet
+				if counters[depth] >= 0 {
+					marker = fmt.Sprintf("%d. ", counters[depth])
+					counters[depth]++
+				}
+				itemStart = false
+			}
+			lines = append(lines, indent+marker+renderInline(t.Children, syntax))
+		case *md.Fence:
+			lines = append(lines, strings.TrimSuffix(t.Content, "\n"))
+		case *md.CodeBlock:
+			lines = append(lines, strings.TrimSuffix(t.Content, "\n"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderTable renders a table as one line per row with the cells separated by pipes
+func renderTable(tokens []md.Token, syntax inlineSyntax) string {
+	var rows []string
+	var cells []string
+	for _, tok := range tokens {
+		switch t := tok.(type) {
+		case *md.TrOpen:
+			cells = nil
+		case *md.Inline:
+			cells = append(cells, renderInline(t.Children, syntax))
+		case *md.TrClose:
+			rows = append(rows, strings.Join(cells, " | "))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
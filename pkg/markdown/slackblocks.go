@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+	md "gitlab.com/golang-commonmark/markdown"
+)
+
+const (
+	// slackHeaderLimit is the maximum number of characters in the text of a header block
+	slackHeaderLimit = 150
+	// slackSectionLimit is the maximum number of characters in the text of a section block
+	slackSectionLimit = 3000
+)
+
+var (
+	slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	// githubRefRegex matches GitHub pull request references e.g. ORG/REPO_NAME#PR
+	githubRefRegex = regexp.MustCompile(`\b([\w.-]+)/([\w.-]+)#(\d+)\b`)
+
+	uncheckedTaskRegex = regexp.MustCompile(`(?m)^(\s*)• \[ ] `)
+	checkedTaskRegex   = regexp.MustCompile(`(?m)^(\s*)• \[[xX]] `)
+)
+
+var slackInlineSyntax = inlineSyntax{
+	strong:        "*",
+	emphasis:      "_",
+	strikethrough: "~",
+	code:          "`",
+	link: func(text, href string) string {
+		if text == "" || text == href {
+			return fmt.Sprintf("<%s>", href)
+		}
+		return fmt.Sprintf("<%s|%s>", href, text)
+	},
+	escape: func(text string) string {
+		text = slackEscaper.Replace(text)
+		return githubRefRegex.ReplaceAllString(text, "<https://github.com/$1/$2/pull/$3|$0>")
+	},
+}
+
+// plainInlineSyntax strips all inline markup, used where Slack only accepts plain_text
+var plainInlineSyntax = inlineSyntax{
+	link: func(text, _ string) string { return text },
+}
+
+// ConvertToSlackBlocks converts the Markdown syntax into Slack Block Kit blocks. Headings become header blocks,
+// horizontal rules become dividers and all other content is rendered as Slack Markup inside section blocks.
+func ConvertToSlackBlocks(markdown string) []slack.Block {
+	tokens := parseTokens(markdown)
+
+	var blocks []slack.Block
+	addSection := func(text string) {
+		for _, chunk := range splitOnLines(text, slackSectionLimit) {
+			blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, chunk, false, false), nil, nil))
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i].(type) {
+		case *md.HeadingOpen:
+			end := closingIndex(tokens, i)
+			text := truncate(renderInlineTokens(tokens[i:end], plainInlineSyntax), slackHeaderLimit)
+			blocks = append(blocks, slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, text, true, false)))
+			i = end
+		case *md.ParagraphOpen:
+			end := closingIndex(tokens, i)
+			addSection(renderInlineTokens(tokens[i:end], slackInlineSyntax))
+			i = end
+		case *md.BlockquoteOpen:
+			end := closingIndex(tokens, i)
+			addSection(prefixLines(renderInlineTokens(tokens[i:end], slackInlineSyntax), "> "))
+			i = end
+		case *md.BulletListOpen, *md.OrderedListOpen:
+			end := closingIndex(tokens, i)
+			text := renderList(tokens[i:end+1], slackInlineSyntax, "• ")
+			text = uncheckedTaskRegex.ReplaceAllString(text, "$1☐ ")
+			text = checkedTaskRegex.ReplaceAllString(text, "$1☒ ")
+			addSection(text)
+			i = end
+		case *md.TableOpen:
+			end := closingIndex(tokens, i)
+			addSection(codeBlock(renderTable(tokens[i:end+1], plainInlineSyntax)))
+			i = end
+		case *md.Fence:
+			addSection(codeBlock(tok.Content))
+		case *md.CodeBlock:
+			addSection(codeBlock(tok.Content))
+		case *md.Hr:
+			blocks = append(blocks, slack.NewDividerBlock())
+		}
+	}
+	return blocks
+}
+
+// SplitSlackBlocks splits the Markdown between its top level blocks into chunks that are each converted into no more
+// than the limit of Slack blocks. A single block converted into more than the limit is kept whole.
+func SplitSlackBlocks(markdown string, limit int) []string {
+	return splitByCost(markdown, limit, func(block string) int {
+		return len(ConvertToSlackBlocks(block))
+	})
+}
+
+func codeBlock(content string) string {
+	return fmt.Sprintf("```\n%s\n```", strings.TrimSuffix(content, "\n"))
+}
+
+func prefixLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// splitOnLines splits text into chunks no longer than the limit, breaking on new lines where possible
+func splitOnLines(text string, limit int) []string {
+	var chunks []string
+	var current string
+	for _, line := range strings.Split(text, "\n") {
+		for len(line) > limit {
+			if current != "" {
+				chunks = append(chunks, current)
+				current = ""
+			}
+			// Make sure we don't split in the middle of a multibyte character
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			chunks = append(chunks, line[:cut])
+			line = line[cut:]
+		}
+		switch {
+		case current == "":
+			current = line
+		case len(current)+len(line)+1 > limit:
+			chunks = append(chunks, current)
+			current = line
+		default:
+			current += "\n" + line
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
@@ -0,0 +1,154 @@
+package markdown
+
+import (
+	"strings"
+
+	md "gitlab.com/golang-commonmark/markdown"
+)
+
+// SplitBlocks splits the Markdown into chunks no longer than the limit. Chunks are only split between top level blocks
+// so that lists, tables & code fences are kept whole. A single block longer than the limit is split on its lines, code
+// fences are closed & reopened in each chunk so that they still render as code.
+func SplitBlocks(markdown string, limit int) []string {
+	if limit <= 0 || len(markdown) <= limit {
+		return []string{markdown}
+	}
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+
+	var chunks []string
+	var current string
+	for _, block := range topLevelBlocks(markdown) {
+		if len(block) > limit {
+			if current != "" {
+				chunks = append(chunks, current)
+				current = ""
+			}
+			chunks = append(chunks, splitBlock(block, limit)...)
+			continue
+		}
+
+		switch {
+		case current == "":
+			current = block
+		case len(current)+len("\n\n")+len(block) > limit:
+			chunks = append(chunks, current)
+			current = block
+		default:
+			current += "\n\n" + block
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// splitByCost splits the Markdown between its top level blocks into chunks whose blocks cost no more than the limit in
+// total. A single block that costs more than the limit is kept whole.
+func splitByCost(markdown string, limit int, cost func(block string) int) []string {
+	if limit <= 0 || cost(markdown) <= limit {
+		return []string{markdown}
+	}
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+
+	var chunks []string
+	var current string
+	total := 0
+	for _, block := range topLevelBlocks(markdown) {
+		n := cost(block)
+		if current != "" && total+n > limit {
+			chunks = append(chunks, current)
+			current, total = "", 0
+		}
+		if current == "" {
+			current = block
+		} else {
+			current += "\n\n" + block
+		}
+		total += n
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// topLevelBlocks splits the Markdown into its top level blocks. Each block runs until the start of the next so that
+// anything the parser doesn't create a token for (e.g. link reference definitions) is kept.
+func topLevelBlocks(markdown string) []string {
+	lines := strings.Split(markdown, "\n")
+	tokens := md.New(md.HTML(true), md.Typographer(false)).Parse([]byte(markdown))
+
+	var starts []int
+	for _, tok := range tokens {
+		if tok.Level() != 0 {
+			continue
+		}
+		if start, ok := blockStartLine(tok); ok && start > 0 {
+			starts = append(starts, start)
+		}
+	}
+
+	var blocks []string
+	prev := 0
+	for _, start := range append(starts, len(lines)) {
+		if block := strings.TrimSpace(strings.Join(lines[prev:start], "\n")); block != "" {
+			blocks = append(blocks, block)
+		}
+		prev = start
+	}
+	return blocks
+}
+
+// blockStartLine returns the line the block opened by the token starts on
+func blockStartLine(tok md.Token) (int, bool) {
+	switch t := tok.(type) {
+	case *md.ParagraphOpen:
+		return t.Map[0], true
+	case *md.HeadingOpen:
+		return t.Map[0], true
+	case *md.BlockquoteOpen:
+		return t.Map[0], true
+	case *md.BulletListOpen:
+		return t.Map[0], true
+	case *md.OrderedListOpen:
+		return t.Map[0], true
+	case *md.TableOpen:
+		return t.Map[0], true
+	case *md.Fence:
+		return t.Map[0], true
+	case *md.CodeBlock:
+		return t.Map[0], true
+	case *md.HTMLBlock:
+		return t.Map[0], true
+	case *md.Hr:
+		return t.Map[0], true
+	}
+	return 0, false
+}
+
+// splitBlock splits a single block that is longer than the limit on its lines
+func splitBlock(block string, limit int) []string {
+	lines := strings.Split(block, "\n")
+	opening := strings.TrimSpace(lines[0])
+	if len(lines) < 2 || !(strings.HasPrefix(opening, "```") || strings.HasPrefix(opening, "~~~")) {
+		return splitOnLines(block, limit)
+	}
+
+	// Code fences are split on their content and each chunk is wrapped in the original fence
+	fence := opening[:3]
+	content := lines[1:]
+	if strings.HasPrefix(strings.TrimSpace(content[len(content)-1]), fence) {
+		content = content[:len(content)-1]
+	}
+	contentLimit := limit - len(opening) - len(fence) - 2
+	if contentLimit <= 0 {
+		return splitOnLines(block, limit)
+	}
+
+	var chunks []string
+	for _, chunk := range splitOnLines(strings.Join(content, "\n"), contentLimit) {
+		chunks = append(chunks, opening+"\n"+chunk+"\n"+fence)
+	}
+	return chunks
+}
@@ -0,0 +1,116 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	md "gitlab.com/golang-commonmark/markdown"
+)
+
+// Adaptive Card TextBlock styles
+const (
+	adaptiveTextBlock = "TextBlock"
+
+	adaptiveSizeLarge     = "Large"
+	adaptiveSizeMedium    = "Medium"
+	adaptiveWeightBolder  = "Bolder"
+	adaptiveFontMonospace = "Monospace"
+)
+
+// AdaptiveCardElement is a TextBlock element in the body of an Adaptive Card. Teams only supports a small subset of
+// Markdown inside a TextBlock, so block level structures (headings, code blocks etc.) are expressed through the
+// styling of the element instead.
+type AdaptiveCardElement struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Wrap      bool   `json:"wrap"`
+	Size      string `json:"size,omitempty"`
+	Weight    string `json:"weight,omitempty"`
+	FontType  string `json:"fontType,omitempty"`
+	Separator bool   `json:"separator,omitempty"`
+}
+
+var teamsInlineSyntax = inlineSyntax{
+	strong:   "**",
+	emphasis: "_",
+	link: func(text, href string) string {
+		return fmt.Sprintf("[%s](%s)", text, href)
+	},
+}
+
+// SplitAdaptiveCard splits the Markdown between its top level blocks into chunks that are each converted into card
+// elements no larger than the limit in bytes once serialised. A single block converted into more than the limit is
+// kept whole.
+func SplitAdaptiveCard(markdown string, limit int) []string {
+	return splitByCost(markdown, limit, adaptiveCardSize)
+}
+
+// adaptiveCardSize is the size of the elements the Markdown is converted into once serialised, including the comma
+// that separates each element in the body of the card
+func adaptiveCardSize(markdown string) int {
+	size := 0
+	for _, element := range ConvertToAdaptiveCard(markdown) {
+		data, _ := json.Marshal(element)
+		size += len(data) + 1
+	}
+	return size
+}
+
+// ConvertToAdaptiveCard converts the Markdown syntax into the body elements of an Adaptive Card.
+func ConvertToAdaptiveCard(markdown string) []AdaptiveCardElement {
+	tokens := parseTokens(markdown)
+
+	var elements []AdaptiveCardElement
+	var separator bool
+	add := func(e AdaptiveCardElement) {
+		e.Type = adaptiveTextBlock
+		e.Wrap = true
+		e.Separator = separator
+		separator = false
+		elements = append(elements, e)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i].(type) {
+		case *md.HeadingOpen:
+			end := closingIndex(tokens, i)
+			add(AdaptiveCardElement{
+				Text:   renderInlineTokens(tokens[i:end], teamsInlineSyntax),
+				Size:   adaptiveHeadingSize(tok.HLevel),
+				Weight: adaptiveWeightBolder,
+			})
+			i = end
+		case *md.ParagraphOpen:
+			end := closingIndex(tokens, i)
+			add(AdaptiveCardElement{Text: renderInlineTokens(tokens[i:end], teamsInlineSyntax)})
+			i = end
+		case *md.BulletListOpen, *md.OrderedListOpen:
+			end := closingIndex(tokens, i)
+			add(AdaptiveCardElement{Text: renderList(tokens[i:end+1], teamsInlineSyntax, "- ")})
+			i = end
+		case *md.TableOpen:
+			end := closingIndex(tokens, i)
+			add(AdaptiveCardElement{Text: renderTable(tokens[i:end+1], teamsInlineSyntax)})
+			i = end
+		case *md.Fence:
+			add(AdaptiveCardElement{Text: strings.TrimSuffix(tok.Content, "\n"), FontType: adaptiveFontMonospace})
+		case *md.CodeBlock:
+			add(AdaptiveCardElement{Text: strings.TrimSuffix(tok.Content, "\n"), FontType: adaptiveFontMonospace})
+		case *md.Hr:
+			separator = true
+		}
+	}
+	return elements
+}
+
+func adaptiveHeadingSize(level int) string {
+	switch level {
+	case 1:
+		return adaptiveSizeLarge
+	case 2:
+		return adaptiveSizeMedium
+	default:
+		return ""
+	}
+}
@@ -1,5 +1,12 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
+const GitHubURL = "https://github.com"
+
 // Pull request states
 const (
 	OpenState   = "open"
@@ -18,6 +25,11 @@ type PullRequestEventDTO struct {
 	SHA           string
 	Branch        string
 	DefaultBranch string
+	// MergeSHA is the SHA of the commit the PR was merged as, empty until it's merged
+	MergeSHA string
+	// MergedAt is when the PR was merged, zero until it's merged
+	MergedAt time.Time
+	Title    string
 }
 
 // PullRequestSummary is a summary of the PR details to be stored alongside release notes
@@ -25,6 +37,17 @@ type PullRequestSummary struct {
 	PRNumber  int
 	RepoOwner string
 	RepoName  string
+	MergeSHA  string
+	// Environment is the environment the PR was released to, if it changed the helmfiles of one
+	Environment string
+	Title       string
+	Author      string
+	MergedAt    time.Time
+}
+
+// URL returns the URL of the pull request on GitHub
+func (p PullRequestSummary) URL() string {
+	return fmt.Sprintf("%s/%s/%s/pull/%d", GitHubURL, p.RepoOwner, p.RepoName, p.PRNumber)
 }
 
 func (p *PullRequestEventDTO) Summary() PullRequestSummary {
@@ -32,5 +55,9 @@ func (p *PullRequestEventDTO) Summary() PullRequestSummary {
 		PRNumber:  p.PRNumber,
 		RepoOwner: p.RepoOwner,
 		RepoName:  p.RepoName,
+		MergeSHA:  p.MergeSHA,
+		Title:     p.Title,
+		Author:    p.PROwner,
+		MergedAt:  p.MergedAt,
 	}
 }
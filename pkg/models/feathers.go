@@ -1,14 +1,159 @@
 package models
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/utils"
+)
+
 type Feathers struct {
-	Teams  Teams  `yaml:"teams"`
-	Config Config `yaml:"config"`
+	// Extends is the source of feathers shared between repositories, which these feathers are merged over
+	Extends string `yaml:"extends,omitempty"`
+	Teams   Teams  `yaml:"teams"`
+	// Groups can be notified in place of listing each of their members, which are the names of teams or other groups
+	Groups map[string][]string `yaml:"groups,omitempty"`
+	// Paths are the teams or groups that own the files matching each glob, so that they're notified of any changes to them
+	Paths  map[string][]string `yaml:"paths,omitempty"`
+	Config Config              `yaml:"config"`
+}
+
+// FeathersSource is where the feathers that are extended are stored, written as owner/repo@branch:path
+type FeathersSource struct {
+	Owner  string
+	Repo   string
+	Branch string
+	Path   string
+}
+
+// ParseFeathersSource parses the source of extended feathers, e.g. org/peacock-config@main:teams.yaml
+func ParseFeathersSource(source string) (FeathersSource, error) {
+	repo, path, _ := strings.Cut(source, ":")
+	repo, branch, _ := strings.Cut(repo, "@")
+	owner, repo, _ := strings.Cut(repo, "/")
+	if owner == "" || repo == "" || strings.Contains(repo, "/") || branch == "" || path == "" {
+		return FeathersSource{}, errors.Errorf("invalid extends %s, must be in the form owner/repo@branch:path", source)
+	}
+	return FeathersSource{
+		Owner:  owner,
+		Repo:   repo,
+		Branch: branch,
+		Path:   path,
+	}, nil
+}
+
+func (s FeathersSource) String() string {
+	return fmt.Sprintf("%s/%s@%s:%s", s.Owner, s.Repo, s.Branch, s.Path)
+}
+
+// GetPathOwners returns the names of the teams that own any of the changed files, with groups expanded to their teams.
+// A file matching several of the globs is owned by the teams of all of them.
+func (f *Feathers) GetPathOwners(files []string) ([]string, error) {
+	// The globs are matched in order so that the owners are always returned in the same order
+	globs := make([]string, 0, len(f.Paths))
+	for glob := range f.Paths {
+		globs = append(globs, glob)
+	}
+	sort.Strings(globs)
+
+	var owners []string
+	for _, glob := range globs {
+		for _, file := range files {
+			if utils.MatchGlob(glob, file) {
+				owners = append(owners, f.Paths[glob]...)
+				break
+			}
+		}
+	}
+	return f.ExpandTeamNames(owners...)
+}
+
+// ExpandTeamNames replaces the names of groups with the names of the teams in them, keeping the order in which they
+// first appear. Each team is only included once, even if it's in several of the groups or is also named on its own.
+func (f *Feathers) ExpandTeamNames(names ...string) ([]string, error) {
+	var expanded []string
+	for _, name := range names {
+		if err := f.expandTeamName(name, nil, &expanded); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// expandTeamName adds the teams of the name to expanded, path is the groups being expanded so that cycles are found
+func (f *Feathers) expandTeamName(name string, path []string, expanded *[]string) error {
+	members, isGroup := f.Groups[name]
+	if !isGroup {
+		if !utils.ExistsInSlice(name, *expanded) {
+			*expanded = append(*expanded, name)
+		}
+		return nil
+	}
+	if utils.ExistsInSlice(name, path) {
+		return errors.Errorf("group %s contains itself: %s", name, strings.Join(append(path, name), " -> "))
+	}
+	for _, member := range members {
+		if err := f.expandTeamName(member, append(path, name), expanded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasTemplate returns whether the release notes of any of the teams are wrapped in a message template
+func (f *Feathers) HasTemplate() bool {
+	if f.Config.Messages.Template != "" {
+		return true
+	}
+	for _, team := range f.Teams {
+		if team.Messages != nil && team.Messages.Template != "" {
+			return true
+		}
+	}
+	return false
 }
 
 type Config struct {
-	Messages Messages `yaml:"messages"`
+	Messages Messages    `yaml:"messages"`
+	Paths    PathsConfig `yaml:"paths,omitempty"`
+}
+
+// PathsConfig is how pull requests are validated against the owners of the paths they change
+type PathsConfig struct {
+	// Required is whether a pull request fails validation when its release notes don't notify the owners of the paths
+	// it changes, otherwise the missing teams are only suggested in the breakdown
+	Required bool `yaml:"required,omitempty"`
 }
 
 type Messages struct {
 	Subject string `yaml:"subject"`
+	// Template is a Go template that wraps the content of each release note, see MessageTemplateData for the variables
+	Template string `yaml:"template,omitempty"`
+	// Metadata is whether the messages link back to the repository & pull request they came from, by default they do
+	Metadata *bool `yaml:"metadata,omitempty"`
+}
+
+// Override returns a copy of the messages config with the fields that are set in the override taking precedence, so that
+// a team can change how its release notes are presented
+func (m Messages) Override(override *Messages) Messages {
+	if override == nil {
+		return m
+	}
+	if override.Subject != "" {
+		m.Subject = override.Subject
+	}
+	if override.Template != "" {
+		m.Template = override.Template
+	}
+	if override.Metadata != nil {
+		m.Metadata = override.Metadata
+	}
+	return m
+}
+
+// ShowMetadata returns whether the messages should link back to the repository & pull request they came from
+func (m Messages) ShowMetadata() bool {
+	return m.Metadata == nil || *m.Metadata
 }
@@ -0,0 +1,177 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeathers_ExpandTeamNames(t *testing.T) {
+	testCases := []struct {
+		name          string
+		groups        map[string][]string
+		inputNames    []string
+		expectedNames []string
+		shouldError   bool
+	}{
+		{
+			name:          "NoGroups",
+			inputNames:    []string{"FrontEnd", "BackEnd"},
+			expectedNames: []string{"FrontEnd", "BackEnd"},
+		},
+		{
+			name:          "Group",
+			groups:        map[string][]string{"AllDevs": {"FrontEnd", "BackEnd", "QA"}},
+			inputNames:    []string{"AllDevs", "Business"},
+			expectedNames: []string{"FrontEnd", "BackEnd", "QA", "Business"},
+		},
+		{
+			name: "NestedGroups",
+			groups: map[string][]string{
+				"Everyone": {"AllDevs", "Business"},
+				"AllDevs":  {"FrontEnd", "BackEnd"},
+			},
+			inputNames:    []string{"Everyone"},
+			expectedNames: []string{"FrontEnd", "BackEnd", "Business"},
+		},
+		{
+			name:          "TeamNamedTwice",
+			groups:        map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}},
+			inputNames:    []string{"BackEnd", "AllDevs"},
+			expectedNames: []string{"BackEnd", "FrontEnd"},
+		},
+		{
+			name: "Cycle",
+			groups: map[string][]string{
+				"AllDevs": {"FrontEnd", "Web"},
+				"Web":     {"AllDevs"},
+			},
+			inputNames:  []string{"AllDevs"},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			feathers := &models.Feathers{Groups: tt.groups}
+			actualNames, err := feathers.ExpandTeamNames(tt.inputNames...)
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedNames, actualNames)
+		})
+	}
+}
+
+func TestFeathers_GetPathOwners(t *testing.T) {
+	feathers := &models.Feathers{
+		Groups: map[string][]string{"AllDevs": {"FrontEnd", "BackEnd"}},
+		Paths: map[string][]string{
+			"api/**":       {"BackEnd", "QA"},
+			"web/":         {"FrontEnd"},
+			"**/*.sql":     {"BackEnd", "Data"},
+			"Dockerfile":   {"AllDevs"},
+			"docs/**/*.md": {"Business"},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		inputFiles     []string
+		expectedOwners []string
+	}{
+		{
+			name:           "NoFiles",
+			expectedOwners: nil,
+		},
+		{
+			name:           "NoOwners",
+			inputFiles:     []string{"README.md"},
+			expectedOwners: nil,
+		},
+		{
+			name:           "SinglePath",
+			inputFiles:     []string{"api/handlers/main.go", "api/main.go"},
+			expectedOwners: []string{"BackEnd", "QA"},
+		},
+		{
+			name:           "SeveralPaths",
+			inputFiles:     []string{"api/migrations/0001_init.sql", "web/index.ts"},
+			expectedOwners: []string{"BackEnd", "Data", "QA", "FrontEnd"},
+		},
+		{
+			name:           "Group",
+			inputFiles:     []string{"Dockerfile"},
+			expectedOwners: []string{"FrontEnd", "BackEnd"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actualOwners, err := feathers.GetPathOwners(tt.inputFiles)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedOwners, actualOwners)
+		})
+	}
+}
+
+func TestParseFeathersSource(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputSource    string
+		expectedSource models.FeathersSource
+		shouldError    bool
+	}{
+		{
+			name:        "Passing",
+			inputSource: "org/peacock-config@main:teams.yaml",
+			expectedSource: models.FeathersSource{
+				Owner:  "org",
+				Repo:   "peacock-config",
+				Branch: "main",
+				Path:   "teams.yaml",
+			},
+		},
+		{
+			name:        "NestedPath",
+			inputSource: "org/peacock-config@release/v1:.peacock/teams.yaml",
+			expectedSource: models.FeathersSource{
+				Owner:  "org",
+				Repo:   "peacock-config",
+				Branch: "release/v1",
+				Path:   ".peacock/teams.yaml",
+			},
+		},
+		{
+			name:        "NoBranch",
+			inputSource: "org/peacock-config:teams.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "NoPath",
+			inputSource: "org/peacock-config@main",
+			shouldError: true,
+		},
+		{
+			name:        "NoOwner",
+			inputSource: "peacock-config@main:teams.yaml",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actualSource, err := models.ParseFeathersSource(tt.inputSource)
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSource, actualSource)
+			assert.Equal(t, tt.inputSource, actualSource.String())
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// LedgerEntry records that a release note was delivered to an address, so that it isn't sent again if the webhook for
+// the pull request is redelivered. Entries are claimed before the note is sent & marked as delivered once it has been.
+type LedgerEntry struct {
+	PullRequest PullRequestSummary `json:"pullRequest" bson:"pullRequest"`
+	// NoteHash is the hash of the release note, an edited note has a different hash & so isn't a duplicate
+	NoteHash    string    `json:"noteHash" bson:"noteHash"`
+	NoteKey     string    `json:"noteKey" bson:"noteKey"`
+	ContactType string    `json:"contactType" bson:"contactType"`
+	Address     string    `json:"address" bson:"address"`
+	ClaimedAt   time.Time `json:"claimedAt" bson:"claimedAt"`
+	// DeliveredAt is zero until every part of the note has been delivered to the address
+	DeliveredAt time.Time `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Message is a single release note prepared for delivery by a message client
+type Message struct {
+	Subject     string
+	Content     string
+	PullRequest PullRequestSummary
+	// NoteHash is the hash of the release note the message was created from
+	NoteHash string
+	// Teams are the names of the teams the release note is for
+	Teams []string
+	// Mentions are the IDs to notify keyed by address, only supported by Slack
+	Mentions map[string][]string
+	// HideMetadata is whether the links back to the repository & pull request should be left out of the message
+	HideMetadata bool
+}
+
+// MessageReference identifies a message that has been delivered by a message client so that it can be found again
+type MessageReference struct {
+	ContactType string `json:"contactType" bson:"contactType"`
+	// NoteKey is the key of the release note the message was created from, it's empty for thread parents
+	NoteKey string `json:"noteKey" bson:"noteKey"`
+	Address string `json:"address" bson:"address"`
+	ID      string `json:"id" bson:"id"`
+	// ThreadID is the ID of the parent message if the message was posted as a reply
+	ThreadID string `json:"threadId,omitempty" bson:"threadId,omitempty"`
+	// Part is the index of the message when a note was too long for a single message & had to be split
+	Part int `json:"part,omitempty" bson:"part,omitempty"`
+}
+
+// Label describes the message for logs & reports
+func (r MessageReference) Label() string {
+	if r.NoteKey == "" {
+		return "thread parent"
+	}
+	return fmt.Sprintf("release note for %s", r.NoteKey)
+}
+
+// DeliveryResult is the outcome of sending a message to a single address
+type DeliveryResult struct {
+	MessageReference
+	Err error
+	// Retryable is whether the failure was transient, in which case sending the message again may succeed
+	Retryable bool
+	// Queued is whether the failed message has been left in the outbox to be sent again
+	Queued bool
+}
+
+// Delivered returns whether the message was successfully sent to the address
+func (r DeliveryResult) Delivered() bool {
+	return r.Err == nil
+}
+
+// DeliveryReport details which addresses each release note was, or wasn't, delivered to
+type DeliveryReport []DeliveryResult
+
+// References returns the references of the messages that were delivered
+func (r DeliveryReport) References() []MessageReference {
+	var refs []MessageReference
+	for _, result := range r {
+		if result.Delivered() {
+			refs = append(refs, result.MessageReference)
+		}
+	}
+	return refs
+}
+
+// Failures returns the results of the messages that weren't delivered
+func (r DeliveryReport) Failures() DeliveryReport {
+	var failures DeliveryReport
+	for _, result := range r {
+		if !result.Delivered() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Queued returns the results of the messages that weren't delivered but will be sent again by the outbox
+func (r DeliveryReport) Queued() DeliveryReport {
+	var queued DeliveryReport
+	for _, result := range r {
+		if !result.Delivered() && result.Queued {
+			queued = append(queued, result)
+		}
+	}
+	return queued
+}
+
+// Err summarises the messages that weren't delivered & won't be sent again, it's nil if all the messages were either
+// delivered or queued to be sent again
+func (r DeliveryReport) Err() error {
+	var failures DeliveryReport
+	for _, f := range r.Failures() {
+		if !f.Queued {
+			failures = append(failures, f)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("failed to deliver %d of %d message(s):", len(failures), len(r)))
+	for _, f := range failures {
+		sb.WriteString(fmt.Sprintf("\n- %s to %s via %s: %s", f.Label(), f.Address, f.ContactType, f.Err))
+		if f.Retryable {
+			sb.WriteString(" (retryable)")
+		}
+	}
+	return errors.New(sb.String())
+}
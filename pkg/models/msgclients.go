@@ -3,7 +3,32 @@ package models
 const (
 	Slack   = "slack"
 	Webhook = "webhook"
+	MSTeams = "msteams"
+	Email   = "email"
+	SMS     = "sms"
 	None    = "none"
 )
 
-var Valid = []string{Slack, Webhook, None}
+var Valid = []string{Slack, Webhook, MSTeams, Email, SMS, None}
+
+// Delivery modes
+const (
+	// MessageDelivery posts each release note as its own message
+	MessageDelivery = "message"
+	// ThreadDelivery posts a single parent message per release with each release note as a reply in its thread
+	ThreadDelivery = "thread"
+)
+
+var ValidDeliveryModes = []string{MessageDelivery, ThreadDelivery}
+
+// Webhook payload formats
+const (
+	// LegacyPayload posts the release note as a JSON object of its body, subject & addresses
+	LegacyPayload = "legacy"
+	// CloudEventsStructuredPayload posts the release note as a CloudEvent with its attributes & data in the body
+	CloudEventsStructuredPayload = "cloudevents-structured"
+	// CloudEventsBinaryPayload posts the data of the CloudEvent as the body with its attributes in the headers
+	CloudEventsBinaryPayload = "cloudevents-binary"
+)
+
+var ValidPayloadFormats = []string{LegacyPayload, CloudEventsStructuredPayload, CloudEventsBinaryPayload}
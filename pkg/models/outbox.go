@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending jobs are waiting to be sent, or sent again after a transient failure
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered jobs have been sent to all of their addresses
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDeadLettered jobs won't be sent again, either the failures weren't transient or the attempts ran out
+	DeliveryDeadLettered DeliveryStatus = "deadLettered"
+)
+
+// DeliveryJob is the delivery of a pull request's release notes via one contact type, it's written to the outbox
+// before anything is sent so that the notes aren't lost if sending fails or the server restarts
+type DeliveryJob struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	PullRequest PullRequestSummary `json:"pullRequest" bson:"pullRequest"`
+	Subject     string             `json:"subject" bson:"subject"`
+	ContactType string             `json:"contactType" bson:"contactType"`
+	// Threaded jobs deliver the notes for threaded Slack teams, they're kept apart from the other Slack teams as all the
+	// notes for an address are posted under a single parent message
+	Threaded bool `json:"threaded" bson:"threaded"`
+	// Notes are all the release notes with teams that use the contact type, they're kept whole so that the references
+	// of the messages sent match the notes of the pull request
+	Notes         []ReleaseNote  `json:"notes" bson:"notes"`
+	Status        DeliveryStatus `json:"status" bson:"status"`
+	Attempts      int            `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time      `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	// LockedUntil stops a job being sent by more than one worker at once, the lock expires in case a worker dies
+	LockedUntil time.Time `json:"lockedUntil" bson:"lockedUntil"`
+	// LeaseToken is set each time the job is locked, so that a worker whose lock has expired can't overwrite the job
+	// once another worker has claimed it
+	LeaseToken string `json:"leaseToken" bson:"leaseToken"`
+	// Messages are the references of the messages that have been delivered
+	Messages []MessageReference `json:"messages" bson:"messages"`
+	// Failures are the addresses that the notes couldn't be delivered to on the last attempt, along with any earlier
+	// failures that weren't transient
+	Failures  []DeliveryFailure `json:"failures" bson:"failures"`
+	CreatedAt time.Time         `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt" bson:"updatedAt"`
+}
+
+// DeliveryFailure is a failed DeliveryResult in a form that can be stored
+type DeliveryFailure struct {
+	NoteKey   string `json:"noteKey" bson:"noteKey"`
+	Address   string `json:"address" bson:"address"`
+	Error     string `json:"error" bson:"error"`
+	Retryable bool   `json:"retryable" bson:"retryable"`
+}
@@ -16,5 +16,8 @@ func MarshalPullRequestEvent(event *github.PullRequestEvent) *PullRequestEventDT
 		SHA:           *event.PullRequest.Head.SHA,
 		Branch:        *event.PullRequest.Head.Ref,
 		DefaultBranch: *event.Repo.DefaultBranch,
+		MergeSHA:      event.PullRequest.GetMergeCommitSHA(),
+		MergedAt:      event.PullRequest.GetMergedAt(),
+		Title:         event.PullRequest.GetTitle(),
 	}
 }
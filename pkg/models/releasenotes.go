@@ -1,6 +1,13 @@
 package models
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/spring-financial-group/peacock/pkg/utils"
+)
 
 type ReleaseNote struct {
 	Teams   Teams
@@ -11,6 +18,58 @@ func (r *ReleaseNote) AppendContent(content string) {
 	r.Content += fmt.Sprintf("\n\n---\n\n%s", content)
 }
 
+// Key identifies the release note within a pull request, as notes are merged by their teams this is the team names
+func (r *ReleaseNote) Key() string {
+	return utils.CommaSeparated(r.Teams.GetAllTeamNames())
+}
+
+// Hash returns a hash of the note's key & content. Unlike the hash of the notes in a pull request it doesn't include the
+// teams' addresses, so it stays the same when the note is only sent to some of them.
+func (r *ReleaseNote) Hash() string {
+	h := sha256.New()
+	h.Write([]byte(r.Key()))
+	h.Write([]byte{0})
+	h.Write([]byte(r.Content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FilterAddresses returns a copy of the note keeping only the addresses for which keep returns true. Channels that are
+// left without any addresses are given the None contact type, rather than being removed, so that the key of the note
+// doesn't change.
+func (r *ReleaseNote) FilterAddresses(keep func(channel Channel, address string) bool) ReleaseNote {
+	teams := make(Teams, len(r.Teams))
+	for i, team := range r.Teams {
+		var channels []Channel
+		for _, c := range team.GetChannels() {
+			filtered := c
+			filtered.Addresses = nil
+			for _, address := range c.Addresses {
+				if keep(c, address) {
+					filtered.Addresses = append(filtered.Addresses, address)
+				}
+			}
+			if len(filtered.Addresses) == 0 {
+				filtered.ContactType = None
+			}
+			channels = append(channels, filtered)
+		}
+		teams[i] = team.WithChannels(channels)
+	}
+	return ReleaseNote{Teams: teams, Content: r.Content}
+}
+
+// HasAddresses returns whether any of the note's teams have an address to send the note to
+func (r *ReleaseNote) HasAddresses() bool {
+	for _, team := range r.Teams {
+		for _, c := range team.GetChannels() {
+			if c.ContactType != None && len(c.Addresses) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (r *ReleaseNote) AreTeamsEqual(other ReleaseNote) bool {
 	if len(r.Teams) != len(other.Teams) {
 		return false
@@ -22,3 +81,13 @@ func (r *ReleaseNote) AreTeamsEqual(other ReleaseNote) bool {
 	}
 	return true
 }
+
+// SentReleaseNotes records the release notes sent for a pull request and the messages they were delivered as, so that
+// the messages can be edited if the notes change after the pull request has been merged
+type SentReleaseNotes struct {
+	PullRequest PullRequestSummary `json:"pullRequest" bson:"pullRequest"`
+	Subject     string             `json:"subject" bson:"subject"`
+	Notes       []ReleaseNote      `json:"notes" bson:"notes"`
+	Messages    []MessageReference `json:"messages" bson:"messages"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
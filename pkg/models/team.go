@@ -10,6 +10,41 @@ type Team struct {
 	APIKey      string   `yaml:"apiKey"`
 	ContactType string   `yaml:"contactType"`
 	Addresses   []string `yaml:"addresses"`
+	// DeliveryMode is how the release notes are posted to the addresses, by default each note is its own message
+	DeliveryMode string `yaml:"deliveryMode,omitempty"`
+	// Mentions are the Slack user group & user IDs to notify when a release note is posted for the team
+	Mentions []string `yaml:"mentions,omitempty"`
+	// Webhook is the endpoint the team's release notes are posted to, in place of the global WEBHOOK_URL
+	Webhook *WebhookEndpoint `yaml:"webhook,omitempty"`
+	// Messages overrides the messages config of the feathers for the team's release notes
+	Messages *Messages `yaml:"messages,omitempty"`
+	// Channels are used in place of the contact type & addresses of the team, so that a team can be sent its release
+	// notes in more than one way
+	Channels []Channel `yaml:"channels,omitempty"`
+}
+
+// Channel is a way of contacting a team, the options are the same as those of a team with a single contact type
+type Channel struct {
+	ContactType  string           `yaml:"contactType"`
+	Addresses    []string         `yaml:"addresses"`
+	DeliveryMode string           `yaml:"deliveryMode,omitempty"`
+	Mentions     []string         `yaml:"mentions,omitempty"`
+	Webhook      *WebhookEndpoint `yaml:"webhook,omitempty"`
+}
+
+// WebhookEnvPrefix is the prefix of the environment variables that the token & secret of a webhook endpoint can name.
+// The feathers are edited in pull requests, so the rest of Peacock's environment, e.g. its GitHub token, mustn't be
+// sendable to an endpoint of the author's choosing.
+const WebhookEnvPrefix = "PEACOCK_WEBHOOK_"
+
+// WebhookEndpoint is the receiver of a webhook team's release notes. The token & secret are the names of environment
+// variables set for Peacock, so that they aren't stored in the repository, & must start with WebhookEnvPrefix.
+type WebhookEndpoint struct {
+	URL       string `yaml:"url"`
+	TokenRef  string `yaml:"tokenRef,omitempty"`
+	SecretRef string `yaml:"secretRef"`
+	// Format is the payload format of the requests, by default it's the same as the global webhook's
+	Format string `yaml:"format,omitempty"`
 }
 
 type Teams []Team
@@ -37,17 +72,15 @@ func (ts Teams) GetAllTeamNames() []string {
 func (ts Teams) GetAllContactTypes() []string {
 	var types []string
 	for _, t := range ts {
-		types = append(types, t.ContactType)
+		for _, c := range t.GetChannels() {
+			types = append(types, c.ContactType)
+		}
 	}
 	return types
 }
 
 func (ts Teams) GetContactTypesByTeamNames(names ...string) []string {
-	var types []string
-	for _, team := range ts.GetTeamsByNames(names...) {
-		types = append(types, team.ContactType)
-	}
-	return types
+	return ts.GetTeamsByNames(names...).GetAllContactTypes()
 }
 
 func (ts Teams) Contains(teamNames ...string) error {
@@ -60,10 +93,110 @@ func (ts Teams) Contains(teamNames ...string) error {
 	return nil
 }
 
+// Filter returns the teams for which keep returns true
+func (ts Teams) Filter(keep func(Team) bool) Teams {
+	var filtered Teams
+	for _, t := range ts {
+		if keep(t) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// FilterChannels returns copies of the teams keeping only the channels for which keep returns true, teams left without
+// any channels are removed
+func (ts Teams) FilterChannels(keep func(Channel) bool) Teams {
+	var filtered Teams
+	for _, t := range ts {
+		var channels []Channel
+		for _, c := range t.GetChannels() {
+			if keep(c) {
+				channels = append(channels, c)
+			}
+		}
+		if len(channels) > 0 {
+			filtered = append(filtered, t.WithChannels(channels))
+		}
+	}
+	return filtered
+}
+
+// GetChannels returns the ways the team is contacted, a team without any channels has a single channel made up of its
+// own contact type & addresses
+func (t Team) GetChannels() []Channel {
+	if len(t.Channels) > 0 {
+		return t.Channels
+	}
+	return []Channel{{
+		ContactType:  t.ContactType,
+		Addresses:    t.Addresses,
+		DeliveryMode: t.DeliveryMode,
+		Mentions:     t.Mentions,
+		Webhook:      t.Webhook,
+	}}
+}
+
+// WithChannels returns a copy of the team contacted via the channels. A team without any channels of its own keeps its
+// single contact type form when given a single channel.
+func (t Team) WithChannels(channels []Channel) Team {
+	if len(t.Channels) == 0 && len(channels) == 1 {
+		c := channels[0]
+		t.ContactType, t.Addresses, t.DeliveryMode, t.Mentions, t.Webhook = c.ContactType, c.Addresses, c.DeliveryMode, c.Mentions, c.Webhook
+		return t
+	}
+	t.Channels = channels
+	return t
+}
+
+// HasChannel returns whether the team is contacted with the contact type at the address
+func (t Team) HasChannel(contactType, address string) bool {
+	for _, c := range t.GetChannels() {
+		if c.ContactType == contactType && utils.ExistsInSlice(address, c.Addresses) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsThreaded returns whether the channel's release notes should be posted as replies to a parent message
+func (c Channel) IsThreaded() bool {
+	return c.ContactType == Slack && c.DeliveryMode == ThreadDelivery
+}
+
+// GetAddressPool returns the addresses of the teams keyed by contact type. Addresses are only included once even if
+// multiple teams share them, so that each address is only sent a release note once.
 func (ts Teams) GetAddressPool() map[string][]string {
 	addressPool := make(map[string][]string, len(ts.GetAllContactTypes()))
 	for _, team := range ts {
-		addressPool[team.ContactType] = append(addressPool[team.ContactType], team.Addresses...)
+		for _, c := range team.GetChannels() {
+			for _, address := range c.Addresses {
+				if !utils.ExistsInSlice(address, addressPool[c.ContactType]) {
+					addressPool[c.ContactType] = append(addressPool[c.ContactType], address)
+				}
+			}
+		}
 	}
 	return addressPool
 }
+
+// GetMentionPool returns the IDs to mention keyed by the address they should be mentioned in, or nil if none of the
+// teams have mentions. IDs are only included once per address even if multiple teams share the address.
+func (ts Teams) GetMentionPool() map[string][]string {
+	var mentionPool map[string][]string
+	for _, team := range ts {
+		for _, c := range team.GetChannels() {
+			for _, address := range c.Addresses {
+				for _, id := range c.Mentions {
+					if mentionPool == nil {
+						mentionPool = make(map[string][]string)
+					}
+					if !utils.ExistsInSlice(id, mentionPool[address]) {
+						mentionPool[address] = append(mentionPool[address], id)
+					}
+				}
+			}
+		}
+	}
+	return mentionPool
+}
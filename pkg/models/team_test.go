@@ -88,3 +88,98 @@ func TestGetAllTeamNames(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMentionPool(t *testing.T) {
+	testCases := []struct {
+		name         string
+		teams        models.Teams
+		expectedPool map[string][]string
+	}{
+		{
+			name: "Passing",
+			teams: models.Teams{
+				{Name: "infrastructure", Addresses: []string{"C1", "C2"}, Mentions: []string{"S012ABCDEF"}},
+				{Name: "ml", Addresses: []string{"C2"}, Mentions: []string{"U012ABCDEF", "S012ABCDEF"}},
+			},
+			expectedPool: map[string][]string{
+				"C1": {"S012ABCDEF"},
+				"C2": {"S012ABCDEF", "U012ABCDEF"},
+			},
+		},
+		{
+			name: "NoMentions",
+			teams: models.Teams{
+				{Name: "infrastructure", Addresses: []string{"C1", "C2"}},
+			},
+			expectedPool: nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actualPool := tt.teams.GetMentionPool()
+			assert.Equal(t, tt.expectedPool, actualPool)
+		})
+	}
+}
+
+func TestGetAddressPool(t *testing.T) {
+	testCases := []struct {
+		name         string
+		teams        models.Teams
+		expectedPool map[string][]string
+	}{
+		{
+			name: "SingleContactType",
+			teams: models.Teams{
+				{Name: "infrastructure", ContactType: models.Slack, Addresses: []string{"C1", "C2"}},
+				{Name: "product", ContactType: models.Webhook, Addresses: []string{"W1"}},
+			},
+			expectedPool: map[string][]string{
+				models.Slack:   {"C1", "C2"},
+				models.Webhook: {"W1"},
+			},
+		},
+		{
+			name: "Channels",
+			teams: models.Teams{
+				{Name: "infrastructure", ContactType: models.Slack, Addresses: []string{"C1"}},
+				{Name: "qa", Channels: []models.Channel{
+					{ContactType: models.Slack, Addresses: []string{"C2"}},
+					{ContactType: models.Email, Addresses: []string{"qa@example.com"}},
+				}},
+			},
+			expectedPool: map[string][]string{
+				models.Slack: {"C1", "C2"},
+				models.Email: {"qa@example.com"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			actualPool := tt.teams.GetAddressPool()
+			assert.Equal(t, tt.expectedPool, actualPool)
+		})
+	}
+}
+
+func TestFilterChannels(t *testing.T) {
+	teams := models.Teams{
+		{Name: "infrastructure", ContactType: models.Slack, Addresses: []string{"C1"}, DeliveryMode: models.ThreadDelivery},
+		{Name: "product", ContactType: models.Webhook, Addresses: []string{"W1"}},
+		{Name: "qa", Channels: []models.Channel{
+			{ContactType: models.Slack, Addresses: []string{"C2"}, DeliveryMode: models.ThreadDelivery},
+			{ContactType: models.Email, Addresses: []string{"qa@example.com"}},
+		}},
+	}
+
+	// Teams without channels keep their single contact type form, teams with channels keep the ones that match
+	expected := models.Teams{
+		{Name: "infrastructure", ContactType: models.Slack, Addresses: []string{"C1"}, DeliveryMode: models.ThreadDelivery},
+		{Name: "qa", Channels: []models.Channel{
+			{ContactType: models.Slack, Addresses: []string{"C2"}, DeliveryMode: models.ThreadDelivery},
+		}},
+	}
+	assert.Equal(t, expected, teams.FilterChannels(models.Channel.IsThreaded))
+}
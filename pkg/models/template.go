@@ -0,0 +1,93 @@
+package models
+
+import (
+	"strings"
+	"text/template"
+)
+
+// DefaultSubject is the subject of the messages when there isn't one in the feathers or passed to the CLI
+const DefaultSubject = "New Release Notes for {{ .Repo }}"
+
+// MessageTemplateData is the data available to the subject & the template in the feathers that wraps the content of
+// each release note
+type MessageTemplateData struct {
+	// Repo is the name of the repository, e.g. peacock
+	Repo     string
+	PRNumber int
+	PRTitle  string
+	PRURL    string
+	// Author is the login of the user who opened the pull request
+	Author string
+	// Environment is the environment the pull request changed, empty if it didn't change one
+	Environment string
+	// Teams are the names of the teams the release note is for
+	Teams []string
+	// Date is the date the pull request was merged in the format 2006-01-02
+	Date string
+	// Content is the release note itself
+	Content string
+}
+
+// NewMessageTemplateData returns the data for the templates of a release note for the teams, the content is left for the
+// caller to add
+func NewMessageTemplateData(pr PullRequestSummary, teams []string) MessageTemplateData {
+	data := MessageTemplateData{
+		Repo:        pr.RepoName,
+		PRNumber:    pr.PRNumber,
+		PRTitle:     pr.Title,
+		PRURL:       pr.URL(),
+		Author:      pr.Author,
+		Environment: pr.Environment,
+		Teams:       teams,
+	}
+	if !pr.MergedAt.IsZero() {
+		data.Date = pr.MergedAt.Format("2006-01-02")
+	}
+	return data
+}
+
+// messageTemplateFuncs are the functions available to the templates in addition to the Go template built-ins
+var messageTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// ParseTemplate parses the template that wraps the content of each release note
+func (m Messages) ParseTemplate() (*template.Template, error) {
+	return parseMessageTemplate("message", m.Template)
+}
+
+// ParseSubject parses the subject of the messages, if it's empty then DefaultSubject is parsed
+func (m Messages) ParseSubject() (*template.Template, error) {
+	subject := m.Subject
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	return parseMessageTemplate("subject", subject)
+}
+
+// RenderSubject renders the subject of the messages for a release note. Subjects are a single line so any new lines in
+// the template are replaced with spaces.
+func (m Messages) RenderSubject(data MessageTemplateData) (string, error) {
+	tmpl, err := m.ParseSubject()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err = tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(sb.String()), " "), nil
+}
+
+// parseMessageTemplate parses a template from the feathers. The template is checked against an empty MessageTemplateData
+// so that unknown variables are found when the feathers are validated rather than when the release notes are sent.
+func parseMessageTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(messageTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err = tmpl.Execute(new(strings.Builder), MessageTemplateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,186 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+)
+
+// Client sends messages as emails through an SMTP relay
+type Client struct {
+	cfg config.Email
+	// from is the parsed sender, the relay is only given its address while the From header keeps the display name
+	from   *mail.Address
+	policy *retry.Policy
+}
+
+// NewClient creates a client for the relay, an error is returned if the address emails are sent from isn't valid, e.g.
+// "Peacock <peacock@example.com>" or "peacock@example.com"
+func NewClient(cfg config.Email, policy *retry.Policy) (*Client, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address %s to send emails from", cfg.From)
+	}
+	return &Client{
+		cfg:    cfg,
+		from:   from,
+		policy: policy,
+	}, nil
+}
+
+func (c *Client) Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult {
+	results := make([]models.DeliveryResult, len(addresses))
+	for i, address := range addresses {
+		results[i].Address = address
+		data, err := c.generateMessage(msg.Content, msg.Subject, address, time.Now())
+		if err != nil {
+			results[i].Err = errors.Wrap(err, "failed to generate email")
+			continue
+		}
+		err = c.policy.Do(ctx, classify, func(ctx context.Context) error {
+			return c.sendMail(ctx, address, data)
+		})
+		if err != nil {
+			results[i].Err = errors.Wrapf(err, "failed to send email to %s", address)
+			results[i].Retryable = isRetryable(err)
+		}
+	}
+	return results
+}
+
+// MaxContentLength is unlimited as there is no practical limit on the length of an email
+func (c *Client) MaxContentLength() int {
+	return 0
+}
+
+// sendMail delivers a single message to the relay, upgrading the connection with STARTTLS if configured. The deadline
+// of the context is applied to the whole SMTP conversation.
+func (c *Client) sendMail(ctx context.Context, to string, msg []byte) error {
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if c.cfg.StartTLS {
+		if err = client.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return errors.Wrap(err, "failed to start tls")
+		}
+	}
+	if c.cfg.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)); err != nil {
+			return errors.Wrap(err, "failed to authenticate")
+		}
+	}
+
+	if err = client.Mail(c.from.Address); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// classify is the retry.Classifier for the relay, SMTP has no equivalent of Retry-After
+func classify(err error) (bool, time.Duration) {
+	return isRetryable(err), 0
+}
+
+// isRetryable returns whether an error from the relay was transient, SMTP uses 4xx codes for temporary failures
+func isRetryable(err error) bool {
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// generateMessage creates a multipart/alternative email containing both a plain-text and an HTML rendering of the
+// content
+func (c *Client) generateMessage(content, subject, to string, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{contentType: "text/plain; charset=utf-8", content: markdown.ConvertToPlainText(content)},
+		{contentType: "text/html; charset=utf-8", content: markdown.ConvertToHTML(content)},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := []struct{ key, value string }{
+		{"From", c.from.String()},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%s", mw.Boundary())},
+	}
+	for _, h := range headers {
+		msg.WriteString(fmt.Sprintf("%s: %s\r\n", h.key, h.value))
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
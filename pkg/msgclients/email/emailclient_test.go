@@ -0,0 +1,163 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	testCases := []struct {
+		name            string
+		from            string
+		expectedAddress string
+		shouldError     bool
+	}{
+		{
+			name:            "Address",
+			from:            "peacock@example.com",
+			expectedAddress: "peacock@example.com",
+		},
+		{
+			name:            "DisplayName",
+			from:            "Peacock <peacock@example.com>",
+			expectedAddress: "peacock@example.com",
+		},
+		{
+			name:        "Empty",
+			from:        "",
+			shouldError: true,
+		},
+		{
+			name:        "Invalid",
+			from:        "Peacock peacock@example.com",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(config.Email{From: tt.from}, retry.NewPolicy(config.Delivery{}))
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAddress, client.from.Address)
+		})
+	}
+}
+
+func TestClient_SendMail(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// A minimal relay that accepts a single message and records the commands it was sent
+	commands := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var received []string
+		tp.PrintfLine("220 localhost ready")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				break
+			}
+			received = append(received, line)
+			if line == "DATA" {
+				tp.PrintfLine("354 go ahead")
+				if _, err = tp.ReadDotBytes(); err != nil {
+					break
+				}
+			} else if line == "QUIT" {
+				tp.PrintfLine("221 bye")
+				break
+			}
+			tp.PrintfLine("250 ok")
+		}
+		commands <- received
+	}()
+
+	host, portStr, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	client, err := NewClient(config.Email{
+		Host: host,
+		Port: port,
+		From: "Peacock <peacock@example.com>",
+	}, retry.NewPolicy(config.Delivery{}))
+	require.NoError(t, err)
+
+	err = client.sendMail(context.Background(), "qa@example.com", []byte("Subject: test\r\n\r\nbody\r\n"))
+	require.NoError(t, err)
+
+	var mailFrom string
+	for _, cmd := range <-commands {
+		if strings.HasPrefix(cmd, "MAIL FROM:") {
+			mailFrom = cmd
+		}
+	}
+	assert.True(t, strings.HasPrefix(mailFrom, "MAIL FROM:<peacock@example.com>"), mailFrom)
+}
+
+func TestClient_GenerateMessage(t *testing.T) {
+	client, err := NewClient(config.Email{From: "Peacock <peacock@example.com>"}, retry.NewPolicy(config.Delivery{}))
+	require.NoError(t, err)
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	data, err := client.generateMessage("### Release\n* New feature", "New Release Notes for peacock", "qa@example.com", date)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, `"Peacock" <peacock@example.com>`, msg.Header.Get("From"))
+	assert.Equal(t, "qa@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "New Release Notes for peacock", msg.Header.Get("Subject"))
+	assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 +0000", msg.Header.Get("Date"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	expectedParts := []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain; charset=utf-8", body: "Release\r\n\r\n* New feature"},
+		{contentType: "text/html; charset=utf-8", body: "<header>Release</header>\r\n<ul>\r\n<li>New feature</li>\r\n</ul>\r\n"},
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for _, expected := range expectedParts {
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, expected.contentType, part.Header.Get("Content-Type"))
+
+		// The multipart reader transparently decodes the quoted-printable parts, line endings are normalised to CRLF
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, expected.body, string(body))
+	}
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
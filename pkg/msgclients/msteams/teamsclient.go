@@ -0,0 +1,128 @@
+package msteams
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/spring-financial-group/peacock/pkg/utils/http_utils"
+)
+
+const (
+	adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion     = "1.4"
+
+	// maxContentLength is the length content is split at before it's converted into a card
+	maxContentLength = 20000
+	// maxMessageSize is the 28KB limit on the size of a Teams message. Short lines of Markdown can serialise to many
+	// times their length so content that would exceed it is split into parts.
+	maxMessageSize = 28 * 1024
+	// maxCardOverhead is the room left in a message for the card around the content, its subject & the part header
+	maxCardOverhead = 2 * 1024
+)
+
+// Client posts messages to Microsoft Teams incoming webhooks. The webhook URLs are the addresses of the team, so
+// no credentials are held by the client itself.
+type Client struct {
+	policy *retry.Policy
+}
+
+func NewClient(policy *retry.Policy) *Client {
+	return &Client{
+		policy: policy,
+	}
+}
+
+type message struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string       `json:"contentType"`
+	ContentURL  *string      `json:"contentUrl"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string                         `json:"$schema"`
+	Type    string                         `json:"type"`
+	Version string                         `json:"version"`
+	Body    []markdown.AdaptiveCardElement `json:"body"`
+	MSTeams msTeamsOptions                 `json:"msteams"`
+}
+
+type msTeamsOptions struct {
+	Width string `json:"width"`
+}
+
+func (c *Client) Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult {
+	results := make([]models.DeliveryResult, len(addresses))
+	data, err := json.Marshal(c.generateMessage(msg.Content, msg.Subject))
+	for i, address := range addresses {
+		results[i].Address = address
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if postErr := c.post(ctx, address, data); postErr != nil {
+			results[i].Err = errors.Wrap(postErr, "failed to post message to teams")
+			results[i].Retryable = http_utils.IsRetryable(postErr)
+		}
+	}
+	return results
+}
+
+func (c *Client) post(ctx context.Context, url string, data []byte) error {
+	return c.policy.Do(ctx, http_utils.Classify, func(ctx context.Context) error {
+		req, err := http_utils.GeneratePostRequest(ctx, url, data)
+		if err != nil {
+			return err
+		}
+		return http_utils.DoRequestAndCatchUnsuccessful(req)
+	})
+}
+
+func (c *Client) MaxContentLength() int {
+	return maxContentLength
+}
+
+// SplitContent splits content that would be converted into a card larger than Teams accepts in a message, each chunk
+// is posted as its own part
+func (c *Client) SplitContent(content string) []string {
+	return markdown.SplitAdaptiveCard(content, maxMessageSize-maxCardOverhead)
+}
+
+func (c *Client) generateMessage(content, subject string) message {
+	var body []markdown.AdaptiveCardElement
+	if subject != "" {
+		body = append(body, markdown.AdaptiveCardElement{
+			Type:   "TextBlock",
+			Text:   subject,
+			Wrap:   true,
+			Size:   "Large",
+			Weight: "Bolder",
+		})
+	}
+	body = append(body, markdown.ConvertToAdaptiveCard(content)...)
+
+	return message{
+		Type: "message",
+		Attachments: []attachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: adaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body:    body,
+					MSTeams: msTeamsOptions{Width: "Full"},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,171 @@
+package msteams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPolicy(maxAttempts int) *retry.Policy {
+	return retry.NewPolicy(config.Delivery{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+}
+
+func TestClient_Send(t *testing.T) {
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(newTestPolicy(1))
+	msg := models.Message{Subject: "New Release Notes for peacock", Content: "### Payments\n* New **feature**"}
+	results := client.Send(context.Background(), msg, []string{server.URL + "/a", server.URL + "/b"})
+
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.NoError(t, result.Err)
+		assert.Equal(t, []string{server.URL + "/a", server.URL + "/b"}[i], result.Address)
+	}
+	require.Len(t, bodies, 2)
+
+	var actual message
+	require.NoError(t, json.Unmarshal(bodies[0], &actual))
+	assert.Equal(t, "message", actual.Type)
+	require.Len(t, actual.Attachments, 1)
+	assert.Nil(t, actual.Attachments[0].ContentURL)
+	assert.Equal(t, adaptiveCardContentType, actual.Attachments[0].ContentType)
+
+	card := actual.Attachments[0].Content
+	assert.Equal(t, adaptiveCardSchema, card.Schema)
+	assert.Equal(t, "AdaptiveCard", card.Type)
+	assert.Equal(t, adaptiveCardVersion, card.Version)
+	assert.Equal(t, "Full", card.MSTeams.Width)
+
+	expectedBody := append([]markdown.AdaptiveCardElement{
+		{Type: "TextBlock", Text: msg.Subject, Wrap: true, Size: "Large", Weight: "Bolder"},
+	}, markdown.ConvertToAdaptiveCard(msg.Content)...)
+	assert.Equal(t, expectedBody, card.Body)
+}
+
+func TestClient_Send_Unsuccessful(t *testing.T) {
+	testCases := []struct {
+		name              string
+		status            int
+		expectedRequests  int
+		expectedRetryable bool
+	}{
+		{
+			name:              "BadRequest",
+			status:            http.StatusBadRequest,
+			expectedRequests:  1,
+			expectedRetryable: false,
+		},
+		{
+			name:              "NotFound",
+			status:            http.StatusNotFound,
+			expectedRequests:  1,
+			expectedRetryable: false,
+		},
+		{
+			name:              "TooManyRequests",
+			status:            http.StatusTooManyRequests,
+			expectedRequests:  2,
+			expectedRetryable: true,
+		},
+		{
+			name:              "ServiceUnavailable",
+			status:            http.StatusServiceUnavailable,
+			expectedRequests:  2,
+			expectedRetryable: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := NewClient(newTestPolicy(2))
+			results := client.Send(context.Background(), models.Message{Content: "A fix"}, []string{server.URL})
+
+			require.Len(t, results, 1)
+			assert.Error(t, results[0].Err)
+			assert.Equal(t, tt.expectedRetryable, results[0].Retryable)
+			assert.Equal(t, tt.expectedRequests, requests)
+		})
+	}
+}
+
+func TestClient_SplitContent(t *testing.T) {
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Many short list items make the most elements, and so the most JSON overhead, for the length of the content
+	var sb strings.Builder
+	for sb.Len() < maxContentLength {
+		sb.WriteString("### Team\n* A **fix** with [a link](https://example.com)\n\n")
+	}
+	client := NewClient(newTestPolicy(1))
+	content := markdown.SplitBlocks(sb.String(), client.MaxContentLength())[0]
+
+	chunks := client.SplitContent(content)
+	require.Greater(t, len(chunks), 1)
+
+	// Each chunk is sent as its own part with a single post, no elements are lost between the parts
+	var elements []markdown.AdaptiveCardElement
+	for i, chunk := range chunks {
+		part := models.Message{
+			Subject: "New Release Notes for peacock",
+			Content: fmt.Sprintf("**(%d/%d)**\n\n%s", i+1, len(chunks), chunk),
+		}
+		results := client.Send(context.Background(), part, []string{server.URL})
+		require.Len(t, results, 1)
+		assert.NoError(t, results[0].Err)
+		require.Len(t, bodies, i+1)
+		assert.LessOrEqual(t, len(bodies[i]), maxMessageSize)
+
+		var actual message
+		require.NoError(t, json.Unmarshal(bodies[i], &actual))
+		// Skip the subject & the part header
+		elements = append(elements, actual.Attachments[0].Content.Body[2:]...)
+	}
+	assert.Equal(t, markdown.ConvertToAdaptiveCard(content), elements)
+}
+
+func TestClient_SplitContent_Fits(t *testing.T) {
+	client := NewClient(newTestPolicy(1))
+	content := "### Payments\n* New **feature**"
+	assert.Equal(t, []string{content}, client.SplitContent(content))
+}
@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spring-financial-group/peacock/pkg/config"
+)
+
+const (
+	defaultTimeout        = 10 * time.Second
+	defaultMaxAttempts    = 4
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// Classifier reports whether an error is transient, so the message can be sent again, and how long the server asked
+// us to wait before retrying, 0 if it didn't say
+type Classifier func(err error) (retryable bool, retryAfter time.Duration)
+
+// Policy is shared by the message clients to send messages with a timeout, retrying transient failures with
+// exponential backoff & jitter
+type Policy struct {
+	timeout        time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	// jitter returns a random duration in [0, d), it's swapped out in tests
+	jitter func(d time.Duration) time.Duration
+	// wait blocks for the duration or until the context is done, it's swapped out in tests
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+func NewPolicy(cfg config.Delivery) *Policy {
+	p := &Policy{
+		timeout:        cfg.Timeout,
+		maxAttempts:    cfg.MaxAttempts,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		jitter:         fullJitter,
+		wait:           wait,
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultTimeout
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = defaultMaxAttempts
+	}
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = defaultInitialBackoff
+	}
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = defaultMaxBackoff
+	}
+	return p
+}
+
+// Do calls fn until it succeeds, it fails with an error that isn't retryable or the attempts run out. Each attempt is
+// given its own timeout. The error from the last attempt is returned unwrapped so that it can still be classified.
+func (p *Policy) Do(ctx context.Context, classify Classifier, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < p.maxAttempts; attempt++ {
+		err = p.attempt(ctx, fn)
+		if err == nil {
+			return nil
+		}
+
+		retryable, retryAfter := classify(err)
+		if !retryable || attempt == p.maxAttempts-1 {
+			return err
+		}
+
+		backoff := p.backoff(attempt, retryAfter)
+		log.Warnf("attempt %d of %d failed, retrying in %s: %s", attempt+1, p.maxAttempts, backoff, err)
+		if waitErr := p.wait(ctx, backoff); waitErr != nil {
+			return errors.Wrapf(err, "stopped retrying as %s", waitErr)
+		}
+	}
+	return err
+}
+
+func (p *Policy) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// backoff returns how long to wait before the next attempt. The server's Retry-After is honoured when given, otherwise
+// a random wait up to an exponentially increasing bound is used so that clients don't retry in lockstep.
+func (p *Policy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	bound := p.maxBackoff
+	if attempt < 32 && p.initialBackoff<<attempt < p.maxBackoff {
+		bound = p.initialBackoff << attempt
+	}
+	return p.jitter(bound)
+}
+
+func fullJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		// There's no point waiting if the context will expire before the next attempt
+		return errors.New("the deadline would be exceeded before the next attempt")
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
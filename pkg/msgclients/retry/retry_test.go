@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errTransient   = errors.New("service unavailable")
+	errRateLimited = errors.New("rate limited")
+	errPermanent   = errors.New("channel not found")
+)
+
+func classifyTestErr(err error) (bool, time.Duration) {
+	switch err {
+	case errTransient:
+		return true, 0
+	case errRateLimited:
+		return true, 7 * time.Second
+	}
+	return false, 0
+}
+
+func TestPolicy_Do(t *testing.T) {
+	testCases := []struct {
+		name             string
+		errs             []error
+		expectedAttempts int
+		expectedWaits    []time.Duration
+		expectedErr      error
+	}{
+		{
+			name:             "SucceedsFirstTime",
+			errs:             []error{nil},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "RetriesTransientErrors",
+			errs:             []error{errTransient, errTransient, nil},
+			expectedAttempts: 3,
+			expectedWaits:    []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:             "HonoursRetryAfter",
+			errs:             []error{errRateLimited, nil},
+			expectedAttempts: 2,
+			expectedWaits:    []time.Duration{7 * time.Second},
+		},
+		{
+			name:             "StopsOnPermanentError",
+			errs:             []error{errTransient, errPermanent},
+			expectedAttempts: 2,
+			expectedWaits:    []time.Duration{time.Second},
+			expectedErr:      errPermanent,
+		},
+		{
+			name:             "GivesUpAfterMaxAttempts",
+			errs:             []error{errTransient, errTransient, errTransient, errTransient},
+			expectedAttempts: 4,
+			expectedWaits:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			expectedErr:      errTransient,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPolicy(config.Delivery{MaxAttempts: 4, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second})
+			// Use the upper bound of the jitter so that the waits are predictable
+			policy.jitter = func(d time.Duration) time.Duration { return d }
+			var waits []time.Duration
+			policy.wait = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			attempts := 0
+			err := policy.Do(context.Background(), classifyTestErr, func(ctx context.Context) error {
+				_, hasDeadline := ctx.Deadline()
+				assert.True(t, hasDeadline)
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			assert.Equal(t, tt.expectedWaits, waits)
+		})
+	}
+}
+
+func TestPolicy_DoStopsWhenContextDone(t *testing.T) {
+	policy := NewPolicy(config.Delivery{MaxAttempts: 4})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	err := policy.Do(ctx, classifyTestErr, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return errTransient
+	})
+
+	assert.Equal(t, 1, attempts)
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorContains(t, err, "stopped retrying")
+}
@@ -1,31 +1,216 @@
 package slack
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/slack-go/slack"
+	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+)
+
+const (
+	// maxContentLength keeps messages within the length Slack recommends for the text of a message, longer release
+	// notes are split with the overflow posted in the thread
+	maxContentLength = 4000
+	// maxBlocks is the most blocks Slack accepts in a message
+	maxBlocks = 50
+	// maxContentBlocks leaves room for the mentions, part number & metadata blocks added around the content
+	maxContentBlocks = maxBlocks - 3
 )
 
 type Client struct {
-	slack *slack.Client
+	slack  *slack.Client
+	policy *retry.Policy
 }
 
-func NewClient(token string) *Client {
+func NewClient(token string, policy *retry.Policy) *Client {
 	return &Client{
-		slack: slack.New(token),
+		slack:  slack.New(token),
+		policy: policy,
 	}
 }
 
-func (c *Client) Send(content, _ string, addresses []string) error {
-	content = markdown.ConvertToSlack(content)
+func (c *Client) Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult {
+	results := make([]models.DeliveryResult, len(addresses))
+	for i, address := range addresses {
+		ts, err := c.post(ctx, address, c.generateMessageOptions(msg, address)...)
+		results[i] = newDeliveryResult(models.MessageReference{Address: address, ID: ts}, err)
+	}
+	return results
+}
+
+func (c *Client) SendThread(ctx context.Context, parent models.Message, replies []models.Message, addresses []string) []models.DeliveryResult {
+	parentOptions := c.generateParentOptions(parent)
+
+	results := make([]models.DeliveryResult, 0, len(addresses)*(len(replies)+1))
 	for _, address := range addresses {
-		_, _, err := c.slack.PostMessage(
-			address,
-			slack.MsgOptionText(content, false),
-			slack.MsgOptionAsUser(true),
-		)
-		if err != nil {
-			return err
+		parentTS, err := c.post(ctx, address, parentOptions...)
+		parentResult := newDeliveryResult(models.MessageReference{Address: address, ID: parentTS}, err)
+		results = append(results, parentResult)
+
+		for _, reply := range replies {
+			if !parentResult.Delivered() {
+				// The replies can't be posted without their parent
+				results = append(results, models.DeliveryResult{
+					MessageReference: models.MessageReference{Address: address},
+					Err:              errors.Wrap(parentResult.Err, "failed to post parent message"),
+					Retryable:        parentResult.Retryable,
+				})
+				continue
+			}
+			results = append(results, c.Reply(ctx, reply, parentResult.MessageReference))
 		}
 	}
-	return nil
+	return results
+}
+
+func (c *Client) Reply(ctx context.Context, msg models.Message, parent models.MessageReference) models.DeliveryResult {
+	// The metadata is already on the parent so there's no need to repeat it in each reply
+	msg.PullRequest = models.PullRequestSummary{}
+	options := append(c.generateMessageOptions(msg, parent.Address), slack.MsgOptionTS(parent.ID))
+	ts, err := c.post(ctx, parent.Address, options...)
+	return newDeliveryResult(models.MessageReference{Address: parent.Address, ID: ts, ThreadID: parent.ID}, err)
+}
+
+func (c *Client) MaxContentLength() int {
+	return maxContentLength
+}
+
+// SplitContent splits content that would be rendered as more blocks than Slack accepts in a message, the overflow is
+// posted in the thread like content that is too long
+func (c *Client) SplitContent(content string) []string {
+	return markdown.SplitSlackBlocks(content, maxContentBlocks)
+}
+
+func (c *Client) Update(ctx context.Context, msg models.Message, ref models.MessageReference) error {
+	if ref.ThreadID != "" {
+		// Replies were sent without the metadata as it's on the parent
+		msg.PullRequest = models.PullRequestSummary{}
+	}
+	options := c.generateMessageOptions(msg, ref.Address)
+	return c.policy.Do(ctx, classify, func(ctx context.Context) error {
+		_, _, _, err := c.slack.UpdateMessageContext(ctx, ref.Address, ref.ID, options...)
+		return err
+	})
+}
+
+func (c *Client) Delete(ctx context.Context, ref models.MessageReference) error {
+	return c.policy.Do(ctx, classify, func(ctx context.Context) error {
+		_, _, err := c.slack.DeleteMessageContext(ctx, ref.Address, ref.ID)
+		return err
+	})
+}
+
+// post posts a message to the channel returning its timestamp, which Slack uses as the message ID
+func (c *Client) post(ctx context.Context, channel string, options ...slack.MsgOption) (string, error) {
+	var ts string
+	err := c.policy.Do(ctx, classify, func(ctx context.Context) error {
+		var err error
+		_, ts, err = c.slack.PostMessageContext(ctx, channel, options...)
+		return err
+	})
+	return ts, err
+}
+
+func (c *Client) generateMessageOptions(msg models.Message, address string) []slack.MsgOption {
+	// The text is used as the fallback for notifications & clients that can't display blocks
+	text := markdown.ConvertToSlack(msg.Content)
+	blocks := markdown.ConvertToSlackBlocks(msg.Content)
+	if mentions := formatMentions(msg.Mentions[address]); mentions != "" {
+		text = fmt.Sprintf("%s\n%s", mentions, text)
+		mentionBlock := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, mentions, false, false), nil, nil)
+		blocks = append([]slack.Block{mentionBlock}, blocks...)
+	}
+	if metadata := c.generateMetadataBlock(msg); metadata != nil {
+		blocks = append(blocks, metadata)
+	}
+	return []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionAsUser(true),
+	}
+}
+
+// generateParentOptions creates a summary message for the release that the release notes are threaded under
+func (c *Client) generateParentOptions(parent models.Message) []slack.MsgOption {
+	subject := parent.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("New Release Notes for %s", parent.PullRequest.RepoName)
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, subject, true, false)),
+	}
+	if metadata := c.generateMetadataBlock(parent); metadata != nil {
+		blocks = append(blocks, metadata)
+	}
+	return []slack.MsgOption{
+		slack.MsgOptionText(subject, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionAsUser(true),
+	}
+}
+
+// generateMetadataBlock creates a context block linking back to the repository & pull request the message came from,
+// unless the team has chosen to hide it
+func (c *Client) generateMetadataBlock(msg models.Message) slack.Block {
+	pr := msg.PullRequest
+	if msg.HideMetadata || pr.RepoOwner == "" || pr.RepoName == "" {
+		return nil
+	}
+
+	repoURL := fmt.Sprintf("%s/%s/%s", domain.GitHubURL, pr.RepoOwner, pr.RepoName)
+	text := fmt.Sprintf("<%s|%s/%s>", repoURL, pr.RepoOwner, pr.RepoName)
+	if pr.PRNumber > 0 {
+		text += fmt.Sprintf(" | <%s/pull/%d|PR #%d>", repoURL, pr.PRNumber, pr.PRNumber)
+	}
+	return slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, text, false, false))
+}
+
+// formatMentions converts the IDs into Slack mentions, user group IDs begin with S and are mentioned as a subteam
+func formatMentions(ids []string) string {
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		if strings.HasPrefix(id, "S") {
+			mentions[i] = fmt.Sprintf("<!subteam^%s>", id)
+		} else {
+			mentions[i] = fmt.Sprintf("<@%s>", id)
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+func newDeliveryResult(ref models.MessageReference, err error) models.DeliveryResult {
+	return models.DeliveryResult{
+		MessageReference: ref,
+		Err:              err,
+		Retryable:        err != nil && isRetryable(err),
+	}
+}
+
+func isRetryable(err error) bool {
+	retryable, _ := classify(err)
+	return retryable
+}
+
+// classify returns whether an error from Slack was transient, rate limits & server errors report this themselves. When
+// rate limited Slack tells us how long to wait with the Retry-After header.
+func classify(err error) (bool, time.Duration) {
+	var rateLimited *slack.RateLimitedError
+	if errors.As(err, &rateLimited) {
+		return true, rateLimited.RetryAfter
+	}
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable(), 0
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded), 0
 }
@@ -0,0 +1,362 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mockPR = models.PullRequestSummary{
+	PRNumber:  1,
+	RepoOwner: "spring-financial-group",
+	RepoName:  "peacock",
+}
+
+// slackRequest is a call made to the Slack API
+type slackRequest struct {
+	method string
+	form   url.Values
+}
+
+// fakeSlack is a Slack API that records the calls made to it. Messages are given increasing timestamps & posting to
+// the channel "#missing" fails.
+type fakeSlack struct {
+	mu       sync.Mutex
+	requests []slackRequest
+	nextTS   int
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeSlack) {
+	fake := &fakeSlack{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.requests = append(fake.requests, slackRequest{method: strings.TrimPrefix(r.URL.Path, "/"), form: r.PostForm})
+
+		w.Header().Set("Content-Type", "application/json")
+		channel := r.PostForm.Get("channel")
+		if channel == "#missing" {
+			_, _ = fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+			return
+		}
+		ts := r.PostForm.Get("ts")
+		if ts == "" {
+			fake.nextTS++
+			ts = fmt.Sprintf("1700000000.%06d", fake.nextTS)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": channel, "ts": ts})
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{
+		slack:  slack.New("token", slack.OptionAPIURL(server.URL+"/")),
+		policy: retry.NewPolicy(config.Delivery{MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+	}
+	return client, fake
+}
+
+// calls returns the calls made to a method of the API
+func (f *fakeSlack) calls(method string) []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var forms []url.Values
+	for _, req := range f.requests {
+		if req.method == method {
+			forms = append(forms, req.form)
+		}
+	}
+	return forms
+}
+
+// postedBlocks decodes the blocks of a posted message
+func postedBlocks(t *testing.T, form url.Values) []slack.Block {
+	var blocks slack.Blocks
+	require.NoError(t, json.Unmarshal([]byte(form.Get("blocks")), &blocks))
+	return blocks.BlockSet
+}
+
+func marshalBlocks(t *testing.T, blocks ...slack.Block) string {
+	data, err := json.Marshal(blocks)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestClient_Send(t *testing.T) {
+	client, fake := newTestClient(t)
+	msg := models.Message{
+		Subject:     "Release",
+		Content:     "### Payments\n* New **feature**",
+		PullRequest: mockPR,
+		Mentions:    map[string][]string{"#releases": {"S012ABCDEF"}},
+	}
+
+	results := client.Send(context.Background(), msg, []string{"#releases", "#other", "#missing"})
+
+	require.Len(t, results, 3)
+	assert.Equal(t, models.MessageReference{Address: "#releases", ID: "1700000000.000001"}, results[0].MessageReference)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, models.MessageReference{Address: "#other", ID: "1700000000.000002"}, results[1].MessageReference)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, "#missing", results[2].Address)
+	assert.Error(t, results[2].Err)
+	assert.False(t, results[2].Retryable)
+
+	posts := fake.calls("chat.postMessage")
+	require.Len(t, posts, 3)
+	contentBlocks := markdown.ConvertToSlackBlocks(msg.Content)
+	metadata := slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+		"<https://github.com/spring-financial-group/peacock|spring-financial-group/peacock> | <https://github.com/spring-financial-group/peacock/pull/1|PR #1>", false, false))
+
+	// The mentions are at the start of both the fallback text & the blocks of the addresses they're for
+	mentions := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "<!subteam^S012ABCDEF>", false, false), nil, nil)
+	assert.Equal(t, "#releases", posts[0].Get("channel"))
+	assert.Equal(t, "<!subteam^S012ABCDEF>\n"+markdown.ConvertToSlack(msg.Content), posts[0].Get("text"))
+	assert.JSONEq(t, marshalBlocks(t, append(append([]slack.Block{mentions}, contentBlocks...), metadata)...), posts[0].Get("blocks"))
+	assert.Equal(t, "true", posts[0].Get("as_user"))
+	assert.Empty(t, posts[0].Get("thread_ts"))
+
+	assert.Equal(t, "#other", posts[1].Get("channel"))
+	assert.Equal(t, markdown.ConvertToSlack(msg.Content), posts[1].Get("text"))
+	assert.JSONEq(t, marshalBlocks(t, append(contentBlocks, metadata)...), posts[1].Get("blocks"))
+}
+
+func TestClient_Send_HideMetadata(t *testing.T) {
+	client, fake := newTestClient(t)
+	msg := models.Message{Content: "A fix", PullRequest: mockPR, HideMetadata: true}
+
+	results := client.Send(context.Background(), msg, []string{"#releases"})
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+
+	posts := fake.calls("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.JSONEq(t, marshalBlocks(t, markdown.ConvertToSlackBlocks(msg.Content)...), posts[0].Get("blocks"))
+}
+
+func TestClient_SplitContent(t *testing.T) {
+	client, fake := newTestClient(t)
+
+	// Each heading & paragraph is a block of its own, so the content is well within the length limit but has far more
+	// blocks than Slack accepts
+	var sb strings.Builder
+	for i := 0; i < 60; i++ {
+		sb.WriteString(fmt.Sprintf("### Team %d\nA fix\n\n", i))
+	}
+	content := sb.String()
+	require.Less(t, len(content), client.MaxContentLength())
+	require.Greater(t, len(markdown.ConvertToSlackBlocks(content)), maxBlocks)
+
+	chunks := client.SplitContent(content)
+	require.Len(t, chunks, 3)
+	var headings int
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(markdown.ConvertToSlackBlocks(chunk)), maxContentBlocks)
+
+		// Each part still fits once the part number, mentions & metadata are added around it
+		part := models.Message{
+			Content:     fmt.Sprintf("**(%d/%d)**\n\n%s", i+1, len(chunks), chunk),
+			PullRequest: mockPR,
+			Mentions:    map[string][]string{"#releases": {"U012ABCDEF"}},
+		}
+		results := client.Send(context.Background(), part, []string{"#releases"})
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+
+		for _, block := range markdown.ConvertToSlackBlocks(chunk) {
+			if block.BlockType() == slack.MBTHeader {
+				headings++
+			}
+		}
+	}
+	// No content is lost between the chunks
+	assert.Equal(t, 60, headings)
+
+	for _, post := range fake.calls("chat.postMessage") {
+		assert.LessOrEqual(t, len(postedBlocks(t, post)), maxBlocks)
+	}
+
+	// Content within the limit isn't split
+	assert.Equal(t, []string{"### Team\nA fix"}, client.SplitContent("### Team\nA fix"))
+}
+
+func TestFormatMentions(t *testing.T) {
+	testCases := []struct {
+		name             string
+		ids              []string
+		expectedMentions string
+	}{
+		{
+			name:             "None",
+			ids:              nil,
+			expectedMentions: "",
+		},
+		{
+			name:             "UserGroup",
+			ids:              []string{"S012ABCDEF"},
+			expectedMentions: "<!subteam^S012ABCDEF>",
+		},
+		{
+			name:             "User",
+			ids:              []string{"U012ABCDEF"},
+			expectedMentions: "<@U012ABCDEF>",
+		},
+		{
+			name:             "EnterpriseUser",
+			ids:              []string{"W012ABCDEF"},
+			expectedMentions: "<@W012ABCDEF>",
+		},
+		{
+			name:             "Multiple",
+			ids:              []string{"U012ABCDEF", "S012ABCDEF", "W012ABCDEF"},
+			expectedMentions: "<@U012ABCDEF> <!subteam^S012ABCDEF> <@W012ABCDEF>",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedMentions, formatMentions(tt.ids))
+		})
+	}
+}
+
+func TestClient_SendThread(t *testing.T) {
+	client, fake := newTestClient(t)
+	parent := models.Message{PullRequest: mockPR}
+	replies := []models.Message{
+		{Content: "First note", PullRequest: mockPR, Mentions: map[string][]string{"#releases": {"U012ABCDEF"}}},
+		{Content: "Second note", PullRequest: mockPR},
+	}
+
+	results := client.SendThread(context.Background(), parent, replies, []string{"#releases", "#missing"})
+
+	// The parent is first for each address, followed by its replies which reference it
+	require.Len(t, results, 6)
+	assert.Equal(t, models.MessageReference{Address: "#releases", ID: "1700000000.000001"}, results[0].MessageReference)
+	assert.Equal(t, models.MessageReference{Address: "#releases", ID: "1700000000.000002", ThreadID: "1700000000.000001"}, results[1].MessageReference)
+	assert.Equal(t, models.MessageReference{Address: "#releases", ID: "1700000000.000003", ThreadID: "1700000000.000001"}, results[2].MessageReference)
+	for _, result := range results[:3] {
+		assert.NoError(t, result.Err)
+	}
+	// The replies fail without being posted when their parent can't be
+	for _, result := range results[3:] {
+		assert.Equal(t, "#missing", result.Address)
+		assert.Error(t, result.Err)
+		assert.Empty(t, result.ID)
+	}
+
+	posts := fake.calls("chat.postMessage")
+	require.Len(t, posts, 4)
+	assert.Equal(t, "#releases", posts[0].Get("channel"))
+	assert.Empty(t, posts[0].Get("thread_ts"))
+	assert.Equal(t, "New Release Notes for peacock", posts[0].Get("text"))
+	header := slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "New Release Notes for peacock", true, false))
+	metadata := slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+		"<https://github.com/spring-financial-group/peacock|spring-financial-group/peacock> | <https://github.com/spring-financial-group/peacock/pull/1|PR #1>", false, false))
+	assert.JSONEq(t, marshalBlocks(t, header, metadata), posts[0].Get("blocks"))
+
+	for i, reply := range posts[1:3] {
+		assert.Equal(t, "#releases", reply.Get("channel"))
+		assert.Equal(t, "1700000000.000001", reply.Get("thread_ts"))
+		// The metadata is only on the parent
+		expectedBlocks := markdown.ConvertToSlackBlocks(replies[i].Content)
+		if i == 0 {
+			mentions := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "<@U012ABCDEF>", false, false), nil, nil)
+			expectedBlocks = append([]slack.Block{mentions}, expectedBlocks...)
+		}
+		assert.JSONEq(t, marshalBlocks(t, expectedBlocks...), reply.Get("blocks"))
+	}
+	assert.Equal(t, "#missing", posts[3].Get("channel"))
+}
+
+func TestClient_Reply(t *testing.T) {
+	client, fake := newTestClient(t)
+	parent := models.MessageReference{Address: "#releases", ID: "1690000000.000001"}
+
+	result := client.Reply(context.Background(), models.Message{Content: "**(2/2)**\n\nThe rest", PullRequest: mockPR}, parent)
+
+	require.NoError(t, result.Err)
+	assert.Equal(t, models.MessageReference{Address: "#releases", ID: "1700000000.000001", ThreadID: "1690000000.000001"}, result.MessageReference)
+
+	posts := fake.calls("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "#releases", posts[0].Get("channel"))
+	assert.Equal(t, "1690000000.000001", posts[0].Get("thread_ts"))
+	assert.JSONEq(t, marshalBlocks(t, markdown.ConvertToSlackBlocks("**(2/2)**\n\nThe rest")...), posts[0].Get("blocks"))
+}
+
+func TestClient_Update(t *testing.T) {
+	testCases := []struct {
+		name           string
+		ref            models.MessageReference
+		expectedBlocks []slack.Block
+	}{
+		{
+			name: "Message",
+			ref:  models.MessageReference{Address: "#releases", ID: "1690000000.000001"},
+			expectedBlocks: append(markdown.ConvertToSlackBlocks("Updated note"), slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+				"<https://github.com/spring-financial-group/peacock|spring-financial-group/peacock> | <https://github.com/spring-financial-group/peacock/pull/1|PR #1>", false, false))),
+		},
+		{
+			// Replies were sent without the metadata as it's on their parent
+			name:           "Reply",
+			ref:            models.MessageReference{Address: "#releases", ID: "1690000000.000002", ThreadID: "1690000000.000001"},
+			expectedBlocks: markdown.ConvertToSlackBlocks("Updated note"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake := newTestClient(t)
+
+			err := client.Update(context.Background(), models.Message{Content: "Updated note", PullRequest: mockPR}, tt.ref)
+			require.NoError(t, err)
+
+			// The stored channel & timestamp are sent back unchanged to identify the message
+			updates := fake.calls("chat.update")
+			require.Len(t, updates, 1)
+			assert.Equal(t, tt.ref.Address, updates[0].Get("channel"))
+			assert.Equal(t, tt.ref.ID, updates[0].Get("ts"))
+			assert.Equal(t, markdown.ConvertToSlack("Updated note"), updates[0].Get("text"))
+			assert.JSONEq(t, marshalBlocks(t, tt.expectedBlocks...), updates[0].Get("blocks"))
+			assert.Empty(t, fake.calls("chat.postMessage"))
+		})
+	}
+}
+
+func TestClient_Update_Error(t *testing.T) {
+	client, _ := newTestClient(t)
+	err := client.Update(context.Background(), models.Message{Content: "Updated note"}, models.MessageReference{Address: "#missing", ID: "1690000000.000001"})
+	assert.Error(t, err)
+}
+
+func TestClient_Delete(t *testing.T) {
+	client, fake := newTestClient(t)
+	ref := models.MessageReference{Address: "#releases", ID: "1690000000.000002", ThreadID: "1690000000.000001"}
+
+	err := client.Delete(context.Background(), ref)
+	require.NoError(t, err)
+
+	deletes := fake.calls("chat.delete")
+	require.Len(t, deletes, 1)
+	assert.Equal(t, ref.Address, deletes[0].Get("channel"))
+	assert.Equal(t, ref.ID, deletes[0].Get("ts"))
+
+	err = client.Delete(context.Background(), models.MessageReference{Address: "#missing", ID: "1690000000.000001"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/spring-financial-group/peacock/pkg/utils/http_utils"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Twilio API
+	DefaultAPIURL = "https://api.twilio.com"
+	messagesPath  = "/2010-04-01/Accounts/%s/Messages.json"
+
+	// maxBodyLength keeps a text to two concatenated segments so that it's delivered as a single short message
+	maxBodyLength = 306
+	// truncationMarker ends the text of a truncated message, it's plain ASCII so that the message stays GSM-7 encoded
+	truncationMarker = "..."
+)
+
+// Client sends messages as texts through a Twilio compatible REST API, the addresses of the team are the phone numbers
+// the texts are sent to
+type Client struct {
+	cfg    config.SMS
+	policy *retry.Policy
+}
+
+func NewClient(cfg config.SMS, policy *retry.Policy) *Client {
+	if cfg.URL == "" {
+		cfg.URL = DefaultAPIURL
+	}
+	return &Client{
+		cfg:    cfg,
+		policy: policy,
+	}
+}
+
+func (c *Client) Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult {
+	results := make([]models.DeliveryResult, len(addresses))
+	body := generateBody(msg)
+	for i, address := range addresses {
+		results[i].Address = address
+		if err := c.post(ctx, address, body); err != nil {
+			results[i].Err = errors.Wrapf(err, "failed to send text to %s", address)
+			results[i].Retryable = http_utils.IsRetryable(err)
+		}
+	}
+	return results
+}
+
+func (c *Client) post(ctx context.Context, to, body string) error {
+	endpoint := strings.TrimSuffix(c.cfg.URL, "/") + fmt.Sprintf(messagesPath, url.PathEscape(c.cfg.AccountSID))
+	form := url.Values{
+		"To":   {to},
+		"From": {c.cfg.From},
+		"Body": {body},
+	}
+	return c.policy.Do(ctx, http_utils.Classify, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http_utils.POST, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set(http_utils.ContentType, "application/x-www-form-urlencoded")
+		req.SetBasicAuth(c.cfg.AccountSID, c.cfg.AuthToken)
+		return http_utils.DoRequestAndCatchUnsuccessful(req)
+	})
+}
+
+// MaxContentLength is unlimited as long release notes are truncated rather than split, sending several texts for a
+// single release note would be more disruptive than useful
+func (c *Client) MaxContentLength() int {
+	return 0
+}
+
+// generateBody renders the message as plain text. If it's too long for a short text then it's truncated & a link to the
+// pull request is added, when its number is known, so that the rest of the release note can be read there.
+func generateBody(msg models.Message) string {
+	text := markdown.ConvertToPlainText(msg.Content)
+	if msg.Subject != "" {
+		text = msg.Subject + "\n" + text
+	}
+	if len([]rune(text)) <= maxBodyLength {
+		return text
+	}
+
+	suffix := truncationMarker
+	if msg.PullRequest.PRNumber > 0 {
+		suffix += "\n" + msg.PullRequest.URL()
+	}
+	keep := maxBodyLength - len([]rune(suffix))
+	return strings.TrimRight(string([]rune(text)[:keep]), " \n") + suffix
+}
@@ -0,0 +1,97 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Send(t *testing.T) {
+	var requests []*http.Request
+	var forms []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		requests = append(requests, r)
+		forms = append(forms, r.PostForm)
+		if r.PostForm.Get("To") == "+15005550001" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(config.SMS{
+		URL:        server.URL,
+		AccountSID: "AC123",
+		AuthToken:  "token",
+		From:       "+15005550006",
+	}, retry.NewPolicy(config.Delivery{MaxAttempts: 1}))
+
+	msg := models.Message{Subject: "Release", Content: "### Payments\n* New **feature**"}
+	results := client.Send(context.Background(), msg, []string{"+447700900123", "+15005550001"})
+
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "+447700900123", results[0].Address)
+	assert.Error(t, results[1].Err)
+	assert.False(t, results[1].Retryable)
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", requests[0].URL.Path)
+	user, pass, ok := requests[0].BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "AC123", user)
+	assert.Equal(t, "token", pass)
+	assert.Equal(t, "+15005550006", forms[0].Get("From"))
+	assert.Equal(t, "Release\nPayments\n\n* New feature", forms[0].Get("Body"))
+}
+
+func TestGenerateBody(t *testing.T) {
+	pr := models.PullRequestSummary{PRNumber: 12, RepoOwner: "spring-financial-group", RepoName: "peacock"}
+	link := "https://github.com/spring-financial-group/peacock/pull/12"
+
+	testCases := []struct {
+		name         string
+		msg          models.Message
+		expectedBody string
+	}{
+		{
+			name:         "Short",
+			msg:          models.Message{Subject: "Release", Content: "A [fix](https://example.com)", PullRequest: pr},
+			expectedBody: "Release\nA fix (https://example.com)",
+		},
+		{
+			name:         "NoSubject",
+			msg:          models.Message{Content: "A fix", PullRequest: pr},
+			expectedBody: "A fix",
+		},
+		{
+			name:         "Truncated",
+			msg:          models.Message{Subject: "Release", Content: strings.Repeat("a", 400), PullRequest: pr},
+			expectedBody: "Release\n" + strings.Repeat("a", maxBodyLength-len("Release\n")-len("...\n"+link)) + "...\n" + link,
+		},
+		{
+			name:         "TruncatedWithoutPRNumber",
+			msg:          models.Message{Subject: "Release", Content: strings.Repeat("a", 400), PullRequest: models.PullRequestSummary{PRNumber: -1, RepoOwner: "spring-financial-group", RepoName: "peacock"}},
+			expectedBody: "Release\n" + strings.Repeat("a", maxBodyLength-len("Release\n")-len("...")) + "...",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			body := generateBody(tt.msg)
+			assert.Equal(t, tt.expectedBody, body)
+			assert.LessOrEqual(t, len([]rune(body)), maxBodyLength)
+		})
+	}
+}
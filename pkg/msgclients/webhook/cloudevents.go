@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	// ReleaseNoteEventType is the type of the CloudEvents sent for release notes
+	ReleaseNoteEventType = "com.peacock.release.note"
+
+	cloudEventsContentType  = "application/cloudevents+json; charset=UTF-8"
+	cloudEventsHeaderPrefix = "ce-"
+)
+
+// cloudEvent is a release note as a CloudEvent, see https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md
+type cloudEvent struct {
+	SpecVersion     string  `json:"specversion"`
+	ID              string  `json:"id"`
+	Source          string  `json:"source"`
+	Type            string  `json:"type"`
+	Subject         string  `json:"subject"`
+	Time            string  `json:"time"`
+	DataContentType string  `json:"datacontenttype"`
+	Data            payload `json:"data"`
+}
+
+// newCloudEvent creates the event for a message. The ID is derived from the hash of the note so that the same note sent
+// again, e.g. when a delivery is retried, has the same ID & can be deduplicated by the receiver.
+func newCloudEvent(msg models.Message, data payload, now time.Time) cloudEvent {
+	pr := msg.PullRequest
+	return cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              fmt.Sprintf("pr-%d-%s-%s", pr.PRNumber, msg.NoteHash, presentationHash(msg)),
+		Source:          fmt.Sprintf("%s/%s/%s", domain.GitHubURL, pr.RepoOwner, pr.RepoName),
+		Type:            ReleaseNoteEventType,
+		Subject:         fmt.Sprintf("pull/%d", pr.PRNumber),
+		Time:            now.UTC().Format(time.RFC3339),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// presentationHash returns a short hash of how the message presents its note. A note is sent as one message for each
+// group of teams that present it differently, & as several messages when it's split into parts, so the messages for a
+// note are told apart by their subject, metadata & content.
+func presentationHash(msg models.Message) string {
+	h := sha256.New()
+	h.Write([]byte(msg.Subject))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatBool(msg.HideMetadata)))
+	h.Write([]byte{0})
+	h.Write([]byte(msg.Content))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// structured returns the body of the event in the structured content mode, where the attributes & data are all in the
+// body
+func (e cloudEvent) structured() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// binary returns the body & headers of the event in the binary content mode, where the data is the body & the
+// attributes are headers
+func (e cloudEvent) binary() ([]byte, http.Header, error) {
+	body, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set(cloudEventsHeaderPrefix+"specversion", e.SpecVersion)
+	header.Set(cloudEventsHeaderPrefix+"id", e.ID)
+	header.Set(cloudEventsHeaderPrefix+"source", e.Source)
+	header.Set(cloudEventsHeaderPrefix+"type", e.Type)
+	header.Set(cloudEventsHeaderPrefix+"subject", e.Subject)
+	header.Set(cloudEventsHeaderPrefix+"time", e.Time)
+	return body, header, nil
+}
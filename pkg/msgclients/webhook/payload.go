@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+)
+
+// SchemaVersion is the version of the payload, it's incremented whenever a change is made that receivers would need to
+// handle. Version 1 only had the body, subject & addresses.
+const SchemaVersion = 2
+
+// payload is the release note as it's posted to the webhook
+type payload struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Subject       string `json:"subject"`
+	// Body is the HTML rendering of the release note, it's the same as Content.HTML & is kept for version 1 receivers
+	Body        string            `json:"body"`
+	Addresses   []string          `json:"addresses"`
+	Teams       []string          `json:"teams"`
+	Repository  payloadRepository `json:"repository"`
+	PullRequest payloadPR         `json:"pullRequest"`
+	// Environment is the environment the release note was released to, if the pull request changed one
+	Environment string         `json:"environment,omitempty"`
+	Content     payloadContent `json:"content"`
+}
+
+type payloadRepository struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+type payloadPR struct {
+	Number   int    `json:"number"`
+	URL      string `json:"url"`
+	MergeSHA string `json:"mergeSha,omitempty"`
+}
+
+// payloadContent is the release note rendered in different formats so that receivers can use the one that suits them
+type payloadContent struct {
+	Markdown string `json:"markdown"`
+	HTML     string `json:"html"`
+	Text     string `json:"text"`
+}
+
+func newPayload(msg models.Message, addresses []string) payload {
+	pr := msg.PullRequest
+	repoURL := fmt.Sprintf("%s/%s/%s", domain.GitHubURL, pr.RepoOwner, pr.RepoName)
+	htmlContent := markdown.ConvertToHTML(msg.Content)
+	return payload{
+		SchemaVersion: SchemaVersion,
+		Subject:       msg.Subject,
+		Body:          htmlContent,
+		Addresses:     addresses,
+		Teams:         msg.Teams,
+		Repository: payloadRepository{
+			Owner: pr.RepoOwner,
+			Name:  pr.RepoName,
+			URL:   repoURL,
+		},
+		PullRequest: payloadPR{
+			Number:   pr.PRNumber,
+			URL:      fmt.Sprintf("%s/pull/%d", repoURL, pr.PRNumber),
+			MergeSHA: pr.MergeSHA,
+		},
+		Environment: pr.Environment,
+		Content: payloadContent{
+			Markdown: msg.Content,
+			HTML:     htmlContent,
+			Text:     markdown.ConvertToPlainText(msg.Content),
+		},
+	}
+}
@@ -1,50 +1,103 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"time"
+
 	"github.com/pkg/errors"
-	"github.com/spring-financial-group/peacock/pkg/markdown"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/spring-financial-group/peacock/pkg/utils"
 	"github.com/spring-financial-group/peacock/pkg/utils/http_utils"
+	"github.com/spring-financial-group/peacock/pkg/webhookverify"
 )
 
 type Client struct {
 	url    string
 	token  string
 	secret string
+	format string
+	policy *retry.Policy
+
+	now func() time.Time
 }
 
-func NewClient(url, authToken, secret string) *Client {
+// NewClient creates a client that posts to the URL in the payload format, by default the legacy format is used. An
+// error is returned if the format isn't one of the valid payload formats.
+func NewClient(url, authToken, secret, format string, policy *retry.Policy) (*Client, error) {
+	if format == "" {
+		format = models.LegacyPayload
+	}
+	if !utils.ExistsInSlice(format, models.ValidPayloadFormats) {
+		return nil, errors.Errorf("unsupported webhook payload format %s, must be one of %v", format, models.ValidPayloadFormats)
+	}
 	return &Client{
 		url:    url,
 		token:  authToken,
 		secret: secret,
-	}
+		format: format,
+		policy: policy,
+		now:    time.Now,
+	}, nil
 }
 
-type postRequest struct {
-	Body      string   `json:"body"`
-	Subject   string   `json:"subject"`
-	Addresses []string `json:"addresses"`
+// Send posts the message for all the addresses in a single request, so the result is the same for every address
+func (h *Client) Send(ctx context.Context, msg models.Message, addresses []string) []models.DeliveryResult {
+	err := h.post(ctx, msg, addresses)
+	results := make([]models.DeliveryResult, len(addresses))
+	for i, address := range addresses {
+		results[i].Address = address
+		if err != nil {
+			results[i].Err = errors.Wrap(err, "failed to post messages")
+			results[i].Retryable = http_utils.IsRetryable(err)
+		}
+	}
+	return results
 }
 
-func (h *Client) Send(content, subject string, addresses []string) error {
-	postReq := postRequest{
-		Body:      markdown.ConvertToHTML(content),
-		Subject:   subject,
-		Addresses: addresses,
-	}
-	data, err := json.Marshal(postReq)
+func (h *Client) post(ctx context.Context, msg models.Message, addresses []string) error {
+	data, header, err := h.encode(msg, newPayload(msg, addresses))
 	if err != nil {
 		return err
 	}
 
-	req, err := http_utils.GenerateAuthenticatedPostRequest(h.url, h.token, http_utils.SignMessage(data, h.secret), data)
-	if err != nil {
-		return err
-	}
-	_, err = http_utils.DoRequestAndCatchUnsuccessful(req)
-	if err != nil {
-		return errors.Wrap(err, "failed to post messages")
+	// The body only signature is kept for receivers that haven't moved to verifying the timestamped one
+	signature := http_utils.SignMessage(data, h.secret)
+	return h.policy.Do(ctx, http_utils.Classify, func(ctx context.Context) error {
+		req, err := http_utils.GenerateAuthenticatedPostRequest(ctx, h.url, h.token, signature, data)
+		if err != nil {
+			return err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		// Each attempt is signed with a new timestamp & nonce so that retries aren't rejected as replays
+		if err = webhookverify.SignRequest(req, data, h.secret, h.now()); err != nil {
+			return err
+		}
+		return http_utils.DoRequestAndCatchUnsuccessful(req)
+	})
+}
+
+// encode returns the body of the request in the client's payload format & any headers it adds to the request
+func (h *Client) encode(msg models.Message, p payload) ([]byte, http.Header, error) {
+	switch h.format {
+	case models.LegacyPayload:
+		data, err := json.Marshal(p)
+		return data, nil, err
+	case models.CloudEventsStructuredPayload:
+		data, err := newCloudEvent(msg, p, h.now()).structured()
+		return data, http.Header{http_utils.ContentType: {cloudEventsContentType}}, err
+	case models.CloudEventsBinaryPayload:
+		return newCloudEvent(msg, p, h.now()).binary()
+	default:
+		return nil, nil, errors.Errorf("unsupported webhook payload format %s", h.format)
 	}
-	return nil
+}
+
+// MaxContentLength is unlimited as the receiver of the webhook is responsible for handling long release notes
+func (h *Client) MaxContentLength() int {
+	return 0
 }
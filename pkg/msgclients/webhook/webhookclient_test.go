@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/spring-financial-group/peacock/pkg/utils/http_utils"
+	"github.com/spring-financial-group/peacock/pkg/webhookverify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Send(t *testing.T) {
+	msg := models.Message{
+		Subject: "Release",
+		Content: "Hello **infra**",
+		PullRequest: models.PullRequestSummary{
+			PRNumber:    1,
+			RepoOwner:   "spring-financial-group",
+			RepoName:    "peacock",
+			MergeSHA:    "merge-sha",
+			Environment: "staging",
+		},
+		NoteHash: "abc123",
+		Teams:    []string{"infrastructure"},
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := payload{
+		SchemaVersion: SchemaVersion,
+		Subject:       "Release",
+		Body:          "<p>Hello <strong>infra</strong></p>\n",
+		Addresses:     []string{"Webhook1"},
+		Teams:         []string{"infrastructure"},
+		Repository: payloadRepository{
+			Owner: "spring-financial-group",
+			Name:  "peacock",
+			URL:   "https://github.com/spring-financial-group/peacock",
+		},
+		PullRequest: payloadPR{
+			Number:   1,
+			URL:      "https://github.com/spring-financial-group/peacock/pull/1",
+			MergeSHA: "merge-sha",
+		},
+		Environment: "staging",
+		Content: payloadContent{
+			Markdown: "Hello **infra**",
+			HTML:     "<p>Hello <strong>infra</strong></p>\n",
+			Text:     "Hello infra",
+		},
+	}
+
+	testCases := []struct {
+		name                string
+		format              string
+		expectedContentType string
+		expectedHeaders     map[string]string
+		expectedBody        interface{}
+	}{
+		{
+			name:                "Legacy",
+			format:              models.LegacyPayload,
+			expectedContentType: http_utils.ApplicationJSON,
+			expectedBody:        data,
+		},
+		{
+			name:                "CloudEventsStructured",
+			format:              models.CloudEventsStructuredPayload,
+			expectedContentType: cloudEventsContentType,
+			expectedBody: cloudEvent{
+				SpecVersion:     "1.0",
+				ID:              "pr-1-abc123-29f05d57ffe2",
+				Source:          "https://github.com/spring-financial-group/peacock",
+				Type:            ReleaseNoteEventType,
+				Subject:         "pull/1",
+				Time:            "2024-01-02T03:04:05Z",
+				DataContentType: "application/json",
+				Data:            data,
+			},
+		},
+		{
+			name:                "CloudEventsBinary",
+			format:              models.CloudEventsBinaryPayload,
+			expectedContentType: http_utils.ApplicationJSON,
+			expectedHeaders: map[string]string{
+				"ce-specversion": "1.0",
+				"ce-id":          "pr-1-abc123-29f05d57ffe2",
+				"ce-source":      "https://github.com/spring-financial-group/peacock",
+				"ce-type":        ReleaseNoteEventType,
+				"ce-subject":     "pull/1",
+				"ce-time":        "2024-01-02T03:04:05Z",
+			},
+			expectedBody: data,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = r
+				body, _ = io.ReadAll(r.Body)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL, "token", "secret", tt.format, retry.NewPolicy(config.Delivery{}))
+			require.NoError(t, err)
+			client.now = func() time.Time { return now }
+
+			results := client.Send(context.Background(), msg, []string{"Webhook1"})
+			require.Len(t, results, 1)
+			require.NoError(t, results[0].Err)
+
+			assert.Equal(t, tt.expectedContentType, req.Header.Get(http_utils.ContentType))
+			assert.Equal(t, http_utils.SignMessage(body, "secret"), req.Header.Get(http_utils.SignatureHeader))
+			assert.Equal(t, strconv.FormatInt(now.Unix(), 10), req.Header.Get(webhookverify.TimestampHeader))
+			assert.Equal(t, webhookverify.Sign("secret", now.Unix(), req.Header.Get(webhookverify.NonceHeader), body), req.Header.Get(webhookverify.SignatureHeader))
+			for key, value := range tt.expectedHeaders {
+				assert.Equal(t, value, req.Header.Get(key))
+			}
+
+			expected, err := json.Marshal(tt.expectedBody)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(body))
+		})
+	}
+}
+
+func TestNewCloudEvent_ID(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := models.Message{
+		Subject:     "Release",
+		Content:     "Hello **infra**",
+		PullRequest: models.PullRequestSummary{PRNumber: 1, RepoOwner: "spring-financial-group", RepoName: "peacock"},
+		NoteHash:    "abc123",
+	}
+	otherSubject := msg
+	otherSubject.Subject = "Infra Release"
+	hiddenMetadata := msg
+	hiddenMetadata.HideMetadata = true
+	secondPart := msg
+	secondPart.Content = "**(2/2)**\n\nHello **infra**"
+
+	// A note sent again has the same ID, but each way it's presented & each of its parts has its own
+	id := newCloudEvent(msg, payload{}, now).ID
+	assert.Equal(t, id, newCloudEvent(msg, payload{}, now.Add(time.Hour)).ID)
+	ids := map[string]bool{id: true}
+	for _, other := range []models.Message{otherSubject, hiddenMetadata, secondPart} {
+		otherID := newCloudEvent(other, payload{}, now).ID
+		assert.False(t, ids[otherID], otherID)
+		ids[otherID] = true
+	}
+}
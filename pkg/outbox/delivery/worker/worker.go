@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+)
+
+const defaultInterval = 30 * time.Second
+
+// Worker drains the outbox in the background, retrying the deliveries that failed with transient errors
+type Worker struct {
+	outboxUC domain.OutboxUseCase
+	interval time.Duration
+}
+
+func NewWorker(cfg *config.Outbox, outboxUC domain.OutboxUseCase) *Worker {
+	w := &Worker{
+		outboxUC: outboxUC,
+		interval: cfg.PollInterval,
+	}
+	if w.interval <= 0 {
+		w.interval = defaultInterval
+	}
+	return w
+}
+
+// Run delivers the jobs that are due every interval until the context is done
+func (w *Worker) Run(ctx context.Context) {
+	log.Infof("Outbox worker started, polling every %s", w.interval)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := w.outboxUC.DeliverDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error(errors.Wrap(err, "failed to deliver jobs from the outbox"))
+		}
+		if count > 0 {
+			log.Infof("%d job(s) attempted from the outbox", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("Outbox worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository(client mongo.Client) domain.OutboxRepository {
+	db := *client.Database("Peacock")
+	collection := db.Collection("Outbox")
+
+	return &repository{
+		collection: collection,
+	}
+}
+
+func (r *repository) Insert(ctx context.Context, jobs []models.DeliveryJob) error {
+	docs := make([]interface{}, len(jobs))
+	for i, job := range jobs {
+		docs[i] = job
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*models.DeliveryJob, error) {
+	filter := bson.M{
+		"status":        models.DeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+		"lockedUntil":   bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{
+		"lockedUntil": now.Add(lease),
+		"leaseToken":  primitive.NewObjectID().Hex(),
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"nextAttemptAt": 1}).
+		SetReturnDocument(options.After)
+
+	var job models.DeliveryJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *repository) Update(ctx context.Context, job models.DeliveryJob) error {
+	// The job is only replaced if no other worker has claimed it since this one did
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID, "leaseToken": job.LeaseToken}, job)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrLeaseLost
+	}
+
+	return nil
+}
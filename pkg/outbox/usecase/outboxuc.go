@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// lease is how long a job is locked for while it's being sent, it's longer than sending the notes can take so that
+	// a job is only picked up by another worker if the one sending it has died
+	lease = 10 * time.Minute
+
+	initialRetryDelay  = time.Minute
+	maxRetryDelay      = time.Hour
+	defaultMaxAttempts = 10
+)
+
+type UseCase struct {
+	repository  domain.OutboxRepository
+	notesUC     domain.ReleaseNotesUseCase
+	ledgerUC    domain.DeliveryLedgerUseCase
+	maxAttempts int
+
+	now func() time.Time
+}
+
+func NewUseCase(cfg *config.Outbox, repository domain.OutboxRepository, notesUC domain.ReleaseNotesUseCase, ledgerUC domain.DeliveryLedgerUseCase) *UseCase {
+	uc := &UseCase{
+		repository:  repository,
+		notesUC:     notesUC,
+		ledgerUC:    ledgerUC,
+		maxAttempts: cfg.MaxAttempts,
+		now:         time.Now,
+	}
+	if uc.maxAttempts <= 0 {
+		uc.maxAttempts = defaultMaxAttempts
+	}
+	return uc
+}
+
+func (uc *UseCase) Deliver(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) (models.DeliveryReport, error) {
+	jobs := uc.newJobs(subject, notes, pr)
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	// The jobs are written locked so that the worker doesn't pick them up while the first attempt is being made. If
+	// the outbox can't be written to the notes are still sent, they just won't be retried.
+	durable := true
+	if err := uc.repository.Insert(ctx, jobs); err != nil {
+		log.Error(errors.Wrap(err, "failed to write jobs to the outbox, sending without retries"))
+		durable = false
+	}
+
+	var report models.DeliveryReport
+	for i := range jobs {
+		report = append(report, uc.attempt(ctx, &jobs[i], durable)...)
+	}
+	return report, nil
+}
+
+func (uc *UseCase) DeliverDue(ctx context.Context) (int, error) {
+	var count int
+	for ctx.Err() == nil {
+		job, err := uc.repository.ClaimNext(ctx, uc.now(), lease)
+		if err != nil {
+			return count, errors.Wrap(err, "failed to claim job from the outbox")
+		}
+		if job == nil {
+			return count, nil
+		}
+
+		log.Infof("Retrying delivery of release notes for %s/PR-%d via %s, attempt %d", job.PullRequest.RepoName, job.PullRequest.PRNumber, job.ContactType, job.Attempts+1)
+		uc.attempt(ctx, job, true)
+		count++
+	}
+	return count, ctx.Err()
+}
+
+// newJobs creates a job for each contact type used by the notes. Threaded Slack teams get a job of their own as their
+// notes are posted together under a parent message.
+func (uc *UseCase) newJobs(subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) []models.DeliveryJob {
+	type jobKey struct {
+		contactType string
+		threaded    bool
+	}
+	var keys []jobKey
+	notesByKey := make(map[jobKey][]models.ReleaseNote)
+	for _, note := range notes {
+		added := make(map[jobKey]bool)
+		for _, team := range note.Teams {
+			for _, c := range team.GetChannels() {
+				if c.ContactType == models.None || len(c.Addresses) == 0 {
+					continue
+				}
+				key := jobKey{c.ContactType, c.IsThreaded()}
+				if added[key] {
+					continue
+				}
+				added[key] = true
+				if _, ok := notesByKey[key]; !ok {
+					keys = append(keys, key)
+				}
+				notesByKey[key] = append(notesByKey[key], note)
+			}
+		}
+	}
+
+	now := uc.now()
+	jobs := make([]models.DeliveryJob, len(keys))
+	for i, key := range keys {
+		jobs[i] = models.DeliveryJob{
+			ID:            primitive.NewObjectID(),
+			PullRequest:   pr,
+			Subject:       subject,
+			ContactType:   key.contactType,
+			Threaded:      key.threaded,
+			Notes:         notesByKey[key],
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			LockedUntil:   now.Add(lease),
+			LeaseToken:    primitive.NewObjectID().Hex(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+	}
+	return jobs
+}
+
+// attempt sends the notes of the job that haven't been delivered yet and records the outcome in the outbox, if the job
+// is durable, i.e. it was written to the outbox
+func (uc *UseCase) attempt(ctx context.Context, job *models.DeliveryJob, durable bool) models.DeliveryReport {
+	report := uc.notesUC.SendReleaseNotes(ctx, job.Subject, pendingNotes(*job), job.PullRequest)
+	job.Attempts++
+
+	refs := report.References()
+	job.Messages = append(job.Messages, refs...)
+	if err := uc.notesUC.AddSentMessages(ctx, job.PullRequest, refs); err != nil {
+		log.Error(errors.Wrap(err, "failed to save sent messages"))
+	}
+	// A note is only delivered to an address once all of its parts are, so that the overflow of a long note isn't lost
+	if err := uc.ledgerUC.Record(ctx, job.Notes, job.PullRequest, completeReferences(report)); err != nil {
+		log.Error(errors.Wrap(err, "failed to record deliveries in the ledger"))
+	}
+
+	// Failures that aren't transient are kept so that those addresses aren't tried again
+	var failures []models.DeliveryFailure
+	for _, f := range job.Failures {
+		if !f.Retryable {
+			failures = append(failures, f)
+		}
+	}
+	var retryable bool
+	for _, f := range report.Failures() {
+		failures = append(failures, models.DeliveryFailure{
+			NoteKey:   f.NoteKey,
+			Address:   f.Address,
+			Error:     f.Err.Error(),
+			Retryable: f.Retryable,
+		})
+		retryable = retryable || f.Retryable
+	}
+	job.Failures = failures
+	// Jobs that aren't in the outbox can't be retried, so there's no outcome to record & none of the failures will be
+	// retried
+	if !durable {
+		uc.release(ctx, *job, report.Failures())
+		return report
+	}
+
+	now := uc.now()
+	switch {
+	case len(failures) == 0:
+		job.Status = models.DeliveryDelivered
+	case retryable && job.Attempts < uc.maxAttempts:
+		job.Status = models.DeliveryPending
+		job.NextAttemptAt = now.Add(retryDelay(job.Attempts))
+		log.Warnf("Delivery of release notes for %s/PR-%d via %s will be retried at %s", job.PullRequest.RepoName, job.PullRequest.PRNumber, job.ContactType, job.NextAttemptAt.Format(time.RFC3339))
+	default:
+		job.Status = models.DeliveryDeadLettered
+		log.Errorf("Delivery of release notes for %s/PR-%d via %s dead-lettered after %d attempt(s)", job.PullRequest.RepoName, job.PullRequest.PRNumber, job.ContactType, job.Attempts)
+	}
+	job.LockedUntil = time.Time{}
+	job.UpdatedAt = now
+
+	// The transient failures of a job that's still pending are queued to be sent again, the others are abandoned
+	var abandoned models.DeliveryReport
+	for i, result := range report {
+		if result.Delivered() {
+			continue
+		}
+		if result.Retryable && job.Status == models.DeliveryPending {
+			report[i].Queued = true
+			continue
+		}
+		abandoned = append(abandoned, result)
+	}
+	uc.release(ctx, *job, abandoned)
+
+	// If this fails the lock will expire & the job will be sent again, so delivery is at least once
+	if err := uc.repository.Update(ctx, *job); err != nil {
+		if errors.Is(err, domain.ErrLeaseLost) {
+			log.Warnf("Delivery job for %s/PR-%d via %s was claimed by another worker, its outcome wasn't saved", job.PullRequest.RepoName, job.PullRequest.PRNumber, job.ContactType)
+		} else {
+			log.Error(errors.Wrap(err, "failed to update job in the outbox"))
+		}
+	}
+	return report
+}
+
+// release removes the ledger's claims of the failed deliveries that won't be retried, so that the notes can be sent to
+// those addresses again
+func (uc *UseCase) release(ctx context.Context, job models.DeliveryJob, failures models.DeliveryReport) {
+	if len(failures) == 0 {
+		return
+	}
+	refs := make([]models.MessageReference, len(failures))
+	for i, f := range failures {
+		refs[i] = f.MessageReference
+	}
+	if err := uc.ledgerUC.Release(ctx, job.Notes, job.PullRequest, refs); err != nil {
+		log.Error(errors.Wrap(err, "failed to release claims in the ledger"))
+	}
+}
+
+type noteAddress struct{ noteKey, address string }
+
+// completeReferences returns the references of the report for the notes that every part was delivered for
+func completeReferences(report models.DeliveryReport) []models.MessageReference {
+	failed := make(map[noteAddress]bool)
+	for _, f := range report.Failures() {
+		failed[noteAddress{f.NoteKey, f.Address}] = true
+	}
+	var refs []models.MessageReference
+	for _, ref := range report.References() {
+		if !failed[noteAddress{ref.NoteKey, ref.Address}] {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// pendingNotes returns the notes of the job limited to the addresses they still need to be sent to via the job's
+// contact type. A note whose first part was delivered but whose overflow failed transiently is sent again in full.
+func pendingNotes(job models.DeliveryJob) []models.ReleaseNote {
+	done := make(map[noteAddress]bool)
+	for _, ref := range job.Messages {
+		if ref.Part == 0 {
+			done[noteAddress{ref.NoteKey, ref.Address}] = true
+		}
+	}
+	for _, f := range job.Failures {
+		// The failures are those of the last attempt along with earlier ones that weren't transient
+		done[noteAddress{f.NoteKey, f.Address}] = !f.Retryable
+	}
+
+	var notes []models.ReleaseNote
+	for _, note := range job.Notes {
+		key := note.Key()
+		pending := note.FilterAddresses(func(c models.Channel, address string) bool {
+			return c.ContactType == job.ContactType && c.IsThreaded() == job.Threaded && !done[noteAddress{key, address}]
+		})
+		if pending.HasAddresses() {
+			notes = append(notes, pending)
+		}
+	}
+	return notes
+}
+
+// retryDelay doubles the wait before each retry of a job, up to a maximum
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 || attempts > 7 {
+		return maxRetryDelay
+	}
+	if delay := initialRetryDelay << (attempts - 1); delay < maxRetryDelay {
+		return delay
+	}
+	return maxRetryDelay
+}
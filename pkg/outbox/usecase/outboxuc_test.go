@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spring-financial-group/peacock/pkg/config"
+	"github.com/spring-financial-group/peacock/pkg/domain/mocks"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	infraTeam = models.Team{
+		Name:        "infrastructure",
+		ContactType: models.Slack,
+		Addresses:   []string{"C1", "C2"},
+	}
+	supportTeam = models.Team{
+		Name:        "support",
+		ContactType: models.Webhook,
+		Addresses:   []string{"Webhook1"},
+	}
+	mockPR = models.PullRequestSummary{
+		PRNumber:  1,
+		RepoOwner: "spring-financial-group",
+		RepoName:  "peacock",
+	}
+	now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+func newTestUseCase(t *testing.T) (*UseCase, *mocks.OutboxRepository, *mocks.ReleaseNotesUseCase, *mocks.DeliveryLedgerUseCase) {
+	mockRepo := mocks.NewOutboxRepository(t)
+	mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+	mockLedgerUC := mocks.NewDeliveryLedgerUseCase(t)
+	uc := NewUseCase(&config.Outbox{MaxAttempts: 3}, mockRepo, mockNotesUC, mockLedgerUC)
+	uc.now = func() time.Time { return now }
+	return uc, mockRepo, mockNotesUC, mockLedgerUC
+}
+
+func TestUseCase_Deliver(t *testing.T) {
+	ctx := context.Background()
+	uc, mockRepo, mockNotesUC, mockLedgerUC := newTestUseCase(t)
+
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam, supportTeam}, Content: "Note"}
+	// Each contact type is its own job, with the teams of other contact types given the None contact type
+	slackTeams := models.Teams{infraTeam, {Name: "support", ContactType: models.None}}
+	webhookTeams := models.Teams{{Name: "infrastructure", ContactType: models.None}, supportTeam}
+
+	var insertedJobs []models.DeliveryJob
+	mockRepo.On("Insert", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		insertedJobs = append(insertedJobs, args.Get(1).([]models.DeliveryJob)...)
+	}).Return(nil).Once()
+
+	delivered := models.DeliveryResult{MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: note.Key(), Address: "C1", ID: "1"}}
+	transient := models.DeliveryResult{MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: note.Key(), Address: "C2"}, Err: errors.New("timeout"), Retryable: true}
+	permanent := models.DeliveryResult{MessageReference: models.MessageReference{ContactType: models.Webhook, NoteKey: note.Key(), Address: "Webhook1"}, Err: errors.New("bad request")}
+
+	mockNotesUC.On("SendReleaseNotes", ctx, "Subject", []models.ReleaseNote{{Teams: slackTeams, Content: "Note"}}, mockPR).
+		Return(models.DeliveryReport{delivered, transient}).Once()
+	mockNotesUC.On("AddSentMessages", ctx, mockPR, []models.MessageReference{delivered.MessageReference}).Return(nil).Once()
+	mockLedgerUC.On("Record", ctx, []models.ReleaseNote{note}, mockPR, []models.MessageReference{delivered.MessageReference}).Return(nil).Once()
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(job models.DeliveryJob) bool {
+		return job.ContactType == models.Slack &&
+			job.Status == models.DeliveryPending &&
+			job.NextAttemptAt.Equal(now.Add(initialRetryDelay)) &&
+			job.LockedUntil.IsZero()
+	})).Return(nil).Once()
+
+	mockNotesUC.On("SendReleaseNotes", ctx, "Subject", []models.ReleaseNote{{Teams: webhookTeams, Content: "Note"}}, mockPR).
+		Return(models.DeliveryReport{permanent}).Once()
+	mockNotesUC.On("AddSentMessages", ctx, mockPR, []models.MessageReference(nil)).Return(nil).Once()
+	mockLedgerUC.On("Record", ctx, []models.ReleaseNote{note}, mockPR, []models.MessageReference(nil)).Return(nil).Once()
+	// The permanent failure won't be retried, so its claim in the ledger is released
+	mockLedgerUC.On("Release", ctx, []models.ReleaseNote{note}, mockPR, []models.MessageReference{permanent.MessageReference}).Return(nil).Once()
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(job models.DeliveryJob) bool {
+		return job.ContactType == models.Webhook && job.Status == models.DeliveryDeadLettered
+	})).Return(nil).Once()
+
+	report, err := uc.Deliver(ctx, "Subject", []models.ReleaseNote{note}, mockPR)
+	require.NoError(t, err)
+	// The transient failure is queued to be sent again so it isn't an error of the delivery
+	queued := transient
+	queued.Queued = true
+	assert.Equal(t, models.DeliveryReport{delivered, queued, permanent}, report)
+	assert.Equal(t, models.DeliveryReport{queued}, report.Queued())
+	require.Error(t, report.Err())
+	assert.NotContains(t, report.Err().Error(), "C2")
+
+	// The jobs are locked when they're written so that the worker doesn't pick them up during the first attempt
+	require.Len(t, insertedJobs, 2)
+	assert.Equal(t, models.Slack, insertedJobs[0].ContactType)
+	assert.Equal(t, models.Webhook, insertedJobs[1].ContactType)
+	assert.Equal(t, now.Add(lease), insertedJobs[0].LockedUntil)
+	assert.NotEmpty(t, insertedJobs[0].LeaseToken)
+}
+
+func TestUseCase_DeliverWithoutOutbox(t *testing.T) {
+	ctx := context.Background()
+	uc, mockRepo, mockNotesUC, mockLedgerUC := newTestUseCase(t)
+
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam}, Content: "Note"}
+	delivered := models.DeliveryResult{MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: note.Key(), Address: "C1", ID: "1"}}
+	transient := models.DeliveryResult{MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: note.Key(), Address: "C2"}, Err: errors.New("timeout"), Retryable: true}
+
+	// The notes are still sent when the outbox can't be written to, but the job isn't updated as it doesn't exist & the
+	// claims of the failures are released as they won't be retried
+	mockRepo.On("Insert", ctx, mock.Anything).Return(errors.New("no reachable servers")).Once()
+	mockNotesUC.On("SendReleaseNotes", ctx, "Subject", []models.ReleaseNote{note}, mockPR).Return(models.DeliveryReport{delivered, transient}).Once()
+	mockNotesUC.On("AddSentMessages", ctx, mockPR, []models.MessageReference{delivered.MessageReference}).Return(nil).Once()
+	mockLedgerUC.On("Record", ctx, []models.ReleaseNote{note}, mockPR, []models.MessageReference{delivered.MessageReference}).Return(nil).Once()
+	mockLedgerUC.On("Release", ctx, []models.ReleaseNote{note}, mockPR, []models.MessageReference{transient.MessageReference}).Return(nil).Once()
+
+	report, err := uc.Deliver(ctx, "Subject", []models.ReleaseNote{note}, mockPR)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryReport{delivered, transient}, report)
+	assert.Empty(t, report.Queued())
+	assert.Error(t, report.Err())
+}
+
+func TestUseCase_DeliverDue(t *testing.T) {
+	ctx := context.Background()
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam}, Content: "Note"}
+	sent := models.MessageReference{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C1", ID: "1"}
+
+	testCases := []struct {
+		name           string
+		attempts       int
+		result         models.DeliveryResult
+		expectedStatus models.DeliveryStatus
+	}{
+		{
+			name:           "Delivered",
+			attempts:       1,
+			result:         models.DeliveryResult{MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C2", ID: "2"}},
+			expectedStatus: models.DeliveryDelivered,
+		},
+		{
+			name:           "RetriedAgain",
+			attempts:       1,
+			result:         models.DeliveryResult{MessageReference: models.MessageReference{Address: "C2"}, Err: errors.New("timeout"), Retryable: true},
+			expectedStatus: models.DeliveryPending,
+		},
+		{
+			name:           "DeadLetteredAfterMaxAttempts",
+			attempts:       2,
+			result:         models.DeliveryResult{MessageReference: models.MessageReference{Address: "C2"}, Err: errors.New("timeout"), Retryable: true},
+			expectedStatus: models.DeliveryDeadLettered,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, mockRepo, mockNotesUC, mockLedgerUC := newTestUseCase(t)
+
+			job := &models.DeliveryJob{
+				PullRequest: mockPR,
+				Subject:     "Subject",
+				ContactType: models.Slack,
+				Notes:       []models.ReleaseNote{note},
+				Status:      models.DeliveryPending,
+				Attempts:    tt.attempts,
+				Messages:    []models.MessageReference{sent},
+				Failures:    []models.DeliveryFailure{{NoteKey: "infrastructure", Address: "C2", Error: "timeout", Retryable: true}},
+			}
+			mockRepo.On("ClaimNext", ctx, now, lease).Return(job, nil).Once()
+			mockRepo.On("ClaimNext", ctx, now, lease).Return(nil, nil).Once()
+
+			// Only the address that hasn't been delivered to is sent again
+			remaining := infraTeam
+			remaining.Addresses = []string{"C2"}
+			report := models.DeliveryReport{tt.result}
+			mockNotesUC.On("SendReleaseNotes", ctx, "Subject", []models.ReleaseNote{{Teams: models.Teams{remaining}, Content: "Note"}}, mockPR).Return(report).Once()
+			mockNotesUC.On("AddSentMessages", ctx, mockPR, report.References()).Return(nil).Once()
+			mockLedgerUC.On("Record", ctx, []models.ReleaseNote{note}, mockPR, report.References()).Return(nil).Once()
+			if tt.expectedStatus == models.DeliveryDeadLettered {
+				mockLedgerUC.On("Release", ctx, []models.ReleaseNote{note}, mockPR, []models.MessageReference{tt.result.MessageReference}).Return(nil).Once()
+			}
+			mockRepo.On("Update", ctx, mock.MatchedBy(func(job models.DeliveryJob) bool {
+				return job.Status == tt.expectedStatus && job.Attempts == tt.attempts+1
+			})).Return(nil).Once()
+
+			count, err := uc.DeliverDue(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
+
+func TestPendingNotes(t *testing.T) {
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam}, Content: "Note"}
+	onlyC2 := infraTeam
+	onlyC2.Addresses = []string{"C2"}
+
+	testCases := []struct {
+		name          string
+		messages      []models.MessageReference
+		failures      []models.DeliveryFailure
+		expectedNotes []models.ReleaseNote
+	}{
+		{
+			name:          "NothingDelivered",
+			expectedNotes: []models.ReleaseNote{note},
+		},
+		{
+			name:          "FirstPartDelivered",
+			messages:      []models.MessageReference{{NoteKey: note.Key(), Address: "C1"}},
+			expectedNotes: []models.ReleaseNote{{Teams: models.Teams{onlyC2}, Content: "Note"}},
+		},
+		{
+			name: "OverflowFailedTransiently",
+			messages: []models.MessageReference{
+				{NoteKey: note.Key(), Address: "C1"},
+				{NoteKey: note.Key(), Address: "C2"},
+			},
+			failures:      []models.DeliveryFailure{{NoteKey: note.Key(), Address: "C2", Retryable: true}},
+			expectedNotes: []models.ReleaseNote{{Teams: models.Teams{onlyC2}, Content: "Note"}},
+		},
+		{
+			name:     "OverflowFailedPermanently",
+			messages: []models.MessageReference{{NoteKey: note.Key(), Address: "C1"}, {NoteKey: note.Key(), Address: "C2"}},
+			failures: []models.DeliveryFailure{{NoteKey: note.Key(), Address: "C2"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			job := models.DeliveryJob{ContactType: models.Slack, Notes: []models.ReleaseNote{note}, Messages: tt.messages, Failures: tt.failures}
+			assert.Equal(t, tt.expectedNotes, pendingNotes(job))
+		})
+	}
+}
+
+func TestCompleteReferences(t *testing.T) {
+	first := models.MessageReference{NoteKey: "infrastructure", Address: "C1", ID: "1"}
+	overflow := models.MessageReference{NoteKey: "infrastructure", Address: "C1", ID: "2", Part: 1}
+	other := models.MessageReference{NoteKey: "infrastructure", Address: "C2", ID: "3"}
+
+	report := models.DeliveryReport{
+		{MessageReference: first},
+		{MessageReference: models.MessageReference{NoteKey: "infrastructure", Address: "C1", Part: 1}, Err: errors.New("timeout"), Retryable: true},
+		{MessageReference: other},
+	}
+	assert.Equal(t, []models.MessageReference{other}, completeReferences(report))
+
+	report = models.DeliveryReport{{MessageReference: first}, {MessageReference: overflow}}
+	assert.Equal(t, []models.MessageReference{first, overflow}, completeReferences(report))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spring-financial-group/peacock/pkg/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type repository struct {
@@ -24,7 +25,14 @@ func NewRepository(client mongo.Client) domain.ReleaseRepository {
 }
 
 func (r *repository) Insert(ctx context.Context, release models.Release) error {
-	_, err := r.collection.InsertOne(ctx, release)
+	// A redelivered webhook would otherwise record the same release twice, so only the first is kept
+	filter := bson.M{
+		"environment":           release.Environment,
+		"pullRequest.repoowner": release.PullRequest.RepoOwner,
+		"pullRequest.reponame":  release.PullRequest.RepoName,
+		"pullRequest.prnumber":  release.PullRequest.PRNumber,
+	}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": release}, options.Update().SetUpsert(true))
 	if err != nil {
 		return err
 	}
@@ -1,70 +1,541 @@
 package msgclients
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spring-financial-group/peacock/pkg/config"
 	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/markdown"
 	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/email"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/msteams"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
 	"github.com/spring-financial-group/peacock/pkg/msgclients/slack"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/sms"
 	"github.com/spring-financial-group/peacock/pkg/msgclients/webhook"
-	"strings"
+	"github.com/spring-financial-group/peacock/pkg/utils"
+)
+
+const (
+	// partHeaderLength is the space reserved in each part of a split message for its numbering
+	partHeaderLength = 16
+	// deliveryTimeout bounds how long sending or editing the release notes can take, including retries, when the caller
+	// hasn't set a deadline, so that an unresponsive client can't hold up the delivery indefinitely
+	deliveryTimeout = 5 * time.Minute
 )
 
 type Handler struct {
 	Clients map[string]domain.MessageClient
+
+	// policy is used by the webhook clients created for teams with their own endpoint
+	policy *retry.Policy
+	// lookupEnv resolves the token & secret references of the teams' webhook endpoints
+	lookupEnv func(key string) (string, bool)
+	// webhookFormat is the payload format of the endpoints that don't set their own
+	webhookFormat string
 }
 
-func NewMessageHandler(cfg *config.MessageHandlers) *Handler {
+func NewMessageHandler(cfg *config.MessageHandlers) (*Handler, error) {
 	clients := make(map[string]domain.MessageClient)
+	// The clients share a policy so that they all retry transient failures in the same way
+	policy := retry.NewPolicy(cfg.Delivery)
 	if cfg.Slack.Token != "" {
 		log.Info("Slack message handler initialised")
-		clients[models.Slack] = slack.NewClient(cfg.Slack.Token)
+		clients[models.Slack] = slack.NewClient(cfg.Slack.Token, policy)
 	}
 	if cfg.Webhook.URL != "" && cfg.Webhook.Secret != "" {
+		client, err := webhook.NewClient(cfg.Webhook.URL, cfg.Webhook.Token, cfg.Webhook.Secret, cfg.Webhook.Format, policy)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialise webhook message handler")
+		}
 		log.Info("Webhook message handler initialised")
-		clients[models.Webhook] = webhook.NewClient(cfg.Webhook.URL, cfg.Webhook.Token, cfg.Webhook.Secret)
+		clients[models.Webhook] = client
 	}
+	if cfg.Email.Host != "" && cfg.Email.From != "" {
+		client, err := email.NewClient(cfg.Email, policy)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialise email message handler")
+		}
+		log.Info("Email message handler initialised")
+		clients[models.Email] = client
+	}
+	if cfg.SMS.AccountSID != "" && cfg.SMS.AuthToken != "" && cfg.SMS.From != "" {
+		log.Info("SMS message handler initialised")
+		clients[models.SMS] = sms.NewClient(cfg.SMS, policy)
+	}
+	// Teams webhook URLs are stored as the addresses in the feathers, so there's nothing to configure
+	log.Info("Microsoft Teams message handler initialised")
+	clients[models.MSTeams] = msteams.NewClient(policy)
 
 	return &Handler{
-		Clients: clients,
-	}
+		Clients:       clients,
+		policy:        policy,
+		lookupEnv:     os.LookupEnv,
+		webhookFormat: cfg.Webhook.Format,
+	}, nil
 }
 
-func (h *Handler) SendReleaseNotes(subject string, notes []models.ReleaseNote) error {
-	var errCount int
+func (h *Handler) SendReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) models.DeliveryReport {
+	ctx, cancel := withDeliveryDeadline(ctx)
+	defer cancel()
+
+	var report models.DeliveryReport
 	for _, n := range notes {
-		err := h.sendNote(n, subject)
+		report = append(report, h.sendNote(ctx, subject, n, pr)...)
+	}
+	report = append(report, h.sendThreads(ctx, models.Message{Subject: subject, PullRequest: pr}, notes)...)
+
+	for _, result := range report {
+		if result.Delivered() {
+			log.Infof("Successfully sent %s to %s via %s", result.Label(), result.Address, result.ContactType)
+			continue
+		}
+		log.Errorf("Failed to send %s to %s via %s: %s", result.Label(), result.Address, result.ContactType, result.Err)
+	}
+	return report
+}
+
+func (h *Handler) sendNote(ctx context.Context, subject string, note models.ReleaseNote, pr models.PullRequestSummary) []models.DeliveryResult {
+	// Threaded teams are sent separately as all the notes in the release are grouped under a single parent message
+	teams := note.Teams.FilterChannels(func(c models.Channel) bool { return !c.IsThreaded() })
+
+	// Teams can override how the note is presented, so the note is rendered once for each group of teams that share the
+	// same presentation rather than once for all the teams
+	var results []models.DeliveryResult
+	for _, group := range groupByPresentation(subject, teams) {
+		msg := newMessage(group.messages, note, pr)
+
+		// We should pool the addresses by contact type so that we only send one note per contact type. Webhook teams
+		// with their own endpoint are sent separately.
+		addressPool := group.teams.FilterChannels(func(c models.Channel) bool { return c.Webhook == nil }).GetAddressPool()
+		for contactType, addresses := range addressPool {
+			if contactType == models.None {
+				continue
+			}
+
+			// A job replayed from the outbox may use a contact type whose client is no longer configured, it fails
+			// without being retried rather than being sent with a nil client
+			client, ok := h.Clients[contactType]
+			var sent []models.DeliveryResult
+			if !ok || client == nil {
+				sent = failedResults(addresses, errors.Errorf("%s message client is not configured", contactType))
+			} else {
+				sent = sendParts(ctx, client, splitMessage(msg, client), addresses)
+			}
+			for _, result := range sent {
+				result.ContactType = contactType
+				result.NoteKey = note.Key()
+				results = append(results, result)
+			}
+		}
+
+		for _, result := range h.sendToEndpoints(ctx, msg, group.teams) {
+			result.ContactType = models.Webhook
+			result.NoteKey = note.Key()
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// presentationGroup is a group of teams that are sent a release note in the same way
+type presentationGroup struct {
+	messages models.Messages
+	teams    models.Teams
+}
+
+// groupByPresentation groups the teams by the subject & options of their messages, keeping the order of the teams. The
+// subject is used for the teams that don't override it. An address shared by teams in different groups is only kept in
+// the first of them, so that the note isn't sent to it once for each way it's presented.
+func groupByPresentation(subject string, teams models.Teams) []presentationGroup {
+	type presentation struct {
+		subject      string
+		showMetadata bool
+	}
+	var groups []presentationGroup
+	indexes := make(map[presentation]int)
+	for _, team := range teams {
+		messages := teamMessages(subject, team)
+		key := presentation{messages.Subject, messages.ShowMetadata()}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, presentationGroup{messages: messages})
+		}
+		groups[i].teams = append(groups[i].teams, team)
+	}
+
+	type destination struct {
+		contactType, endpoint, address string
+	}
+	claimed := make(map[destination]int)
+	for i := range groups {
+		note := models.ReleaseNote{Teams: groups[i].teams}
+		groups[i].teams = note.FilterAddresses(func(c models.Channel, address string) bool {
+			var endpoint string
+			if c.Webhook != nil {
+				endpoint = c.Webhook.URL
+			}
+			key := destination{c.ContactType, endpoint, address}
+			group, ok := claimed[key]
+			if !ok {
+				claimed[key] = i
+				return true
+			}
+			return group == i
+		}).Teams
+	}
+	return groups
+}
+
+// teamMessages returns the messages config for the team, the subject is used unless the team overrides it
+func teamMessages(subject string, team models.Team) models.Messages {
+	return models.Messages{Subject: subject}.Override(team.Messages)
+}
+
+// sendToEndpoints posts the note to the endpoints of the webhook teams that have their own, once per endpoint with the
+// addresses of all the teams that share it
+func (h *Handler) sendToEndpoints(ctx context.Context, msg models.Message, teams models.Teams) []models.DeliveryResult {
+	var endpoints []models.WebhookEndpoint
+	addressesByEndpoint := make(map[models.WebhookEndpoint][]string)
+	for _, team := range teams {
+		for _, c := range team.GetChannels() {
+			if c.Webhook == nil || c.ContactType != models.Webhook {
+				continue
+			}
+			endpoint := *c.Webhook
+			if _, ok := addressesByEndpoint[endpoint]; !ok {
+				endpoints = append(endpoints, endpoint)
+			}
+			addressesByEndpoint[endpoint] = append(addressesByEndpoint[endpoint], c.Addresses...)
+		}
+	}
+
+	var results []models.DeliveryResult
+	for _, endpoint := range endpoints {
+		addresses := addressesByEndpoint[endpoint]
+		client, err := h.endpointClient(endpoint)
 		if err != nil {
-			log.Error(err)
-			errCount++
+			results = append(results, failedResults(addresses, err)...)
 			continue
 		}
+		results = append(results, sendParts(ctx, client, splitMessage(msg, client), addresses)...)
 	}
-	if errCount > 0 {
-		return errors.New("failed to send release notes")
+	return results
+}
+
+// failedResults returns a result for each address that failed with the error, the failures aren't retryable
+func failedResults(addresses []string, err error) []models.DeliveryResult {
+	results := make([]models.DeliveryResult, len(addresses))
+	for i, address := range addresses {
+		results[i] = models.DeliveryResult{MessageReference: models.MessageReference{Address: address}, Err: err}
+	}
+	return results
+}
+
+// endpointClient creates a webhook client for an endpoint, resolving its token & secret from the environment
+func (h *Handler) endpointClient(endpoint models.WebhookEndpoint) (*webhook.Client, error) {
+	lookupEnv := h.lookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	// The feathers are validated before they're used, but the refs are checked again as they name the environment
+	for _, ref := range []string{endpoint.SecretRef, endpoint.TokenRef} {
+		if ref != "" && !strings.HasPrefix(ref, models.WebhookEnvPrefix) {
+			return nil, errors.Errorf("environment variable %s for webhook %s doesn't start with %s", ref, endpoint.URL, models.WebhookEnvPrefix)
+		}
+	}
+	secret, ok := lookupEnv(endpoint.SecretRef)
+	if !ok || secret == "" {
+		return nil, errors.Errorf("secret %s for webhook %s is not set", endpoint.SecretRef, endpoint.URL)
+	}
+	var token string
+	if endpoint.TokenRef != "" {
+		if token, ok = lookupEnv(endpoint.TokenRef); !ok || token == "" {
+			return nil, errors.Errorf("token %s for webhook %s is not set", endpoint.TokenRef, endpoint.URL)
+		}
+	}
+	format := endpoint.Format
+	if format == "" {
+		format = h.webhookFormat
+	}
+	return webhook.NewClient(endpoint.URL, token, secret, format, h.policy)
+}
+
+// newMessage creates the message for a release note presented as set in the messages config
+func newMessage(messages models.Messages, note models.ReleaseNote, pr models.PullRequestSummary) models.Message {
+	return models.Message{
+		Subject:      renderSubject(messages.Subject, pr, note.Teams.GetAllTeamNames()),
+		Content:      note.Content,
+		PullRequest:  pr,
+		NoteHash:     note.Hash(),
+		Teams:        note.Teams.GetAllTeamNames(),
+		Mentions:     note.Teams.GetMentionPool(),
+		HideMetadata: !messages.ShowMetadata(),
+	}
+}
+
+// renderSubject fills in the placeholders of the subject for the teams. The subject is rendered here so that it's the
+// same whether the release notes are sent by the CLI or the service. If it can't be rendered then it's used as it is.
+func renderSubject(subject string, pr models.PullRequestSummary, teams []string) string {
+	rendered, err := models.Messages{Subject: subject}.RenderSubject(models.NewMessageTemplateData(pr, teams))
+	if err != nil {
+		log.Errorf("failed to render subject %q: %s", subject, err)
+		return subject
+	}
+	return rendered
+}
+
+// sendParts sends the parts of a split message. Threaded clients post the overflow as replies to the first part, other
+// clients send each part as its own message. The overflow is only sent to the addresses that received the first part.
+func sendParts(ctx context.Context, client domain.MessageClient, parts []models.Message, addresses []string) []models.DeliveryResult {
+	results := client.Send(ctx, parts[0], addresses)
+	if len(parts) == 1 {
+		return results
+	}
+
+	var delivered []models.DeliveryResult
+	var deliveredAddresses []string
+	for _, result := range results {
+		if result.Delivered() {
+			delivered = append(delivered, result)
+			deliveredAddresses = append(deliveredAddresses, result.Address)
+		}
+	}
+	if len(delivered) == 0 {
+		return results
+	}
+
+	threaded, isThreaded := client.(domain.ThreadedMessageClient)
+	for i, part := range parts[1:] {
+		var overflow []models.DeliveryResult
+		if isThreaded {
+			for _, first := range delivered {
+				overflow = append(overflow, threaded.Reply(ctx, part, first.MessageReference))
+			}
+		} else {
+			overflow = client.Send(ctx, part, deliveredAddresses)
+		}
+		for _, result := range overflow {
+			result.Part = i + 1
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// splitMessage splits a message with content longer than the client's limit, or that the client can't otherwise send in
+// one message, into numbered parts. Mentions are only kept on the first part so that people aren't notified for every
+// part.
+func splitMessage(msg models.Message, client domain.MessageClient) []models.Message {
+	chunks := []string{msg.Content}
+	if limit := client.MaxContentLength(); limit > 0 && len(msg.Content) > limit {
+		chunks = markdown.SplitBlocks(msg.Content, limit-partHeaderLength)
+	}
+	if splitter, ok := client.(domain.ContentSplitter); ok {
+		var split []string
+		for _, chunk := range chunks {
+			split = append(split, splitter.SplitContent(chunk)...)
+		}
+		chunks = split
+	}
+	if len(chunks) == 1 {
+		return []models.Message{msg}
+	}
+
+	parts := make([]models.Message, len(chunks))
+	for i, chunk := range chunks {
+		parts[i] = msg
+		parts[i].Content = fmt.Sprintf("**(%d/%d)**\n\n%s", i+1, len(chunks), chunk)
+		if i > 0 {
+			parts[i].Mentions = nil
+		}
+	}
+	return parts
+}
+
+// sendThreads posts the release notes for threaded teams as replies under one parent message per address
+func (h *Handler) sendThreads(ctx context.Context, parent models.Message, notes []models.ReleaseNote) []models.DeliveryResult {
+	// Collect the notes for each address, a note should only be sent once to an address even if multiple teams share it.
+	// The thread at each address is presented as set for the first team with the address.
+	var addresses []string
+	notesByAddress := make(map[string][]models.ReleaseNote)
+	messagesByAddress := make(map[string]models.Messages)
+	for _, note := range notes {
+		threadedTeams := note.Teams.FilterChannels(models.Channel.IsThreaded)
+		for _, team := range threadedTeams {
+			for _, c := range team.GetChannels() {
+				for _, address := range c.Addresses {
+					if _, ok := messagesByAddress[address]; !ok {
+						messagesByAddress[address] = teamMessages(parent.Subject, team)
+					}
+				}
+			}
+		}
+		for _, address := range threadedTeams.GetAddressPool()[models.Slack] {
+			existing, ok := notesByAddress[address]
+			if !ok {
+				addresses = append(addresses, address)
+			}
+			if len(existing) > 0 && existing[len(existing)-1].Key() == note.Key() {
+				continue
+			}
+			notesByAddress[address] = append(existing, note)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	client, ok := h.Clients[models.Slack].(domain.ThreadedMessageClient)
+	if !ok {
+		results := make([]models.DeliveryResult, len(addresses))
+		for i, address := range addresses {
+			results[i] = models.DeliveryResult{
+				MessageReference: models.MessageReference{ContactType: models.Slack, Address: address},
+				Err:              errors.New("slack message client does not support threads"),
+			}
+		}
+		return results
+	}
+
+	var results []models.DeliveryResult
+	for _, address := range addresses {
+		messages := messagesByAddress[address]
+		// Long notes are split into several replies, so we keep track of which note & part each reply is
+		var replies []models.Message
+		var replyRefs []models.MessageReference
+		var teams []string
+		for _, n := range notesByAddress[address] {
+			for _, name := range n.Teams.GetAllTeamNames() {
+				if !utils.ExistsInSlice(name, teams) {
+					teams = append(teams, name)
+				}
+			}
+			for i, part := range splitMessage(newMessage(messages, n, parent.PullRequest), client) {
+				replies = append(replies, part)
+				replyRefs = append(replyRefs, models.MessageReference{NoteKey: n.Key(), Part: i})
+			}
+		}
+
+		// The parent is for all the notes in the thread
+		threadParent := parent
+		threadParent.Subject = renderSubject(messages.Subject, parent.PullRequest, teams)
+		threadParent.HideMetadata = !messages.ShowMetadata()
+		for i, result := range client.SendThread(ctx, threadParent, replies, []string{address}) {
+			result.ContactType = models.Slack
+			// The parent is always the first result, followed by the replies in order
+			if i > 0 {
+				result.NoteKey = replyRefs[i-1].NoteKey
+				result.Part = replyRefs[i-1].Part
+			}
+			results = append(results, result)
+		}
 	}
-	return nil
+	return results
 }
 
-func (h *Handler) sendNote(note models.ReleaseNote, subject string) error {
-	// We should pool the addresses by contact type so that we only send one note per contact type
-	addressPool := note.Teams.GetAddressPool()
-	for contactType, addresses := range addressPool {
-		if contactType == models.None {
+func (h *Handler) UpdateReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, refs []models.MessageReference, pr models.PullRequestSummary) ([]models.MessageReference, error) {
+	ctx, cancel := withDeliveryDeadline(ctx)
+	defer cancel()
+
+	notesByKey := make(map[string]models.ReleaseNote, len(notes))
+	for _, n := range notes {
+		notesByKey[n.Key()] = n
+	}
+
+	// Count the parts each note was sent as so that we know if a note no longer fits in them
+	type noteMessage struct{ contactType, noteKey, address string }
+	sentParts := make(map[noteMessage]int)
+	for _, ref := range refs {
+		sentParts[noteMessage{ref.ContactType, ref.NoteKey, ref.Address}]++
+	}
+
+	// Replies are edited before other messages so that a message isn't deleted before the replies in its thread
+	refs = append([]models.MessageReference(nil), refs...)
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].ThreadID != "" && refs[j].ThreadID == ""
+	})
+
+	var remaining []models.MessageReference
+	var errCount int
+	for _, ref := range refs {
+		client, ok := h.Clients[ref.ContactType].(domain.EditableMessageClient)
+		if !ok {
+			log.Warnf("%s messages can't be edited once sent, skipping message in %s", ref.ContactType, ref.Address)
+			remaining = append(remaining, ref)
 			continue
 		}
 
-		err := h.Clients[contactType].Send(note.Content, subject, addresses)
-		if err != nil {
-			return errors.Wrapf(err, "failed to send note")
+		var parts []models.Message
+		note, ok := notesByKey[ref.NoteKey]
+		if ok {
+			parts = splitMessage(newMessage(teamMessages(subject, addressTeam(note, ref)), note, pr), client)
+		}
+
+		// The message is deleted if its note has been removed or the note is now shorter & doesn't need this part
+		if ref.Part >= len(parts) {
+			if err := client.Delete(ctx, ref); err != nil {
+				log.Error(errors.Wrapf(err, "failed to delete message in %s", ref.Address))
+				remaining = append(remaining, ref)
+				errCount++
+				continue
+			}
+			log.Infof("Release note message deleted from %s via %s", ref.Address, ref.ContactType)
+			continue
+		}
+
+		if n := sentParts[noteMessage{ref.ContactType, ref.NoteKey, ref.Address}]; ref.Part == n-1 && len(parts) > n {
+			log.Warnf("release note for %s is now longer than the %d message(s) it was sent as in %s, the end will be cut off", ref.NoteKey, n, ref.Address)
+		}
+
+		remaining = append(remaining, ref)
+		if err := client.Update(ctx, parts[ref.Part], ref); err != nil {
+			log.Error(errors.Wrapf(err, "failed to update message in %s", ref.Address))
+			errCount++
+			continue
+		}
+		log.Infof("Release note message updated in %s via %s", ref.Address, ref.ContactType)
+	}
+
+	if errCount > 0 {
+		return remaining, errors.Errorf("failed to edit %d message(s)", errCount)
+	}
+	return remaining, nil
+}
+
+// addressTeam returns the team of the note that the message was sent to, so that the message is edited with the team's
+// presentation. If no team has the address then an empty team is returned.
+func addressTeam(note models.ReleaseNote, ref models.MessageReference) models.Team {
+	for _, team := range note.Teams {
+		if team.HasChannel(ref.ContactType, ref.Address) {
+			return team
 		}
-		log.Infof("Release note successfully sent to %s via %s", strings.Join(addresses, ", "), contactType)
 	}
-	return nil
+	return models.Team{}
 }
 
-func (h *Handler) IsInitialised(contactType string) bool {
-	_, ok := h.Clients[contactType]
-	return ok || contactType == models.None
+// withDeliveryDeadline applies the delivery timeout to the context unless it already has a deadline
+func withDeliveryDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, deliveryTimeout)
+}
+
+func (h *Handler) IsInitialised(channel models.Channel) bool {
+	// Channels with their own endpoint don't need the global webhook to be configured
+	if channel.ContactType == models.Webhook && channel.Webhook != nil {
+		return true
+	}
+	_, ok := h.Clients[channel.ContactType]
+	return ok || channel.ContactType == models.None
 }
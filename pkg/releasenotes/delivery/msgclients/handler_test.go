@@ -1,10 +1,21 @@
 package msgclients
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/spring-financial-group/peacock/pkg/config"
 	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/spring-financial-group/peacock/pkg/domain/mocks"
 	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/msgclients/retry"
+	"github.com/spring-financial-group/peacock/pkg/utils/http_utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"testing"
 )
 
@@ -41,9 +52,20 @@ var (
 		productTeam,
 		testingTeam,
 	}
+	// withDeadline matches the context passed to the clients, which should always have a deadline
+	withDeadline = mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	mockPR = models.PullRequestSummary{
+		PRNumber:  1,
+		RepoOwner: "spring-financial-group",
+		RepoName:  "peacock",
+	}
 )
 
 func TestHandler_SendMessage(t *testing.T) {
+	ctx := context.Background()
 	slack := mocks.NewMessageClient(t)
 	webhook := mocks.NewMessageClient(t)
 
@@ -51,10 +73,14 @@ func TestHandler_SendMessage(t *testing.T) {
 		models.Slack:   slack,
 		models.Webhook: webhook,
 	}}
+	slack.On("MaxContentLength").Return(4000)
+	webhook.On("MaxContentLength").Return(0)
 
 	testCases := []struct {
-		name         string
-		inputMessage models.ReleaseNote
+		name            string
+		subject         string
+		inputMessage    models.ReleaseNote
+		expectedSubject string
 	}{
 		{
 			name: "Default",
@@ -62,6 +88,7 @@ func TestHandler_SendMessage(t *testing.T) {
 				Teams:   allTeams,
 				Content: "Test message content",
 			},
+			expectedSubject: "New Release Notes for peacock",
 		},
 		{
 			name: "NoneTeam",
@@ -69,16 +96,547 @@ func TestHandler_SendMessage(t *testing.T) {
 				Teams:   allTeams,
 				Content: "Test message content",
 			},
+			expectedSubject: "New Release Notes for peacock",
+		},
+		{
+			name:    "SubjectPlaceholders",
+			subject: "{{ .Repo }} PR-{{ .PRNumber }} for {{ join .Teams \", \" }}",
+			inputMessage: models.ReleaseNote{
+				Teams:   models.Teams{infraTeam, supportTeam},
+				Content: "Test message content",
+			},
+			expectedSubject: "peacock PR-1 for infrastructure, support",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			slack.On("Send", tc.inputMessage.Content, "", []string{"#SlackAdd1", "#SlackAdd2", "#SlackAdd3", "#SlackAdd4"}).Return(nil)
-			webhook.On("Send", tc.inputMessage.Content, "", []string{"Webhook1", "Webhook2", "Webhook3", "Webhook4"}).Return(nil)
+			expectedMsg := models.Message{Subject: tc.expectedSubject, Content: tc.inputMessage.Content, PullRequest: mockPR, NoteHash: tc.inputMessage.Hash(), Teams: tc.inputMessage.Teams.GetAllTeamNames()}
+			slackAddresses := tc.inputMessage.Teams.GetAddressPool()[models.Slack]
+			webhookAddresses := tc.inputMessage.Teams.GetAddressPool()[models.Webhook]
+			slack.On("Send", withDeadline, expectedMsg, slackAddresses).Return(nil)
+			webhook.On("Send", withDeadline, expectedMsg, webhookAddresses).Return(nil)
+
+			report := handler.SendReleaseNotes(ctx, tc.subject, []models.ReleaseNote{tc.inputMessage}, mockPR)
+			assert.NoError(t, report.Err())
+		})
+	}
+}
+
+func TestHandler_SendThreads(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewThreadedMessageClient(t)
+	webhook := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack:   slack,
+		models.Webhook: webhook,
+	}}
+
+	slack.On("MaxContentLength").Return(4000)
+	webhook.On("MaxContentLength").Return(0)
+
+	threadedInfra := infraTeam
+	threadedInfra.DeliveryMode = models.ThreadDelivery
+	threadedDevs := devsTeam
+	threadedDevs.DeliveryMode = models.ThreadDelivery
+	threadedDevs.Addresses = []string{"#SlackAdd1"}
+
+	notes := []models.ReleaseNote{
+		{Teams: models.Teams{threadedInfra, supportTeam}, Content: "Infra content"},
+		{Teams: models.Teams{threadedDevs}, Content: "Devs content"},
+	}
+
+	infraMsg := models.Message{Subject: "Release", Content: "Infra content", PullRequest: mockPR, NoteHash: notes[0].Hash(), Teams: notes[0].Teams.GetAllTeamNames()}
+	devsMsg := models.Message{Subject: "Release", Content: "Devs content", PullRequest: mockPR, NoteHash: notes[1].Hash(), Teams: notes[1].Teams.GetAllTeamNames()}
+	parent := models.Message{Subject: "Release", PullRequest: mockPR}
+
+	webhook.On("Send", withDeadline, infraMsg, []string{"Webhook1", "Webhook2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "Webhook1"}},
+		{MessageReference: models.MessageReference{Address: "Webhook2"}},
+	})
+	slack.On("SendThread", withDeadline, parent, []models.Message{infraMsg, devsMsg}, []string{"#SlackAdd1"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "2", ThreadID: "1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "3", ThreadID: "1"}},
+	})
+	slack.On("SendThread", withDeadline, parent, []models.Message{infraMsg}, []string{"#SlackAdd2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2", ID: "4"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2", ID: "5", ThreadID: "4"}},
+	})
+
+	expectedRefs := []models.MessageReference{
+		{ContactType: models.Webhook, NoteKey: "infrastructure, support", Address: "Webhook1"},
+		{ContactType: models.Webhook, NoteKey: "infrastructure, support", Address: "Webhook2"},
+		{ContactType: models.Slack, Address: "#SlackAdd1", ID: "1"},
+		{ContactType: models.Slack, NoteKey: "infrastructure, support", Address: "#SlackAdd1", ID: "2", ThreadID: "1"},
+		{ContactType: models.Slack, NoteKey: "devs", Address: "#SlackAdd1", ID: "3", ThreadID: "1"},
+		{ContactType: models.Slack, Address: "#SlackAdd2", ID: "4"},
+		{ContactType: models.Slack, NoteKey: "infrastructure, support", Address: "#SlackAdd2", ID: "5", ThreadID: "4"},
+	}
+
+	report := handler.SendReleaseNotes(ctx, "Release", notes, mockPR)
+	assert.NoError(t, report.Err())
+	assert.Equal(t, expectedRefs, report.References())
+}
+
+func TestHandler_SendTeamOverrides(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewMessageClient(t)
+	webhook := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack:   slack,
+		models.Webhook: webhook,
+	}}
+	slack.On("MaxContentLength").Return(4000)
+	webhook.On("MaxContentLength").Return(0)
+
+	// The business team wants a formal subject without the links back to GitHub, the other teams use the defaults
+	hideMetadata := false
+	businessTeam := devsTeam
+	businessTeam.Name = "business"
+	businessTeam.Messages = &models.Messages{Subject: "{{ .Repo }} has been released", Metadata: &hideMetadata}
+
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam, businessTeam, supportTeam}, Content: "Test message content"}
+	defaultMsg := models.Message{Subject: "Release", Content: "Test message content", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+	businessMsg := defaultMsg
+	businessMsg.Subject = "peacock has been released"
+	businessMsg.HideMetadata = true
+
+	slack.On("Send", withDeadline, defaultMsg, []string{"#SlackAdd1", "#SlackAdd2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2"}},
+	}).Once()
+	slack.On("Send", withDeadline, businessMsg, []string{"#SlackAdd3", "#SlackAdd4"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd3"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd4"}},
+	}).Once()
+	webhook.On("Send", withDeadline, defaultMsg, []string{"Webhook1", "Webhook2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "Webhook1"}},
+		{MessageReference: models.MessageReference{Address: "Webhook2"}},
+	}).Once()
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.NoError(t, report.Err())
+	assert.Len(t, report.References(), 6)
+}
+
+func TestHandler_SendSharedAddressWithOverrides(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack: slack,
+	}}
+	slack.On("MaxContentLength").Return(4000)
+
+	// The business team shares a channel with the infra team, it's only sent the note as the infra team is presented it
+	businessTeam := models.Team{
+		Name:        "business",
+		ContactType: models.Slack,
+		Addresses:   []string{"#SlackAdd2", "#SlackAdd3"},
+		Messages:    &models.Messages{Subject: "{{ .Repo }} has been released"},
+	}
+
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam, businessTeam}, Content: "Test message content"}
+	defaultMsg := models.Message{Subject: "Release", Content: "Test message content", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+	businessMsg := defaultMsg
+	businessMsg.Subject = "peacock has been released"
+
+	slack.On("Send", withDeadline, defaultMsg, []string{"#SlackAdd1", "#SlackAdd2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2"}},
+	}).Once()
+	slack.On("Send", withDeadline, businessMsg, []string{"#SlackAdd3"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd3"}},
+	}).Once()
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.NoError(t, report.Err())
+	assert.Len(t, report.References(), 3)
+}
+
+func TestHandler_SendToChannels(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewThreadedMessageClient(t)
+	email := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack: slack,
+		models.Email: email,
+	}}
+	slack.On("MaxContentLength").Return(4000)
+	email.On("MaxContentLength").Return(0)
+
+	// QA are sent their notes in a Slack thread & by email, alongside the infrastructure team's channel
+	qaTeam := models.Team{Name: "qa", Channels: []models.Channel{
+		{ContactType: models.Slack, Addresses: []string{"#QA"}, DeliveryMode: models.ThreadDelivery},
+		{ContactType: models.Email, Addresses: []string{"qa@example.com"}},
+	}}
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam, qaTeam}, Content: "Test message content"}
+	expectedMsg := models.Message{Subject: "Release", Content: "Test message content", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+
+	slack.On("Send", withDeadline, expectedMsg, []string{"#SlackAdd1", "#SlackAdd2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2"}},
+	}).Once()
+	email.On("Send", withDeadline, expectedMsg, []string{"qa@example.com"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "qa@example.com"}},
+	}).Once()
+	slack.On("SendThread", withDeadline, models.Message{Subject: "Release", PullRequest: mockPR}, []models.Message{expectedMsg}, []string{"#QA"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#QA", ID: "1"}},
+		{MessageReference: models.MessageReference{Address: "#QA", ID: "2", ThreadID: "1"}},
+	}).Once()
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.NoError(t, report.Err())
+	assert.ElementsMatch(t, []models.MessageReference{
+		{ContactType: models.Slack, NoteKey: "infrastructure, qa", Address: "#SlackAdd1"},
+		{ContactType: models.Slack, NoteKey: "infrastructure, qa", Address: "#SlackAdd2"},
+		{ContactType: models.Email, NoteKey: "infrastructure, qa", Address: "qa@example.com"},
+		{ContactType: models.Slack, Address: "#QA", ID: "1"},
+		{ContactType: models.Slack, NoteKey: "infrastructure, qa", Address: "#QA", ID: "2", ThreadID: "1"},
+	}, report.References())
+}
+
+func TestHandler_SendMentions(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack: slack,
+	}}
+
+	slack.On("MaxContentLength").Return(4000)
+
+	onCallInfra := infraTeam
+	onCallInfra.Mentions = []string{"S012ABCDEF"}
+
+	note := models.ReleaseNote{Teams: models.Teams{onCallInfra, devsTeam}, Content: "Urgent content"}
+	expectedMsg := models.Message{
+		Subject:     "Release",
+		Content:     "Urgent content",
+		PullRequest: mockPR,
+		NoteHash:    note.Hash(),
+		Teams:       note.Teams.GetAllTeamNames(),
+		Mentions: map[string][]string{
+			"#SlackAdd1": {"S012ABCDEF"},
+			"#SlackAdd2": {"S012ABCDEF"},
+		},
+	}
+	slack.On("Send", withDeadline, expectedMsg, []string{"#SlackAdd1", "#SlackAdd2", "#SlackAdd3", "#SlackAdd4"}).Return(nil)
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.NoError(t, report.Err())
+}
+
+func TestHandler_SendLongNote(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewThreadedMessageClient(t)
+	webhook := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack:   slack,
+		models.Webhook: webhook,
+	}}
+	slack.On("MaxContentLength").Return(50)
+	webhook.On("MaxContentLength").Return(50)
+
+	onCallInfra := infraTeam
+	onCallInfra.Addresses = []string{"#SlackAdd1"}
+	onCallInfra.Mentions = []string{"S012ABCDEF"}
+	product := productTeam
+	product.Addresses = []string{"Webhook1"}
+
+	note := models.ReleaseNote{
+		Teams:   models.Teams{onCallInfra, product},
+		Content: "First paragraph of the note\n\nSecond paragraph of the note",
+	}
+	mentions := map[string][]string{"#SlackAdd1": {"S012ABCDEF"}}
+	firstPart := models.Message{Subject: "Release", Content: "**(1/2)**\n\nFirst paragraph of the note", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames(), Mentions: mentions}
+	secondPart := models.Message{Subject: "Release", Content: "**(2/2)**\n\nSecond paragraph of the note", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+
+	// Slack posts the overflow in the thread of the first part
+	firstRef := models.MessageReference{Address: "#SlackAdd1", ID: "1"}
+	slack.On("Send", withDeadline, firstPart, []string{"#SlackAdd1"}).Return([]models.DeliveryResult{{MessageReference: firstRef}}).Once()
+	slack.On("Reply", withDeadline, secondPart, firstRef).Return(models.DeliveryResult{
+		MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "2", ThreadID: "1"},
+	}).Once()
+
+	// Other clients send each part as a separate message
+	webhook.On("Send", withDeadline, firstPart, []string{"Webhook1"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "Webhook1"}},
+	}).Once()
+	webhook.On("Send", withDeadline, secondPart, []string{"Webhook1"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "Webhook1"}},
+	}).Once()
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.NoError(t, report.Err())
+	assert.ElementsMatch(t, []models.MessageReference{
+		{ContactType: models.Slack, NoteKey: "infrastructure, product", Address: "#SlackAdd1", ID: "1"},
+		{ContactType: models.Slack, NoteKey: "infrastructure, product", Address: "#SlackAdd1", ID: "2", ThreadID: "1", Part: 1},
+		{ContactType: models.Webhook, NoteKey: "infrastructure, product", Address: "Webhook1"},
+		{ContactType: models.Webhook, NoteKey: "infrastructure, product", Address: "Webhook1", Part: 1},
+	}, report.References())
+}
+
+// splittingClient is a threaded client that also limits its content other than by length, e.g. Slack's limit on blocks
+type splittingClient struct {
+	*mocks.ThreadedMessageClient
+	split func(content string) []string
+}
+
+func (c *splittingClient) SplitContent(content string) []string {
+	return c.split(content)
+}
+
+func TestHandler_SendSplitContent(t *testing.T) {
+	ctx := context.Background()
+	slack := &splittingClient{
+		ThreadedMessageClient: mocks.NewThreadedMessageClient(t),
+		split: func(content string) []string {
+			return strings.Split(content, "\n\n")
+		},
+	}
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack: slack,
+	}}
+	slack.On("MaxContentLength").Return(4000)
+
+	team := infraTeam
+	team.Addresses = []string{"#SlackAdd1"}
+	note := models.ReleaseNote{
+		Teams:   models.Teams{team},
+		Content: "First paragraph\n\nSecond paragraph",
+	}
+	firstPart := models.Message{Subject: "Release", Content: "**(1/2)**\n\nFirst paragraph", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+	secondPart := models.Message{Subject: "Release", Content: "**(2/2)**\n\nSecond paragraph", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+
+	// The overflow is posted in the thread of the first part even though the content is within the length limit
+	firstRef := models.MessageReference{Address: "#SlackAdd1", ID: "1"}
+	slack.On("Send", withDeadline, firstPart, []string{"#SlackAdd1"}).Return([]models.DeliveryResult{{MessageReference: firstRef}}).Once()
+	slack.On("Reply", withDeadline, secondPart, firstRef).Return(models.DeliveryResult{
+		MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "2", ThreadID: "1"},
+	}).Once()
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.NoError(t, report.Err())
+	assert.ElementsMatch(t, []models.MessageReference{
+		{ContactType: models.Slack, NoteKey: "infrastructure", Address: "#SlackAdd1", ID: "1"},
+		{ContactType: models.Slack, NoteKey: "infrastructure", Address: "#SlackAdd1", ID: "2", ThreadID: "1", Part: 1},
+	}, report.References())
+}
+
+func TestHandler_SendPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewMessageClient(t)
+	webhook := mocks.NewMessageClient(t)
+
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack:   slack,
+		models.Webhook: webhook,
+	}}
+	slack.On("MaxContentLength").Return(4000)
+	webhook.On("MaxContentLength").Return(0)
+
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam, supportTeam}, Content: "Test message content"}
+	expectedMsg := models.Message{Subject: "Release", Content: "Test message content", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+
+	// One failed address shouldn't stop the note being delivered to the others
+	slack.On("Send", withDeadline, expectedMsg, []string{"#SlackAdd1", "#SlackAdd2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2"}, Err: errors.New("channel_not_found")},
+	})
+	webhook.On("Send", withDeadline, expectedMsg, []string{"Webhook1", "Webhook2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "Webhook1"}, Err: errors.New("Status code indicated failure 503"), Retryable: true},
+		{MessageReference: models.MessageReference{Address: "Webhook2"}},
+	})
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.ElementsMatch(t, []models.MessageReference{
+		{ContactType: models.Slack, NoteKey: "infrastructure, support", Address: "#SlackAdd1", ID: "1"},
+		{ContactType: models.Webhook, NoteKey: "infrastructure, support", Address: "Webhook2"},
+	}, report.References())
+
+	failures := report.Failures()
+	assert.Len(t, failures, 2)
+	err := report.Err()
+	assert.ErrorContains(t, err, "failed to deliver 2 of 4 message(s)")
+	assert.ErrorContains(t, err, "- release note for infrastructure, support to #SlackAdd2 via slack: channel_not_found")
+	assert.ErrorContains(t, err, "- release note for infrastructure, support to Webhook1 via webhook: Status code indicated failure 503 (retryable)")
+}
+
+func TestHandler_SendUnconfiguredClient(t *testing.T) {
+	ctx := context.Background()
+	slack := mocks.NewMessageClient(t)
+
+	// A replayed job can name a contact type whose client has since been removed from the config
+	handler := &Handler{Clients: map[string]domain.MessageClient{
+		models.Slack:   slack,
+		models.Webhook: nil,
+	}}
+	slack.On("MaxContentLength").Return(4000)
+
+	note := models.ReleaseNote{Teams: models.Teams{infraTeam, supportTeam}, Content: "Test message content"}
+	expectedMsg := models.Message{Subject: "Release", Content: "Test message content", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}
+	slack.On("Send", withDeadline, expectedMsg, []string{"#SlackAdd1", "#SlackAdd2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "#SlackAdd1", ID: "1"}},
+		{MessageReference: models.MessageReference{Address: "#SlackAdd2", ID: "2"}},
+	})
+
+	for _, clients := range []map[string]domain.MessageClient{handler.Clients, {models.Slack: slack}} {
+		handler.Clients = clients
+		report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+		assert.Len(t, report.References(), 2)
+
+		failures := report.Failures()
+		assert.Len(t, failures, 2)
+		for _, failure := range failures {
+			assert.False(t, failure.Retryable)
+		}
+		assert.ErrorContains(t, report.Err(), "- release note for infrastructure, support to Webhook1 via webhook: webhook message client is not configured")
+	}
+}
+
+func TestHandler_SendToTeamEndpoints(t *testing.T) {
+	ctx := context.Background()
+	webhook := mocks.NewMessageClient(t)
+
+	// Each endpoint records the addresses it was sent & the signature of the request
+	type received struct {
+		addresses []string
+		signature string
+	}
+	newEndpoint := func(requests *[]received) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Addresses []string `json:"addresses"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			*requests = append(*requests, received{body.Addresses, r.Header.Get(http_utils.SignatureHeader)})
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	var paymentsRequests, billingRequests []received
+	paymentsServer := newEndpoint(&paymentsRequests)
+	billingServer := newEndpoint(&billingRequests)
+
+	env := map[string]string{"PEACOCK_WEBHOOK_PAYMENTS_SECRET": "payments-secret", "PEACOCK_WEBHOOK_BILLING_SECRET": "billing-secret"}
+	handler := &Handler{
+		Clients:   map[string]domain.MessageClient{models.Webhook: webhook},
+		policy:    retry.NewPolicy(config.Delivery{}),
+		lookupEnv: func(key string) (string, bool) { v, ok := env[key]; return v, ok },
+	}
+	webhook.On("MaxContentLength").Return(0)
+
+	paymentsTeam := models.Team{Name: "payments", ContactType: models.Webhook, Addresses: []string{"Payments1"},
+		Webhook: &models.WebhookEndpoint{URL: paymentsServer.URL, SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET"}}
+	ledgerTeam := models.Team{Name: "ledger", ContactType: models.Webhook, Addresses: []string{"Ledger1"},
+		Webhook: &models.WebhookEndpoint{URL: paymentsServer.URL, SecretRef: "PEACOCK_WEBHOOK_PAYMENTS_SECRET"}}
+	billingTeam := models.Team{Name: "billing", ContactType: models.Webhook, Addresses: []string{"Billing1"},
+		Webhook: &models.WebhookEndpoint{URL: billingServer.URL, SecretRef: "PEACOCK_WEBHOOK_BILLING_SECRET"}}
+	unconfiguredTeam := models.Team{Name: "unconfigured", ContactType: models.Webhook, Addresses: []string{"Unconfigured1"},
+		Webhook: &models.WebhookEndpoint{URL: billingServer.URL, SecretRef: "PEACOCK_WEBHOOK_MISSING_SECRET"}}
+
+	note := models.ReleaseNote{Teams: models.Teams{supportTeam, paymentsTeam, ledgerTeam, billingTeam, unconfiguredTeam}, Content: "Test message content"}
+	// Teams without their own endpoint are still sent via the global webhook
+	webhook.On("Send", withDeadline, models.Message{Subject: "Release", Content: "Test message content", PullRequest: mockPR, NoteHash: note.Hash(), Teams: note.Teams.GetAllTeamNames()}, []string{"Webhook1", "Webhook2"}).Return([]models.DeliveryResult{
+		{MessageReference: models.MessageReference{Address: "Webhook1"}},
+		{MessageReference: models.MessageReference{Address: "Webhook2"}},
+	}).Once()
+
+	report := handler.SendReleaseNotes(ctx, "Release", []models.ReleaseNote{note}, mockPR)
+	assert.Len(t, report.References(), 5)
+
+	// Teams that share an endpoint are sent in a single request
+	assert.Equal(t, []received{{[]string{"Payments1", "Ledger1"}, paymentsRequests[0].signature}}, paymentsRequests)
+	assert.Equal(t, []received{{[]string{"Billing1"}, billingRequests[0].signature}}, billingRequests)
+	assert.NotEqual(t, paymentsRequests[0].signature, billingRequests[0].signature)
+
+	failures := report.Failures()
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, "Unconfigured1", failures[0].Address)
+		assert.Equal(t, models.Webhook, failures[0].ContactType)
+		assert.ErrorContains(t, failures[0].Err, "secret PEACOCK_WEBHOOK_MISSING_SECRET")
+	}
+}
+
+func TestHandler_EndpointClientRefs(t *testing.T) {
+	env := map[string]string{"GITHUB_TOKEN": "github-token", "PEACOCK_WEBHOOK_SECRET": "secret"}
+	handler := &Handler{
+		policy:    retry.NewPolicy(config.Delivery{}),
+		lookupEnv: func(key string) (string, bool) { v, ok := env[key]; return v, ok },
+	}
+
+	testCases := []struct {
+		name        string
+		endpoint    models.WebhookEndpoint
+		shouldError bool
+	}{
+		{
+			name:     "Prefixed",
+			endpoint: models.WebhookEndpoint{URL: "https://example.com", SecretRef: "PEACOCK_WEBHOOK_SECRET"},
+		},
+		{
+			name:        "TokenRefWithoutPrefix",
+			endpoint:    models.WebhookEndpoint{URL: "https://example.com", TokenRef: "GITHUB_TOKEN", SecretRef: "PEACOCK_WEBHOOK_SECRET"},
+			shouldError: true,
+		},
+		{
+			name:        "SecretRefWithoutPrefix",
+			endpoint:    models.WebhookEndpoint{URL: "https://example.com", SecretRef: "GITHUB_TOKEN"},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.endpointClient(tt.endpoint)
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewMessageHandler_WebhookFormat(t *testing.T) {
+	testCases := []struct {
+		name        string
+		format      string
+		shouldError bool
+	}{
+		{
+			name:   "DefaultFormat",
+			format: "",
+		},
+		{
+			name:   "ValidFormat",
+			format: models.CloudEventsBinaryPayload,
+		},
+		{
+			name:        "InvalidFormat",
+			format:      "cloudevents",
+			shouldError: true,
+		},
+	}
 
-			err := handler.SendReleaseNotes("", []models.ReleaseNote{tc.inputMessage})
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := NewMessageHandler(&config.MessageHandlers{
+				Webhook: config.Webhook{URL: "https://example.com", Secret: "secret", Format: tt.format},
+			})
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
 			assert.NoError(t, err)
+			assert.Contains(t, handler.Clients, models.Webhook)
 		})
 	}
 }
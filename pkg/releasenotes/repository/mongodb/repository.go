@@ -1,8 +1,87 @@
 package mongodb
 
-type ReleaseNotesRepository struct {
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type repository struct {
+	collection *mongo.Collection
+}
+
+func NewRepository(client mongo.Client) domain.ReleaseNotesRepository {
+	db := *client.Database("Peacock")
+	collection := db.Collection("SentReleaseNotes")
+
+	return &repository{
+		collection: collection,
+	}
+}
+
+func (r *repository) Save(ctx context.Context, sent models.SentReleaseNotes) error {
+	if sent.Messages == nil {
+		// Messages are pushed onto the array as they're delivered, which mongo can't do if it's null
+		sent.Messages = []models.MessageReference{}
+	}
+	_, err := r.collection.ReplaceOne(ctx, pullRequestFilter(sent.PullRequest), sent, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) Create(ctx context.Context, sent models.SentReleaseNotes) error {
+	if sent.Messages == nil {
+		sent.Messages = []models.MessageReference{}
+	}
+	// The record is only set on insert so that the messages of an existing record aren't lost
+	update := bson.M{"$setOnInsert": sent}
+	_, err := r.collection.UpdateOne(ctx, pullRequestFilter(sent.PullRequest), update, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) AddMessages(ctx context.Context, pr models.PullRequestSummary, refs []models.MessageReference) error {
+	update := bson.M{
+		"$push": bson.M{"messages": bson.M{"$each": refs}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	}
+	_, err := r.collection.UpdateOne(ctx, pullRequestFilter(pr), update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) GetByPullRequest(ctx context.Context, pr models.PullRequestSummary) (*models.SentReleaseNotes, error) {
+	var sent models.SentReleaseNotes
+	err := r.collection.FindOne(ctx, pullRequestFilter(pr)).Decode(&sent)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &sent, nil
 }
 
-func NewReleaseNotesRepository() *ReleaseNotesRepository {
-	return &ReleaseNotesRepository{}
+func pullRequestFilter(pr models.PullRequestSummary) bson.M {
+	return bson.M{
+		"pullRequest.repoowner": pr.RepoOwner,
+		"pullRequest.reponame":  pr.RepoName,
+		"pullRequest.prnumber":  pr.PRNumber,
+	}
 }
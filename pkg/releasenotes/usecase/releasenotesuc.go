@@ -2,6 +2,7 @@ package releasenotesuc
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -15,6 +16,7 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 )
 
 const (
@@ -32,32 +34,54 @@ Release Note {{ inc $idx }} will be sent to: {{ getTeamNames $val.Teams }}
 
 </details>
 
-{{ end -}}`
+{{ end -}}
+{{ if .missingTeams }}
+***
+This pull request changes paths owned by teams that aren't notified: {{ commaSeparated .missingTeams }}. To notify them add:
+~~~markdown
+### Notify {{ commaSeparated .missingTeams }}
+~~~
+{{ end -}}
+{{ if .warnings }}
+***
+The feathers have warnings, they don't stop the release notes from being sent:
+
+{{ problemsTable .warnings }}{{ end -}}`
 )
 
 type UseCase struct {
 	MsgClientsHandler domain.MessageHandler
+	// repository is optional, without it the sent messages aren't recorded and can't be edited later
+	repository domain.ReleaseNotesRepository
 }
 
-func NewUseCase(msgClientsHandler domain.MessageHandler) *UseCase {
-	return &UseCase{msgClientsHandler}
+func NewUseCase(msgClientsHandler domain.MessageHandler, repository domain.ReleaseNotesRepository) *UseCase {
+	return &UseCase{
+		MsgClientsHandler: msgClientsHandler,
+		repository:        repository,
+	}
 }
 
-func (uc *UseCase) GetReleaseNotesFromMarkdownAndTeamsInFeathers(markdown string, teamsInFeathers models.Teams) ([]models.ReleaseNote, error) {
+func (uc *UseCase) GetReleaseNotesFromMarkdownAndTeamsInFeathers(markdown string, feathers *models.Feathers) ([]models.ReleaseNote, error) {
 	_, releaseNotes, err := uc.ParseReleaseNoteFromMarkdown(markdown, true)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get release notes from markdown")
 	}
-	if err = uc.PopulateTeamsInReleaseNotes(releaseNotes, teamsInFeathers); err != nil {
+	if err = uc.PopulateTeamsInReleaseNotes(releaseNotes, feathers); err != nil {
 		return nil, errors.Wrap(err, "failed to populate teams in release notes")
 	}
 	releaseNotes = uc.MergeReleaseNotes(releaseNotes)
 	return releaseNotes, nil
 }
 
-func (uc *UseCase) PopulateTeamsInReleaseNotes(releaseNotes []models.ReleaseNote, teamsInFeathers models.Teams) error {
+func (uc *UseCase) PopulateTeamsInReleaseNotes(releaseNotes []models.ReleaseNote, feathers *models.Feathers) error {
 	for i, note := range releaseNotes {
-		teamsInNote, err := uc.getAndValidateTeamsByNames(note.Teams.GetAllTeamNames(), teamsInFeathers)
+		// Groups are replaced by their teams, so a team in several of the groups named is only sent the note once
+		teamNames, err := feathers.ExpandTeamNames(note.Teams.GetAllTeamNames()...)
+		if err != nil {
+			return errors.Wrap(err, "failed to expand groups")
+		}
+		teamsInNote, err := uc.getAndValidateTeamsByNames(teamNames, feathers.Teams)
 		if err != nil {
 			return errors.Wrap(err, "failed to get teams by name")
 		}
@@ -196,8 +220,10 @@ func (uc *UseCase) getAndValidateTeamsByNames(teamNames []string, teamsInFeather
 	}
 	wantedTeams := teamsInFeathers.GetTeamsByNames(teamNames...)
 	for _, team := range wantedTeams {
-		if !uc.MsgClientsHandler.IsInitialised(team.ContactType) {
-			return nil, errors.New(fmt.Sprintf("communication method %s has not been configured", team.ContactType))
+		for _, c := range team.GetChannels() {
+			if !uc.MsgClientsHandler.IsInitialised(c) {
+				return nil, errors.New(fmt.Sprintf("communication method %s has not been configured", c.ContactType))
+			}
 		}
 	}
 	return wantedTeams, nil
@@ -230,10 +256,12 @@ func (uc *UseCase) GenerateHash(notes []models.ReleaseNote) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func (uc *UseCase) GenerateBreakdown(notes []models.ReleaseNote, hash string, totalTeams int) (string, error) {
+func (uc *UseCase) GenerateBreakdown(notes []models.ReleaseNote, hash string, totalTeams int, missingTeams []string, warnings []domain.FeathersProblem) (string, error) {
 	tmplFuncs := template.FuncMap{
-		"inc":          func(i int) int { return i + 1 },
-		"getTeamNames": func(ts models.Teams) string { return utils.CommaSeparated(ts.GetAllTeamNames()) },
+		"inc":            func(i int) int { return i + 1 },
+		"getTeamNames":   func(ts models.Teams) string { return utils.CommaSeparated(ts.GetAllTeamNames()) },
+		"commaSeparated": utils.CommaSeparated[string],
+		"problemsTable":  comment.FeathersProblemsTable,
 		"addPlural": func(i int) string {
 			var plural string
 			if i > 1 {
@@ -250,8 +278,10 @@ func (uc *UseCase) GenerateBreakdown(notes []models.ReleaseNote, hash string, to
 
 	var buf bytes.Buffer
 	err = tpl.Execute(&buf, map[string]any{
-		"totalTeams": totalTeams,
-		"notes":      notes,
+		"totalTeams":   totalTeams,
+		"notes":        notes,
+		"missingTeams": missingTeams,
+		"warnings":     warnings,
 	})
 	if err != nil {
 		return "", err
@@ -262,8 +292,188 @@ func (uc *UseCase) GenerateBreakdown(notes []models.ReleaseNote, hash string, to
 	return breakdown, nil
 }
 
-func (uc *UseCase) SendReleaseNotes(subject string, notes []models.ReleaseNote) error {
-	return uc.MsgClientsHandler.SendReleaseNotes(subject, notes)
+// WrapReleaseNotes returns a copy of the notes with their content wrapped by the template from the feathers. Teams can
+// override the messages config, so a note is split into one note for each template its teams use & each team that
+// overrides the config, or hides the metadata, is given the config that applies to it for the message handler. If
+// there's no template then the content of the notes is left as it is.
+func (uc *UseCase) WrapReleaseNotes(messages models.Messages, notes []models.ReleaseNote, pr models.PullRequestSummary) ([]models.ReleaseNote, error) {
+	var wrapped []models.ReleaseNote
+	for _, n := range notes {
+		// Notes without any teams have nothing to be wrapped for
+		if len(n.Teams) == 0 {
+			wrapped = append(wrapped, n)
+			continue
+		}
+
+		// Group the teams by their template, keeping the order of the teams
+		var templates []string
+		teamsByTemplate := make(map[string]models.Teams)
+		for _, team := range n.Teams {
+			teamMessages := messages.Override(team.Messages)
+			if team.Messages != nil || messages.Metadata != nil {
+				team.Messages = &teamMessages
+			}
+			if _, ok := teamsByTemplate[teamMessages.Template]; !ok {
+				templates = append(templates, teamMessages.Template)
+			}
+			teamsByTemplate[teamMessages.Template] = append(teamsByTemplate[teamMessages.Template], team)
+		}
+
+		for _, text := range templates {
+			note := models.ReleaseNote{Teams: teamsByTemplate[text], Content: n.Content}
+			if text != "" {
+				content, err := wrapContent(text, note, pr)
+				if err != nil {
+					return nil, err
+				}
+				note.Content = content
+			}
+			wrapped = append(wrapped, note)
+		}
+	}
+	return wrapped, nil
+}
+
+// wrapContent executes the template with the content of the note
+func wrapContent(text string, note models.ReleaseNote, pr models.PullRequestSummary) (string, error) {
+	tmpl, err := models.Messages{Template: text}.ParseTemplate()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse message template")
+	}
+
+	data := models.NewMessageTemplateData(pr, note.Teams.GetAllTeamNames())
+	data.Content = note.Content
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to execute message template for %s", note.Key())
+	}
+	return buf.String(), nil
+}
+
+func (uc *UseCase) SendReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) models.DeliveryReport {
+	return uc.MsgClientsHandler.SendReleaseNotes(ctx, subject, notes, pr)
+}
+
+func (uc *UseCase) CreateSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) error {
+	if uc.repository == nil {
+		return nil
+	}
+	return uc.repository.Create(ctx, models.SentReleaseNotes{
+		PullRequest: pr,
+		Subject:     subject,
+		Notes:       notes,
+		Messages:    []models.MessageReference{},
+		UpdatedAt:   time.Now(),
+	})
+}
+
+func (uc *UseCase) SaveSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, refs []models.MessageReference, pr models.PullRequestSummary) error {
+	if uc.repository == nil {
+		return nil
+	}
+	return uc.repository.Save(ctx, models.SentReleaseNotes{
+		PullRequest: pr,
+		Subject:     subject,
+		Notes:       notes,
+		Messages:    refs,
+		UpdatedAt:   time.Now(),
+	})
+}
+
+func (uc *UseCase) AddSentMessages(ctx context.Context, pr models.PullRequestSummary, refs []models.MessageReference) error {
+	if uc.repository == nil || len(refs) == 0 {
+		return nil
+	}
+	return uc.repository.AddMessages(ctx, pr, refs)
+}
+
+func (uc *UseCase) UpdateSentReleaseNotes(ctx context.Context, subject string, notes []models.ReleaseNote, pr models.PullRequestSummary) error {
+	if uc.repository == nil {
+		return errors.New("no repository configured for sent release notes")
+	}
+	sent, err := uc.repository.GetByPullRequest(ctx, pr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get sent release notes")
+	}
+	if sent == nil {
+		log.Infof("no release notes were sent for %s/PR-%d, skipping", pr.RepoName, pr.PRNumber)
+		return nil
+	}
+
+	previousContent := make(map[string]string, len(sent.Notes))
+	for _, n := range sent.Notes {
+		previousContent[n.Key()] = n.Content
+	}
+
+	// Only notes that have already been sent can be edited, notes added after the merge are not sent
+	var currentNotes, changedNotes []models.ReleaseNote
+	for _, n := range notes {
+		content, ok := previousContent[n.Key()]
+		if !ok {
+			log.Warnf("release note for %s was added after the pull request was merged, it will not be sent", n.Key())
+			continue
+		}
+		currentNotes = append(currentNotes, n)
+		if content != n.Content {
+			changedNotes = append(changedNotes, n)
+		}
+	}
+
+	editRefs, keepRefs := selectMessagesToEdit(sent.Messages, currentNotes, changedNotes)
+	if len(editRefs) == 0 {
+		log.Info("sent release notes are unchanged, skipping")
+		return nil
+	}
+
+	editedRefs, err := uc.MsgClientsHandler.UpdateReleaseNotes(ctx, sent.Subject, changedNotes, editRefs, pr)
+	if saveErr := uc.SaveSentReleaseNotes(ctx, sent.Subject, currentNotes, append(keepRefs, editedRefs...), pr); saveErr != nil {
+		log.Error(errors.Wrap(saveErr, "failed to save sent release notes"))
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to update sent release notes")
+	}
+	return nil
+}
+
+// selectMessagesToEdit splits the sent messages into those that need to be edited (their note has changed or been
+// removed) and those that can be kept as they are. Thread parents are only edited, i.e. deleted, once all of their
+// replies have been removed.
+func selectMessagesToEdit(sent []models.MessageReference, currentNotes, changedNotes []models.ReleaseNote) (edit, keep []models.MessageReference) {
+	current := make(map[string]bool, len(currentNotes))
+	for _, n := range currentNotes {
+		current[n.Key()] = true
+	}
+	changed := make(map[string]bool, len(changedNotes))
+	for _, n := range changedNotes {
+		changed[n.Key()] = true
+	}
+
+	type thread struct{ address, parentID string }
+	remainingReplies := make(map[thread]int)
+	for _, ref := range sent {
+		if ref.ThreadID != "" && current[ref.NoteKey] {
+			remainingReplies[thread{ref.Address, ref.ThreadID}]++
+		}
+	}
+
+	// Parents are deleted after their replies so that Slack doesn't leave a placeholder for them in the channel
+	var emptyParents []models.MessageReference
+	for _, ref := range sent {
+		switch {
+		case ref.NoteKey == "":
+			if remainingReplies[thread{ref.Address, ref.ID}] == 0 {
+				emptyParents = append(emptyParents, ref)
+				continue
+			}
+			keep = append(keep, ref)
+		case !current[ref.NoteKey], changed[ref.NoteKey]:
+			edit = append(edit, ref)
+		default:
+			keep = append(keep, ref)
+		}
+	}
+	return append(edit, emptyParents...), keep
 }
 
 func addSuffixIfNotExists(text string, suffix string) string {
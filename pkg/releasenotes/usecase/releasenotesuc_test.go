@@ -1,15 +1,19 @@
 package releasenotesuc
 
 import (
+	"context"
 	"fmt"
 	"github.com/spring-financial-group/peacock/pkg/domain"
+	"github.com/spring-financial-group/peacock/pkg/domain/mocks"
 	"github.com/spring-financial-group/peacock/pkg/models"
 	"github.com/spring-financial-group/peacock/pkg/msgclients/slack"
 	"github.com/spring-financial-group/peacock/pkg/msgclients/webhook"
 	"github.com/spring-financial-group/peacock/pkg/releasenotes/delivery/msgclients"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 var (
@@ -40,6 +44,9 @@ var (
 		productTeam,
 		teamWithBadContactType,
 	}
+	allGroups = map[string][]string{
+		"engineering": {"devs", "infrastructure"},
+	}
 )
 
 func TestUseCase_GetReleaseNotesFromMarkdownAndTeamsInFeathers(t *testing.T) {
@@ -48,7 +55,7 @@ func TestUseCase_GetReleaseNotesFromMarkdownAndTeamsInFeathers(t *testing.T) {
 			models.Slack:   &slack.Client{},
 			models.Webhook: &webhook.Client{},
 		},
-	})
+	}, nil)
 
 	testCases := []struct {
 		name          string
@@ -255,11 +262,33 @@ func TestUseCase_GetReleaseNotesFromMarkdownAndTeamsInFeathers(t *testing.T) {
 			},
 			shouldError: false,
 		},
+		{
+			name:          "Group",
+			inputMarkdown: "### Notify engineering, ml\nTest Content",
+			expectedNotes: []models.ReleaseNote{
+				{
+					Teams:   models.Teams{devsTeam, infraTeam, mlTeam},
+					Content: "Test Content",
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name:          "GroupAndMember",
+			inputMarkdown: "### Notify infrastructure, engineering\nTest Content",
+			expectedNotes: []models.ReleaseNote{
+				{
+					Teams:   models.Teams{infraTeam, devsTeam},
+					Content: "Test Content",
+				},
+			},
+			shouldError: false,
+		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			actualMessages, err := uc.GetReleaseNotesFromMarkdownAndTeamsInFeathers(tt.inputMarkdown, allTeams)
+			actualMessages, err := uc.GetReleaseNotesFromMarkdownAndTeamsInFeathers(tt.inputMarkdown, &models.Feathers{Teams: allTeams, Groups: allGroups})
 			if tt.shouldError {
 				fmt.Println("expected error: " + err.Error())
 				assert.Error(t, err)
@@ -274,7 +303,7 @@ func TestUseCase_GetReleaseNotesFromMarkdownAndTeamsInFeathers(t *testing.T) {
 func TestUseCase_ParseReleaseNoteFromMarkdown(t *testing.T) {
 	uc := NewUseCase(&msgclients.Handler{
 		Clients: map[string]domain.MessageClient{},
-	})
+	}, nil)
 
 	testCases := []struct {
 		name             string
@@ -351,12 +380,14 @@ func TestUseCase_ParseReleaseNoteFromMarkdown(t *testing.T) {
 }
 
 func TestOptions_GenerateMessageBreakdown(t *testing.T) {
-	uc := NewUseCase(nil)
+	uc := NewUseCase(nil, nil)
 
 	testCases := []struct {
 		name              string
 		inputNotes        []models.ReleaseNote
 		numberOfTeams     int
+		missingTeams      []string
+		warnings          []domain.FeathersProblem
 		expectedBreakdown string
 	}{
 		{
@@ -385,13 +416,39 @@ func TestOptions_GenerateMessageBreakdown(t *testing.T) {
 			numberOfTeams:     2,
 			expectedBreakdown: "Successfully validated 2 release notes.\n\n***\nRelease Note 1 will be sent to: infrastructure\n<details>\n<summary>Release Note Breakdown</summary>\n\nNew release of some infrastructure\nrelated things\n\n</details>\n\n\n***\nRelease Note 2 will be sent to: ml\n<details>\n<summary>Release Note Breakdown</summary>\n\nNew release of some ml\nrelated things\n\n</details>\n<!-- hash: ReallyGoodHash type: breakdown -->\n",
 		},
+		{
+			name: "MissingTeams",
+			inputNotes: []models.ReleaseNote{
+				{
+					Teams:   models.Teams{infraTeam},
+					Content: "New release of some infrastructure\nrelated things",
+				},
+			},
+			numberOfTeams:     3,
+			missingTeams:      []string{"ml", "devs"},
+			expectedBreakdown: "Successfully validated 1 release note.\n\n***\nRelease Note 1 will be sent to: infrastructure\n<details>\n<summary>Release Note Breakdown</summary>\n\nNew release of some infrastructure\nrelated things\n\n</details>\n\n\n***\nThis pull request changes paths owned by teams that aren't notified: ml, devs. To notify them add:\n~~~markdown\n### Notify ml, devs\n~~~\n<!-- hash: ReallyGoodHash type: breakdown -->\n",
+		},
+		{
+			name: "FeathersWarnings",
+			inputNotes: []models.ReleaseNote{
+				{
+					Teams:   models.Teams{infraTeam},
+					Content: "New release of some infrastructure\nrelated things",
+				},
+			},
+			numberOfTeams: 1,
+			warnings: []domain.FeathersProblem{
+				{Line: 7, Column: 5, Severity: domain.WarningSeverity, Message: "unknown field contacttype"},
+			},
+			expectedBreakdown: "Successfully validated 1 release note.\n\n***\nRelease Note 1 will be sent to: infrastructure\n<details>\n<summary>Release Note Breakdown</summary>\n\nNew release of some infrastructure\nrelated things\n\n</details>\n\n\n***\nThe feathers have warnings, they don't stop the release notes from being sent:\n\n| Line | Column | Severity | Problem |\n|------|--------|----------|---------|\n| 7 | 5 | warning | unknown field contacttype |\n<!-- hash: ReallyGoodHash type: breakdown -->\n",
+		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			mockHash := "ReallyGoodHash"
 
-			actualBreakdown, err := uc.GenerateBreakdown(tt.inputNotes, mockHash, tt.numberOfTeams)
+			actualBreakdown, err := uc.GenerateBreakdown(tt.inputNotes, mockHash, tt.numberOfTeams, tt.missingTeams, tt.warnings)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedBreakdown, actualBreakdown)
 		})
@@ -441,7 +498,7 @@ func TestUseCase_GetMarkdownFromReleaseNotes(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			uc := NewUseCase(nil)
+			uc := NewUseCase(nil, nil)
 			actual := uc.GetMarkdownFromReleaseNotes(tc.notes)
 			assert.Equal(t, tc.expected, actual)
 		})
@@ -547,10 +604,164 @@ func TestUseCase_AppendReleaseNotesToExistingMarkdown(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			uc := NewUseCase(nil)
+			uc := NewUseCase(nil, nil)
 			actual, err := uc.AppendReleaseNotesToExistingMarkdown(tc.existingMarkdown, tc.new)
 			require.NoError(t, err)
 			assert.Equal(t, tc.expected, actual)
 		})
 	}
 }
+
+func TestUseCase_WrapReleaseNotes(t *testing.T) {
+	notes := []models.ReleaseNote{
+		{Teams: models.Teams{infraTeam, devsTeam}, Content: "New feature"},
+		{Teams: models.Teams{productTeam}, Content: "Bug fix"},
+	}
+	// QA wants the link to the pull request & the environment in place of the repository
+	qaTeam := models.Team{
+		Name:        "qa",
+		ContactType: models.Slack,
+		Messages:    &models.Messages{Template: "{{ .PRURL }} in {{ .Environment }}\n{{ .Content }}"},
+	}
+	pr := models.PullRequestSummary{
+		PRNumber:    12,
+		RepoOwner:   "spring-financial-group",
+		RepoName:    "peacock",
+		Environment: "production",
+		Title:       "Add templates",
+		Author:      "some-user",
+		MergedAt:    time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name          string
+		messages      models.Messages
+		notes         []models.ReleaseNote
+		expectedNotes []models.ReleaseNote
+		shouldError   bool
+	}{
+		{
+			name:          "NoTemplate",
+			messages:      models.Messages{Subject: "Release"},
+			expectedNotes: notes,
+		},
+		{
+			name:     "Template",
+			messages: models.Messages{Template: "**{{ .Repo }}** #{{ .PRNumber }} {{ .PRTitle }} by {{ .Author }} on {{ .Date }} to {{ .Environment }} for {{ join .Teams \", \" }}\n{{ .PRURL }}\n\n{{ .Content }}"},
+			expectedNotes: []models.ReleaseNote{
+				{
+					Teams:   models.Teams{infraTeam, devsTeam},
+					Content: "**peacock** #12 Add templates by some-user on 2024-03-04 to production for infrastructure, devs\nhttps://github.com/spring-financial-group/peacock/pull/12\n\nNew feature",
+				},
+				{
+					Teams:   models.Teams{productTeam},
+					Content: "**peacock** #12 Add templates by some-user on 2024-03-04 to production for product\nhttps://github.com/spring-financial-group/peacock/pull/12\n\nBug fix",
+				},
+			},
+		},
+		{
+			name:        "InvalidTemplate",
+			messages:    models.Messages{Template: "{{ .Service }}"},
+			shouldError: true,
+		},
+		{
+			name:     "TeamOverride",
+			messages: models.Messages{Subject: "Release", Template: "{{ .Repo }}: {{ .Content }}"},
+			notes: []models.ReleaseNote{
+				{Teams: models.Teams{infraTeam, qaTeam, devsTeam}, Content: "New feature"},
+			},
+			expectedNotes: []models.ReleaseNote{
+				{Teams: models.Teams{infraTeam, devsTeam}, Content: "peacock: New feature"},
+				{
+					Teams: models.Teams{{
+						Name:        "qa",
+						ContactType: models.Slack,
+						Messages:    &models.Messages{Subject: "Release", Template: "{{ .PRURL }} in {{ .Environment }}\n{{ .Content }}"},
+					}},
+					Content: "https://github.com/spring-financial-group/peacock/pull/12 in production\nNew feature",
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := NewUseCase(nil, nil)
+			if tc.notes == nil {
+				tc.notes = notes
+			}
+			actual, err := uc.WrapReleaseNotes(tc.messages, tc.notes, pr)
+			if tc.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedNotes, actual)
+		})
+	}
+}
+
+func TestUseCase_UpdateSentReleaseNotes(t *testing.T) {
+	ctx := context.Background()
+	pr := models.PullRequestSummary{PRNumber: 1, RepoOwner: "spring-financial-group", RepoName: "peacock"}
+
+	mockHandler := mocks.NewMessageHandler(t)
+	mockRepo := mocks.NewReleaseNotesRepository(t)
+	uc := NewUseCase(mockHandler, mockRepo)
+
+	infraParent := models.MessageReference{ContactType: models.Slack, Address: "C1", ID: "p1"}
+	infraReply := models.MessageReference{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C1", ID: "r1", ThreadID: "p1"}
+	devsMessage := models.MessageReference{ContactType: models.Slack, NoteKey: "devs", Address: "C2", ID: "m1"}
+	mlParent := models.MessageReference{ContactType: models.Slack, Address: "C3", ID: "p2"}
+	mlReply := models.MessageReference{ContactType: models.Slack, NoteKey: "ml", Address: "C3", ID: "r2", ThreadID: "p2"}
+
+	mockRepo.On("GetByPullRequest", ctx, pr).Return(&models.SentReleaseNotes{
+		PullRequest: pr,
+		Subject:     "Subject",
+		Notes: []models.ReleaseNote{
+			{Teams: models.Teams{infraTeam}, Content: "Old infra note"},
+			{Teams: models.Teams{devsTeam}, Content: "Devs note"},
+			{Teams: models.Teams{mlTeam}, Content: "ML note"},
+		},
+		Messages: []models.MessageReference{infraParent, infraReply, devsMessage, mlParent, mlReply},
+	}, nil).Once()
+
+	updatedInfra := models.ReleaseNote{Teams: models.Teams{infraTeam}, Content: "New infra note"}
+	devs := models.ReleaseNote{Teams: models.Teams{devsTeam}, Content: "Devs note"}
+	// Product wasn't sent at merge so shouldn't be sent now
+	product := models.ReleaseNote{Teams: models.Teams{productTeam}, Content: "Product note"}
+
+	// The ML note has been removed so its reply & then the empty parent should be deleted
+	mockHandler.On("UpdateReleaseNotes", ctx, "Subject", []models.ReleaseNote{updatedInfra}, []models.MessageReference{infraReply, mlReply, mlParent}, pr).
+		Return([]models.MessageReference{infraReply}, nil).Once()
+
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(sent models.SentReleaseNotes) bool {
+		assert.Equal(t, []models.ReleaseNote{updatedInfra, devs}, sent.Notes)
+		assert.Equal(t, []models.MessageReference{infraParent, devsMessage, infraReply}, sent.Messages)
+		return true
+	})).Return(nil).Once()
+
+	err := uc.UpdateSentReleaseNotes(ctx, "Subject", []models.ReleaseNote{updatedInfra, devs, product}, pr)
+	assert.NoError(t, err)
+}
+
+func TestUseCase_CreateSentReleaseNotes(t *testing.T) {
+	ctx := context.Background()
+	pr := models.PullRequestSummary{PRNumber: 1, RepoOwner: "spring-financial-group", RepoName: "peacock"}
+
+	mockHandler := mocks.NewMessageHandler(t)
+	mockRepo := mocks.NewReleaseNotesRepository(t)
+	uc := NewUseCase(mockHandler, mockRepo)
+
+	// The record is created rather than saved so that a resend doesn't replace the messages already sent
+	notes := []models.ReleaseNote{{Teams: models.Teams{infraTeam}, Content: "Infra note"}}
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(sent models.SentReleaseNotes) bool {
+		assert.Equal(t, pr, sent.PullRequest)
+		assert.Equal(t, "Subject", sent.Subject)
+		assert.Equal(t, notes, sent.Notes)
+		assert.Equal(t, []models.MessageReference{}, sent.Messages)
+		return true
+	})).Return(nil).Once()
+
+	err := uc.CreateSentReleaseNotes(ctx, "Subject", notes, pr)
+	assert.NoError(t, err)
+}
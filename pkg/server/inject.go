@@ -7,18 +7,24 @@ import (
 	"github.com/spring-financial-group/peacock/pkg/feathers"
 	"github.com/spring-financial-group/peacock/pkg/git/github"
 	"github.com/spring-financial-group/peacock/pkg/health"
+	ledgerrepo "github.com/spring-financial-group/peacock/pkg/ledger/repository/mongodb"
+	ledgeruc "github.com/spring-financial-group/peacock/pkg/ledger/usecase"
 	"github.com/spring-financial-group/peacock/pkg/logger"
+	"github.com/spring-financial-group/peacock/pkg/outbox/delivery/worker"
+	outboxrepo "github.com/spring-financial-group/peacock/pkg/outbox/repository/mongodb"
+	outboxuc "github.com/spring-financial-group/peacock/pkg/outbox/usecase"
 	releasehandler "github.com/spring-financial-group/peacock/pkg/release/delivery"
 	releaserepo "github.com/spring-financial-group/peacock/pkg/release/repository/mongodb"
 	releaseuc "github.com/spring-financial-group/peacock/pkg/release/usecase"
 	"github.com/spring-financial-group/peacock/pkg/releasenotes/delivery/msgclients"
+	releasenotesrepo "github.com/spring-financial-group/peacock/pkg/releasenotes/repository/mongodb"
 	releasenotesuc "github.com/spring-financial-group/peacock/pkg/releasenotes/usecase"
 	"github.com/spring-financial-group/peacock/pkg/webhook/handler"
 	"github.com/spring-financial-group/peacock/pkg/webhook/usecase"
 	"github.com/swaggest/swgui/v3cdn"
 )
 
-func inject(cfg *config.Config, data *DataSources) (*gin.Engine, error) {
+func inject(cfg *config.Config, data *DataSources) (*gin.Engine, *worker.Worker, error) {
 	// Setup router
 	gin.SetMode(gin.ReleaseMode)
 
@@ -41,16 +47,30 @@ func inject(cfg *config.Config, data *DataSources) (*gin.Engine, error) {
 
 	scmClient := github.NewClient(cfg.SCM.User, cfg.SCM.Token)
 
-	msgHandler := msgclients.NewMessageHandler(&cfg.MessageHandlers)
+	msgHandler, err := msgclients.NewMessageHandler(&cfg.MessageHandlers)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	notesUC := releasenotesuc.NewUseCase(msgHandler)
+	notesRepo := releasenotesrepo.NewRepository(*data.MongoDBClient)
+	notesUC := releasenotesuc.NewUseCase(msgHandler, notesRepo)
 
-	feathersUC := feathers.NewUseCase()
+	feathersUC := feathers.NewUseCase(&cfg.Feathers, scmClient)
 
 	releaseRepo := releaserepo.NewRepository(*data.MongoDBClient)
 	releaseUC := releaseuc.NewUseCase(releaseRepo)
 
-	webhookUC := webhookuc.NewUseCase(&cfg.SCM, scmClient, notesUC, feathersUC, releaseUC)
+	ledgerRepo, err := ledgerrepo.NewRepository(*data.MongoDBClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	ledgerUC := ledgeruc.NewUseCase(ledgerRepo, notesUC)
+
+	outboxRepo := outboxrepo.NewRepository(*data.MongoDBClient)
+	outboxUC := outboxuc.NewUseCase(&cfg.Outbox, outboxRepo, notesUC, ledgerUC)
+	outboxWorker := worker.NewWorker(&cfg.Outbox, outboxUC)
+
+	webhookUC := webhookuc.NewUseCase(&cfg.SCM, scmClient, notesUC, feathersUC, releaseUC, outboxUC, ledgerUC)
 
 	// Setup handlers
 	webhookhandler.NewHandler(&cfg.SCM, publicGroup, webhookUC)
@@ -59,5 +79,5 @@ func inject(cfg *config.Config, data *DataSources) (*gin.Engine, error) {
 	infraGroup.GET("/swagger/v1/swagger.json", func(c *gin.Context) { c.File("docs/swagger.json") })
 	infraGroup.GET("/swagger/index.html", gin.WrapH(v3cdn.NewHandler("Peacock API", "/swagger/v1/swagger.json", "/")))
 
-	return router, nil
+	return router, outboxWorker, nil
 }
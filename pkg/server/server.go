@@ -30,11 +30,14 @@ func Run() {
 	}
 	defer sources.Close(context.Background())
 
-	router, err := inject(cfg, sources)
+	router, outboxWorker, err := inject(cfg, sources)
 	if err != nil {
 		log.Fatalf("Unable to initialise router: %v\n", err)
 	}
 
+	// The worker retries failed deliveries until the server is shut down
+	go outboxWorker.Run(ctx)
+
 	srv := &http.Server{
 		Addr:    ":8080",
 		Handler: router,
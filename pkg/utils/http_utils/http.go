@@ -2,12 +2,17 @@ package http_utils
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
@@ -18,8 +23,17 @@ const (
 
 	AuthorizationHeader = "Authorization"
 	SignatureHeader     = "X-Signature-256"
+	RetryAfterHeader    = "Retry-After"
+
+	// DefaultTimeout is a backstop for requests whose context has no deadline
+	DefaultTimeout = 30 * time.Second
+	// maxDrainSize is the most of an unused response body that's read so the connection can be reused, a longer body
+	// is just closed rather than keeping the request waiting on a misbehaving server
+	maxDrainSize = 4 << 10
 )
 
+var httpClient = &http.Client{Timeout: DefaultTimeout}
+
 // SignMessage uses HMAC & SHA256 hashing to sign a message
 func SignMessage(msg []byte, secret string) string {
 	mac := hmac.New(sha256.New, []byte(secret))
@@ -27,25 +41,90 @@ func SignMessage(msg []byte, secret string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// DoRequestAndCatchUnsuccessful sends a http request. If the response code != 200 then it returns an error.
-func DoRequestAndCatchUnsuccessful(request *http.Request) (*http.Response, error) {
-	client := &http.Client{}
-	resp, err := client.Do(request)
+// StatusError is returned when the response code of a request is not 2xx
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is how long the server asked us to wait before sending the request again, 0 if it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Status code indicated failure %s", e.Status)
+}
+
+// DoRequestAndCatchUnsuccessful sends a http request. If the response code is not 2xx then it returns a StatusError.
+// The body of the response isn't used, it's drained & closed whatever the response code so that the connection can be
+// reused by the next attempt. Only up to maxDrainSize of the body is read.
+func DoRequestAndCatchUnsuccessful(request *http.Request) error {
+	resp, err := httpClient.Do(request)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainSize))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			RetryAfter: parseRetryAfter(resp.Header.Get(RetryAfterHeader), time.Now()),
+		}
+	}
+	return nil
+}
+
+// parseRetryAfter parses the Retry-After header which is either a number of seconds or a HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
 	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// IsRetryable returns whether the error from a request was transient, e.g. a timeout or the server being overloaded,
+// so sending the request again may succeed
+func IsRetryable(err error) bool {
+	retryable, _ := Classify(err)
+	return retryable
+}
+
+// Classify returns whether the error from a request was transient & how long the server asked us to wait before
+// retrying. Rate limits (429) & server errors (5xx) are transient, other 4xx errors mean the request itself was wrong.
+func Classify(err error) (bool, time.Duration) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		retryable := statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+		return retryable, statusErr.RetryAfter
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded), 0
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return resp, errors.New(fmt.Sprintf("Status code indicated failure %s", resp.Status))
+// GeneratePostRequest creates a POST http.Request with a JSON body
+func GeneratePostRequest(ctx context.Context, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, POST, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
 	}
-	return resp, nil
+	req.Header.Add(ContentType, ApplicationJSON)
+	return req, nil
 }
 
 // GenerateAuthenticatedPostRequest creates a POST http.Request adding a token to the AuthorizationHeader header
 // and hash to the SignatureHeader
-func GenerateAuthenticatedPostRequest(url, authToken, hash string, body []byte) (*http.Request, error) {
+func GenerateAuthenticatedPostRequest(ctx context.Context, url, authToken, hash string, body []byte) (*http.Request, error) {
 	// create the request
-	req, err := http.NewRequest(POST, url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, POST, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
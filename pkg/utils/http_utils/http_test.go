@@ -0,0 +1,241 @@
+package http_utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{
+			name:     "Empty",
+			value:    "",
+			expected: 0,
+		},
+		{
+			name:     "DeltaSeconds",
+			value:    "120",
+			expected: 2 * time.Minute,
+		},
+		{
+			name:     "ZeroSeconds",
+			value:    "0",
+			expected: 0,
+		},
+		{
+			name:     "NegativeSeconds",
+			value:    "-5",
+			expected: 0,
+		},
+		{
+			name:     "HTTPDate",
+			value:    now.Add(90 * time.Second).Format(http.TimeFormat),
+			expected: 90 * time.Second,
+		},
+		{
+			name:     "PastHTTPDate",
+			value:    now.Add(-time.Hour).Format(http.TimeFormat),
+			expected: 0,
+		},
+		{
+			name:     "CurrentHTTPDate",
+			value:    now.Format(http.TimeFormat),
+			expected: 0,
+		},
+		{
+			name:     "Garbage",
+			value:    "soon",
+			expected: 0,
+		},
+		{
+			name:     "FractionalSeconds",
+			value:    "1.5",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseRetryAfter(tt.value, now))
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name               string
+		err                error
+		expectedRetryable  bool
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name:               "TooManyRequests",
+			err:                &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 30 * time.Second},
+			expectedRetryable:  true,
+			expectedRetryAfter: 30 * time.Second,
+		},
+		{
+			name:              "InternalServerError",
+			err:               &StatusError{StatusCode: http.StatusInternalServerError},
+			expectedRetryable: true,
+		},
+		{
+			name:               "ServiceUnavailable",
+			err:                &StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute},
+			expectedRetryable:  true,
+			expectedRetryAfter: time.Minute,
+		},
+		{
+			name:              "BadRequest",
+			err:               &StatusError{StatusCode: http.StatusBadRequest},
+			expectedRetryable: false,
+		},
+		{
+			name:              "NotFound",
+			err:               &StatusError{StatusCode: http.StatusNotFound},
+			expectedRetryable: false,
+		},
+		{
+			name:               "WrappedStatusError",
+			err:                errors.Wrap(&StatusError{StatusCode: http.StatusBadGateway, RetryAfter: time.Second}, "failed to post"),
+			expectedRetryable:  true,
+			expectedRetryAfter: time.Second,
+		},
+		{
+			name:              "NetworkError",
+			err:               errors.Wrap(timeoutError{}, "failed to post"),
+			expectedRetryable: true,
+		},
+		{
+			name:              "DeadlineExceeded",
+			err:               errors.Wrap(context.DeadlineExceeded, "failed to post"),
+			expectedRetryable: true,
+		},
+		{
+			name:              "Canceled",
+			err:               errors.Wrap(context.Canceled, "failed to post"),
+			expectedRetryable: false,
+		},
+		{
+			name:              "OtherError",
+			err:               errors.New("failed to marshal"),
+			expectedRetryable: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, retryAfter := Classify(tt.err)
+			assert.Equal(t, tt.expectedRetryable, retryable)
+			assert.Equal(t, tt.expectedRetryAfter, retryAfter)
+			assert.Equal(t, tt.expectedRetryable, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestDoRequestAndCatchUnsuccessful_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RetryAfterHeader, "7")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, err := GeneratePostRequest(context.Background(), server.URL, []byte("{}"))
+	require.NoError(t, err)
+	err = DoRequestAndCatchUnsuccessful(req)
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	retryable, retryAfter := Classify(err)
+	assert.True(t, retryable)
+	assert.Equal(t, 7*time.Second, retryAfter)
+}
+
+func TestDoRequestAndCatchUnsuccessful_ReusesConnection(t *testing.T) {
+	statuses := []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusOK}
+	requests := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[requests])
+		requests++
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	var mu sync.Mutex
+	connections := 0
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			connections++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// The connection can only be reused by the next request if the body of each response was drained & closed
+	for range statuses {
+		req, err := GeneratePostRequest(context.Background(), server.URL, []byte("{}"))
+		require.NoError(t, err)
+		_ = DoRequestAndCatchUnsuccessful(req)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, connections)
+}
+
+func TestDoRequestAndCatchUnsuccessful_LongBody(t *testing.T) {
+	// The server keeps writing until the client goes away, the request shouldn't wait for it to finish
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		chunk := make([]byte, 1024)
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	req, err := GeneratePostRequest(context.Background(), server.URL, []byte("{}"))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- DoRequestAndCatchUnsuccessful(req)
+	}()
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request waited for the whole body")
+	}
+}
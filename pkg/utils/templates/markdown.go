@@ -3,8 +3,10 @@ package templates
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/russross/blackfriday"
 )
@@ -18,6 +20,8 @@ var _ blackfriday.Renderer = &ASCIIRenderer{}
 // documents as plain text, well suited for human reading on terminals.
 type ASCIIRenderer struct {
 	Indentation string
+	// SkipHTML drops any raw HTML in the document instead of writing it out
+	SkipHTML bool
 
 	listItemCount uint
 	listLevel     uint
@@ -29,19 +33,26 @@ type ASCIIRenderer struct {
 func (r *ASCIIRenderer) NormalText(out *bytes.Buffer, text []byte) {
 	raw := string(text)
 	lines := strings.Split(raw, linebreak)
-	for _, line := range lines {
-		trimmed := strings.Trim(line, " \n\t")
-		out.WriteString(trimmed)
-		out.WriteString(" ")
+	for i, line := range lines {
+		if i > 0 {
+			line = strings.TrimLeft(line, " \t")
+		}
+		if i < len(lines)-1 {
+			line = strings.TrimRight(line, " \t") + " "
+		}
+		out.WriteString(line)
 	}
 }
 
-// List renders the start and end of a list.
+// List renders the start and end of a list. The item count of the enclosing
+// list is kept so that ordered lists continue numbering after a nested list.
 func (r *ASCIIRenderer) List(out *bytes.Buffer, text func() bool, flags int) {
+	itemCount := r.listItemCount
 	r.listLevel++
 	out.WriteString(linebreak)
 	text()
 	r.listLevel--
+	r.listItemCount = itemCount
 }
 
 // ListItem renders list items and supports both ordered and unordered lists.
@@ -51,7 +62,7 @@ func (r *ASCIIRenderer) ListItem(out *bytes.Buffer, text []byte, flags int) {
 	} else {
 		r.listItemCount++
 	}
-	indent := strings.Repeat(r.Indentation, int(r.listLevel))
+	indent := strings.Repeat(r.Indentation, int(r.listLevel)-1)
 	var bullet string
 	if flags&blackfriday.LIST_TYPE_ORDERED != 0 {
 		bullet += fmt.Sprintf("%d.", r.listItemCount)
@@ -59,7 +70,10 @@ func (r *ASCIIRenderer) ListItem(out *bytes.Buffer, text []byte, flags int) {
 		bullet += "*"
 	}
 	out.WriteString(indent + bullet + " ")
-	r.fw(out, text)
+	// Items containing blocks or nested lists are separated by the line breaks
+	// of a paragraph, which would leave blank lines inside the item
+	text = bytes.Trim(text, linebreak)
+	r.fw(out, bytes.ReplaceAll(text, []byte(linebreak+linebreak), []byte(linebreak)))
 	out.WriteString(linebreak)
 }
 
@@ -74,26 +88,92 @@ func (r *ASCIIRenderer) Paragraph(out *bytes.Buffer, text func() bool) {
 func (r *ASCIIRenderer) BlockCode(out *bytes.Buffer, text []byte, lang string) {
 	out.WriteString(linebreak)
 	var lines []string
-	for _, line := range strings.Split(string(text), linebreak) {
+	for _, line := range strings.Split(strings.TrimRight(string(text), linebreak), linebreak) {
 		indented := r.Indentation + line
 		lines = append(lines, indented)
 	}
 	out.WriteString(strings.Join(lines, linebreak))
+	out.WriteString(linebreak)
+}
+
+// Header renders a heading on its own line.
+func (r *ASCIIRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) {
+	out.WriteString(linebreak)
+	text()
+	out.WriteString(linebreak)
+}
+
+// Table renders the header row followed by a rule and the body rows.
+func (r *ASCIIRenderer) Table(out *bytes.Buffer, header []byte, body []byte, columnData []int) {
+	out.WriteString(linebreak)
+	r.fw(out, header)
+	if len(header) > 0 {
+		width := utf8.RuneCount(bytes.TrimSpace(header))
+		out.WriteString(strings.Repeat("-", width) + linebreak)
+	}
+	r.fw(out, body)
+}
+
+// TableRow renders a row of a table on its own line.
+func (r *ASCIIRenderer) TableRow(out *bytes.Buffer, text []byte) {
+	r.fw(out, text)
+	out.WriteString(linebreak)
+}
+
+// TableHeaderCell renders a cell of the header row, separating it from the
+// previous cell.
+func (r *ASCIIRenderer) TableHeaderCell(out *bytes.Buffer, text []byte, align int) {
+	r.TableCell(out, text, align)
+}
+
+// TableCell renders a cell of a row, separating it from the previous cell.
+func (r *ASCIIRenderer) TableCell(out *bytes.Buffer, text []byte, align int) {
+	// Each row is rendered into its own buffer so anything already written is a
+	// previous cell
+	if out.Len() > 0 {
+		out.WriteString(" | ")
+	}
+	r.fw(out, bytes.TrimSpace(text))
+}
+
+// Link renders the text of a link followed by its URL, or just the URL if they
+// are the same.
+func (r *ASCIIRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	r.writeLink(out, link, content)
+}
+
+// Image renders the alternative text of an image followed by its URL.
+func (r *ASCIIRenderer) Image(out *bytes.Buffer, link []byte, title []byte, alt []byte) {
+	r.writeLink(out, link, alt)
+}
+
+// Entity renders an HTML entity as the character it represents.
+func (r *ASCIIRenderer) Entity(out *bytes.Buffer, entity []byte) {
+	out.WriteString(html.UnescapeString(string(entity)))
+}
+
+// BlockHtml renders a block of raw HTML unless SkipHTML is set.
+func (r *ASCIIRenderer) BlockHtml(out *bytes.Buffer, text []byte) {
+	if !r.SkipHTML {
+		r.fw(out, text)
+	}
+}
+
+// RawHtmlTag renders an inline HTML tag unless SkipHTML is set.
+func (r *ASCIIRenderer) RawHtmlTag(out *bytes.Buffer, text []byte) {
+	if !r.SkipHTML {
+		r.fw(out, text)
+	}
 }
 
 func (r *ASCIIRenderer) GetFlags() int { return 0 }
 func (r *ASCIIRenderer) HRule(out *bytes.Buffer) {
 	out.WriteString(linebreak + "----------" + linebreak)
 }
-func (r *ASCIIRenderer) LineBreak(out *bytes.Buffer)                                      { out.WriteString(linebreak) }
-func (r *ASCIIRenderer) TitleBlock(out *bytes.Buffer, text []byte)                        { r.fw(out, text) }
-func (r *ASCIIRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) { text() }
-func (r *ASCIIRenderer) BlockHtml(out *bytes.Buffer, text []byte)                         { r.fw(out, text) }
-func (r *ASCIIRenderer) BlockQuote(out *bytes.Buffer, text []byte)                        { r.fw(out, text) }
-func (r *ASCIIRenderer) TableRow(out *bytes.Buffer, text []byte)                          { r.fw(out, text) }
-func (r *ASCIIRenderer) TableHeaderCell(out *bytes.Buffer, text []byte, align int)        { r.fw(out, text) }
-func (r *ASCIIRenderer) TableCell(out *bytes.Buffer, text []byte, align int)              { r.fw(out, text) }
-func (r *ASCIIRenderer) Footnotes(out *bytes.Buffer, text func() bool)                    { text() }
+func (r *ASCIIRenderer) LineBreak(out *bytes.Buffer)                   { out.WriteString(linebreak) }
+func (r *ASCIIRenderer) TitleBlock(out *bytes.Buffer, text []byte)     { r.fw(out, text) }
+func (r *ASCIIRenderer) BlockQuote(out *bytes.Buffer, text []byte)     { r.fw(out, text) }
+func (r *ASCIIRenderer) Footnotes(out *bytes.Buffer, text func() bool) { text() }
 func (r *ASCIIRenderer) FootnoteItem(out *bytes.Buffer, name, text []byte, flags int) {
 	r.fw(out, text)
 }
@@ -101,11 +181,9 @@ func (r *ASCIIRenderer) AutoLink(out *bytes.Buffer, link []byte, kind int)
 func (r *ASCIIRenderer) CodeSpan(out *bytes.Buffer, text []byte)                   { r.fw(out, text) }
 func (r *ASCIIRenderer) DoubleEmphasis(out *bytes.Buffer, text []byte)             { r.fw(out, text) }
 func (r *ASCIIRenderer) Emphasis(out *bytes.Buffer, text []byte)                   { r.fw(out, text) }
-func (r *ASCIIRenderer) RawHtmlTag(out *bytes.Buffer, text []byte)                 { r.fw(out, text) }
 func (r *ASCIIRenderer) TripleEmphasis(out *bytes.Buffer, text []byte)             { r.fw(out, text) }
 func (r *ASCIIRenderer) StrikeThrough(out *bytes.Buffer, text []byte)              { r.fw(out, text) }
 func (r *ASCIIRenderer) FootnoteRef(out *bytes.Buffer, ref []byte, id int)         { r.fw(out, ref) }
-func (r *ASCIIRenderer) Entity(out *bytes.Buffer, entity []byte)                   { r.fw(out, entity) }
 func (r *ASCIIRenderer) Smartypants(out *bytes.Buffer, text []byte)                { r.fw(out, text) }
 func (r *ASCIIRenderer) DocumentHeader(out *bytes.Buffer)                          {}
 func (r *ASCIIRenderer) DocumentFooter(out *bytes.Buffer)                          {}
@@ -113,16 +191,13 @@ func (r *ASCIIRenderer) TocHeaderWithAnchor(text []byte, level int, anchor strin
 func (r *ASCIIRenderer) TocHeader(text []byte, level int)                          {}
 func (r *ASCIIRenderer) TocFinalize()                                              {}
 
-func (r *ASCIIRenderer) Table(out *bytes.Buffer, header []byte, body []byte, columnData []int) {
-	r.fw(out, header, body)
-}
-
-func (r *ASCIIRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
-	r.fw(out, link)
-}
-
-func (r *ASCIIRenderer) Image(out *bytes.Buffer, link []byte, title []byte, alt []byte) {
-	r.fw(out, link)
+func (r *ASCIIRenderer) writeLink(out *bytes.Buffer, link, text []byte) {
+	text = bytes.TrimSpace(text)
+	if len(text) == 0 || bytes.Equal(text, link) {
+		r.fw(out, link)
+		return
+	}
+	r.fw(out, text, []byte(" ("), link, []byte(")"))
 }
 
 func (r *ASCIIRenderer) fw(out io.Writer, text ...[]byte) {
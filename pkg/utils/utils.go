@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"path"
 	"strings"
 )
 
@@ -47,3 +48,34 @@ func Exists(path string) (bool, error) {
 	}
 	return false, err
 }
+
+// MatchGlob returns whether the slash separated file path matches the glob pattern. A `**` element matches any number
+// of directories, including none, & the other elements are matched with path.Match. A pattern ending with a slash
+// matches everything in the directory.
+func MatchGlob(pattern, filePath string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchGlobElems(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(filePath, "/"))
+}
+
+func matchGlobElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchGlobElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], elems[0]); err != nil || !matched {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
@@ -36,3 +36,73 @@ func TestUtils_CommaSeperated(t *testing.T) {
 		})
 	}
 }
+
+func TestUtils_MatchGlob(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pattern  string
+		filePath string
+		expected bool
+	}{
+		{
+			name:     "ExactPath",
+			pattern:  "api/main.go",
+			filePath: "api/main.go",
+			expected: true,
+		},
+		{
+			name:     "Wildcard",
+			pattern:  "api/*.go",
+			filePath: "api/main.go",
+			expected: true,
+		},
+		{
+			name:     "WildcardDoesNotMatchSubdirectories",
+			pattern:  "api/*.go",
+			filePath: "api/handlers/main.go",
+			expected: false,
+		},
+		{
+			name:     "DoubleStar",
+			pattern:  "api/**",
+			filePath: "api/handlers/v1/main.go",
+			expected: true,
+		},
+		{
+			name:     "DoubleStarInMiddle",
+			pattern:  "**/*.sql",
+			filePath: "migrations/0001_init.sql",
+			expected: true,
+		},
+		{
+			name:     "DoubleStarMatchesNoDirectories",
+			pattern:  "api/**/main.go",
+			filePath: "api/main.go",
+			expected: true,
+		},
+		{
+			name:     "Directory",
+			pattern:  "web/",
+			filePath: "web/src/index.ts",
+			expected: true,
+		},
+		{
+			name:     "LeadingSlash",
+			pattern:  "/api/**",
+			filePath: "api/main.go",
+			expected: true,
+		},
+		{
+			name:     "DifferentDirectory",
+			pattern:  "api/**",
+			filePath: "web/api/main.go",
+			expected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, utils.MatchGlob(tt.pattern, tt.filePath))
+		})
+	}
+}
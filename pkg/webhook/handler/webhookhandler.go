@@ -1,6 +1,8 @@
 package webhookhandler
 
 import (
+	"strings"
+
 	"github.com/cbrgm/githubevents/githubevents"
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-github/v48/github"
@@ -79,19 +81,32 @@ func (h *Handler) handlePullRequestClosedEvent(_ string, _ string, event *github
 	return h.useCase.RunPeacock(models.MarshalPullRequestEvent(event))
 }
 
-// handlePullRequestEditEvent starts a dry-run when a PR has been edited (e.g. body/title changed)
+// handlePullRequestEditEvent starts a dry-run when a PR has been edited (e.g. body/title changed). If the PR has
+// already been merged then the messages that were sent are edited to match the new release notes instead.
 func (h *Handler) handlePullRequestEditEvent(_ string, _ string, event *github.PullRequestEvent) error {
-	log.Infof("%s/PR-%d edited. Starting dry-run.", *event.Repo.Name, *event.PullRequest.Number)
 	if *event.PullRequest.State == models.ClosedState {
-		// No need to handle closed
-		log.Info("PR is closed. Skipping.")
-		return nil
+		if !event.PullRequest.GetMerged() {
+			// No need to handle closed
+			log.Infof("%s/PR-%d edited but is closed. Skipping.", *event.Repo.Name, *event.PullRequest.Number)
+			return nil
+		}
+		log.Infof("%s/PR-%d edited after merge. Updating sent messages.", *event.Repo.Name, *event.PullRequest.Number)
+		return h.useCase.UpdatePeacock(models.MarshalPullRequestEvent(event))
 	}
+	log.Infof("%s/PR-%d edited. Starting dry-run.", *event.Repo.Name, *event.PullRequest.Number)
 	return h.useCase.ValidatePeacock(models.MarshalPullRequestEvent(event))
 }
 
-// handleIssueCommentCreatedEvent starts a dry-run when a comment has been created on a PR
+// handleIssueCommentCreatedEvent starts a dry-run when a comment has been created on a PR, or resends the release notes
+// of a merged PR if the comment is the resend command
 func (h *Handler) handleIssueCommentCreatedEvent(_ string, _ string, event *github.IssueCommentEvent) error {
+	if strings.TrimSpace(event.Comment.GetBody()) == webhookuc.ResendCommand {
+		if !event.Issue.IsPullRequest() {
+			return nil
+		}
+		log.Infof("%s/PR-%d resend requested.", *event.Repo.Name, *event.Issue.Number)
+		return h.useCase.ResendPeacock(models.MarshalIssueCommentCreatedEvent(event), event.Comment.GetUser().GetLogin())
+	}
 	log.Infof("%s/PR-%d comments edited. Starting dry-run.", *event.Repo.Name, *event.Issue.Number)
 	if *event.Issue.State == models.ClosedState {
 		// No need to handle closed
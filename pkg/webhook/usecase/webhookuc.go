@@ -2,15 +2,26 @@ package webhookuc
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"strings"
+	"time"
 
-	"github.com/google/go-github/v48/github"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spring-financial-group/peacock/pkg/config"
 	"github.com/spring-financial-group/peacock/pkg/domain"
 	"github.com/spring-financial-group/peacock/pkg/git/comment"
 	"github.com/spring-financial-group/peacock/pkg/models"
+	"github.com/spring-financial-group/peacock/pkg/utils"
+)
+
+const (
+	// ResendCommand is the comment an admin makes on a merged PR to send its release notes again
+	ResendCommand = "/peacock resend"
+	// AdminPermission is the permission level a user needs to resend release notes
+	AdminPermission = "admin"
 )
 
 type WebHookUseCase struct {
@@ -19,12 +30,14 @@ type WebHookUseCase struct {
 	notesUC   domain.ReleaseNotesUseCase
 	featherUC domain.FeathersUseCase
 	releaseUC domain.ReleaseUseCase
+	outboxUC  domain.OutboxUseCase
+	ledgerUC  domain.DeliveryLedgerUseCase
 
 	feathers    map[int64]*feathersMeta
 	prTemplates map[int64]*prTemplateMeta
 }
 
-func NewUseCase(cfg *config.SCM, scm domain.SCM, notesUC domain.ReleaseNotesUseCase, feathersUC domain.FeathersUseCase, releaseUC domain.ReleaseUseCase) *WebHookUseCase {
+func NewUseCase(cfg *config.SCM, scm domain.SCM, notesUC domain.ReleaseNotesUseCase, feathersUC domain.FeathersUseCase, releaseUC domain.ReleaseUseCase, outboxUC domain.OutboxUseCase, ledgerUC domain.DeliveryLedgerUseCase) *WebHookUseCase {
 	return &WebHookUseCase{
 		cfg:         cfg,
 		scm:         scm,
@@ -32,6 +45,8 @@ func NewUseCase(cfg *config.SCM, scm domain.SCM, notesUC domain.ReleaseNotesUseC
 		featherUC:   feathersUC,
 		feathers:    make(map[int64]*feathersMeta),
 		releaseUC:   releaseUC,
+		outboxUC:    outboxUC,
+		ledgerUC:    ledgerUC,
 		prTemplates: make(map[int64]*prTemplateMeta),
 	}
 }
@@ -61,12 +76,12 @@ func (w *WebHookUseCase) ValidatePeacock(e *models.PullRequestEventDTO) error {
 	}
 
 	// Get the feathers for the pull request, should cache this as this will run for any edited event
-	feathers, err := w.getFeathers(ctx, e.Branch, e)
+	feathers, warnings, err := w.getFeathers(ctx, e.Branch, e)
 	if err != nil {
 		return w.handleError(ctx, domain.ValidationContext, e, err)
 	}
 
-	releaseNotes, err := w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(e.Body, feathers.Teams)
+	releaseNotes, err := w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(e.Body, feathers)
 	if err != nil {
 		return w.handleError(ctx, domain.ValidationContext, e, errors.Wrap(err, "failed to parse release notes from markdown"))
 	}
@@ -76,7 +91,7 @@ func (w *WebHookUseCase) ValidatePeacock(e *models.PullRequestEventDTO) error {
 	}
 
 	// ensure the current notes aren't the same as the template, as we don't want default messages being sent out
-	templateReleaseNotes, err := w.getPRTemplateOrDefault(ctx, e.Branch, e, feathers.Teams)
+	templateReleaseNotes, err := w.getPRTemplateOrDefault(ctx, e.Branch, e, feathers)
 	if err != nil {
 		return w.handleError(ctx, domain.ValidationContext, e, err)
 	}
@@ -87,11 +102,22 @@ func (w *WebHookUseCase) ValidatePeacock(e *models.PullRequestEventDTO) error {
 		return w.handleError(ctx, domain.ValidationContext, e, errors.New("release notes cannot be the same as the pull request template"))
 	}
 
+	// Check that the teams that own the paths changed by the pull request are notified
+	missingTeams, err := w.getMissingPathOwners(ctx, e, feathers, releaseNotes)
+	if err != nil {
+		return w.handleError(ctx, domain.ValidationContext, e, err)
+	}
+	if len(missingTeams) > 0 && feathers.Config.Paths.Required {
+		log.Infof("release notes don't notify the owners of the changed paths, failing")
+		return w.handleError(ctx, domain.ValidationContext, e, errors.Errorf("release notes must notify the teams that own the paths changed, add \"### Notify %s\"", utils.CommaSeparated(missingTeams)))
+	}
+
 	// Prevent doing work if the new release notes are same as the previous release notes
 	newHash, err := w.notesUC.GenerateHash(releaseNotes)
 	if err != nil {
 		return w.handleError(ctx, domain.ValidationContext, e, errors.Wrap(err, "failed to generate message hash"))
 	}
+	newHash = breakdownHash(newHash, missingTeams, warnings)
 
 	// Compare the previous hash to the current one, stop here if there are no changes (there's no work to do)
 	comments, err := w.scm.GetPRCommentsByUser(ctx, e.RepoOwner, e.RepoName, e.PRNumber)
@@ -109,7 +135,7 @@ func (w *WebHookUseCase) ValidatePeacock(e *models.PullRequestEventDTO) error {
 	}
 
 	// Break down the release notes to prove we've parsed them and to check the formatting
-	breakdown, err := w.notesUC.GenerateBreakdown(releaseNotes, newHash, len(feathers.Teams))
+	breakdown, err := w.notesUC.GenerateBreakdown(releaseNotes, newHash, len(feathers.Teams), missingTeams, warnings)
 	if err != nil {
 		return w.handleError(ctx, domain.ValidationContext, e, errors.Wrap(err, "failed to generate message breakdown"))
 	}
@@ -134,6 +160,48 @@ func (w *WebHookUseCase) ValidatePeacock(e *models.PullRequestEventDTO) error {
 }
 
 func (w *WebHookUseCase) RunPeacock(e *models.PullRequestEventDTO) error {
+	return w.runPeacock(e, false)
+}
+
+// ResendPeacock sends the release notes of a merged PR again, including to the addresses they've already been delivered
+// to. Only admins of the repository can request a resend.
+func (w *WebHookUseCase) ResendPeacock(e *models.PullRequestEventDTO, requestedBy string) error {
+	ctx := context.Background()
+
+	permission, err := w.scm.GetUserPermission(ctx, e.RepoOwner, e.RepoName, requestedBy)
+	if err != nil {
+		return errors.Wrap(err, "failed to get permission of user requesting resend")
+	}
+	if permission != AdminPermission {
+		log.Infof("%s is not an admin of %s/%s, skipping resend", requestedBy, e.RepoOwner, e.RepoName)
+		return w.scm.CommentOnPR(ctx, e.RepoOwner, e.RepoName, e.PRNumber, fmt.Sprintf("@%s: only repository admins can resend release notes", requestedBy))
+	}
+
+	// The comment event doesn't contain the details of the pull request
+	pr, err := w.scm.GetPullRequest(ctx, e.RepoOwner, e.RepoName, e.PRNumber)
+	if err != nil {
+		return err
+	}
+	if !pr.GetMerged() {
+		log.Infof("%s/PR-%d is not merged, skipping resend", e.RepoName, e.PRNumber)
+		return w.scm.CommentOnPR(ctx, e.RepoOwner, e.RepoName, e.PRNumber, fmt.Sprintf("@%s: release notes can only be resent once the pull request is merged", requestedBy))
+	}
+	e.PullRequestID = pr.GetID()
+	e.PROwner = pr.GetUser().GetLogin()
+	e.Body = pr.GetBody()
+	e.Branch = pr.GetHead().GetRef()
+	e.SHA = pr.GetHead().GetSHA()
+	e.MergeSHA = pr.GetMergeCommitSHA()
+	e.MergedAt = pr.GetMergedAt()
+	e.Title = pr.GetTitle()
+
+	log.Infof("resend of release notes for %s/PR-%d requested by %s", e.RepoName, e.PRNumber, requestedBy)
+	return w.runPeacock(e, true)
+}
+
+// runPeacock sends the release notes of a merged PR. Unless forced, the notes that have already been delivered to an
+// address are not sent to it again, so that a redelivered webhook doesn't notify the teams twice.
+func (w *WebHookUseCase) runPeacock(e *models.PullRequestEventDTO, force bool) error {
 	ctx := context.Background()
 	defer w.CleanUp(e.PullRequestID)
 
@@ -155,13 +223,13 @@ func (w *WebHookUseCase) RunPeacock(e *models.PullRequestEventDTO) error {
 	}
 
 	// Get the feathers for the pull request, should cache this as this will run for any edited event
-	feathers, err := w.getFeathers(ctx, e.DefaultBranch, e)
+	feathers, _, err := w.getFeathers(ctx, e.DefaultBranch, e)
 	if err != nil {
 		return w.handleError(ctx, domain.ReleaseContext, e, err)
 	}
 
 	// Parse the PR body for any releaseNotes
-	releaseNotes, err := w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(e.Body, feathers.Teams)
+	releaseNotes, err := w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(e.Body, feathers)
 	if err != nil {
 		return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to parse release notes from markdown"))
 	}
@@ -170,44 +238,132 @@ func (w *WebHookUseCase) RunPeacock(e *models.PullRequestEventDTO) error {
 		return w.createCommitStatus(ctx, e, domain.SuccessState, defaultSHA, domain.ReleaseContext)
 	}
 
-	if err = w.notesUC.SendReleaseNotes(feathers.Config.Messages.Subject, releaseNotes); err != nil {
+	// The environment is found before the notes are sent so that it can be included in the messages
+	pr := e.Summary()
+	pr.Environment = w.getChangedEnv(ctx, e)
+
+	// The notes are wrapped before anything else so that the ledger & the record of the sent notes have the content that
+	// was actually sent
+	messages, err := w.notesUC.WrapReleaseNotes(feathers.Config.Messages, releaseNotes, pr)
+	if err != nil {
+		return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to wrap release notes in message template"))
+	}
+
+	notesToSend := messages
+	var duplicates []models.LedgerEntry
+	if !force {
+		// The deliveries are claimed before the notes are sent so that a redelivered webhook handled at the same time
+		// doesn't send them too
+		notesToSend, duplicates, err = w.ledgerUC.Claim(ctx, messages, pr)
+		if err != nil {
+			return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to check for release notes already delivered"))
+		}
+		for _, d := range duplicates {
+			if d.DeliveredAt.IsZero() {
+				log.Infof("release notes for %s already being delivered to %s via %s since %s, skipping", d.NoteKey, d.Address, d.ContactType, d.ClaimedAt.Format(time.RFC3339))
+				continue
+			}
+			log.Infof("release notes for %s already delivered to %s via %s at %s, skipping", d.NoteKey, d.Address, d.ContactType, d.DeliveredAt.Format(time.RFC3339))
+		}
+	}
+
+	// The notes are recorded before they're sent, the messages are added to the record as they're delivered so that
+	// they can be edited later. If some have already been delivered, or the notes are being resent, the record already
+	// exists & keeps their messages.
+	if len(duplicates) == 0 {
+		if err = w.notesUC.CreateSentReleaseNotes(ctx, feathers.Config.Messages.Subject, messages, pr); err != nil {
+			log.Error(errors.Wrap(err, "failed to save sent release notes"))
+		}
+	}
+	report, err := w.outboxUC.Deliver(ctx, feathers.Config.Messages.Subject, notesToSend, pr)
+	if err != nil {
+		return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to queue releaseNotes"))
+	}
+	log.Infof("%d message(s) sent", len(report.References()))
+
+	// The release is saved as long as some of the notes were delivered or queued to be sent again by the outbox, so
+	// that it isn't lost when the outbox delivers them later
+	queued := report.Queued()
+	if len(report) == 0 || len(report.References()) > 0 || len(queued) > 0 {
+		if pr.Environment != "" {
+			log.Infof("saving release for environment %s", pr.Environment)
+			err = w.releaseUC.SaveRelease(ctx, pr.Environment, releaseNotes, pr)
+			if err != nil {
+				return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to save release"))
+			}
+		} else {
+			log.Warn("environment not found for release, skipping save")
+		}
+	}
+
+	// Only the failures that won't be sent again are errors, the status stays pending while the others are retried
+	if err = report.Err(); err != nil {
 		return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to send releaseNotes"))
 	}
 
-	log.Infof("%d message(s) sent", len(releaseNotes))
+	switch {
+	case len(queued) > 0:
+		description := fmt.Sprintf("%d delivery(s) failed & will be retried", len(queued))
+		err = w.scm.CreatePeacockCommitStatusWithDescription(ctx, e.RepoOwner, e.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext, description)
+	case len(duplicates) > 0:
+		description := fmt.Sprintf("Skipped %d delivery(s) already made, comment \"%s\" to send again", len(duplicates), ResendCommand)
+		err = w.scm.CreatePeacockCommitStatusWithDescription(ctx, e.RepoOwner, e.RepoName, defaultSHA, domain.SuccessState, domain.ReleaseContext, description)
+	default:
+		err = w.createCommitStatus(ctx, e, domain.SuccessState, defaultSHA, domain.ReleaseContext)
+	}
+	if err != nil {
+		log.Errorf("failed to create release status: %v", err)
+	}
+	return nil
+}
 
-	files, err := w.scm.GetFilesChangedFromPR(ctx, e.RepoOwner, e.RepoName, e.PRNumber)
+// UpdatePeacock edits the messages already sent for a merged PR so that they match the release notes in its body
+func (w *WebHookUseCase) UpdatePeacock(e *models.PullRequestEventDTO) error {
+	ctx := context.Background()
+	defer w.CleanUp(e.PullRequestID)
+
+	feathers, _, err := w.getFeathers(ctx, e.DefaultBranch, e)
 	if err != nil {
-		return errors.Wrap(err, "failed to get changed files from pr")
+		return w.handleError(ctx, domain.ReleaseContext, e, err)
 	}
 
-	if changedEnvironment := w.getChangedEnv(files); changedEnvironment != "" {
-		log.Infof("saving release for environment %s", changedEnvironment)
-		err = w.releaseUC.SaveRelease(ctx, changedEnvironment, releaseNotes, e.Summary())
+	// An empty body means that all the release notes have been removed
+	var releaseNotes []models.ReleaseNote
+	if e.Body != "" {
+		releaseNotes, err = w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(e.Body, feathers)
 		if err != nil {
-			return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to save release"))
+			return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to parse release notes from markdown"))
 		}
-	} else {
-		log.Warn("environment not found for release, skipping save")
 	}
 
-	err = w.createCommitStatus(ctx, e, domain.SuccessState, defaultSHA, domain.ReleaseContext)
+	pr := e.Summary()
+	// The messages were sent wrapped in the template & with the subject rendered for the environment, so the environment
+	// is looked up for the notes & subject to be the same as when they were sent
+	pr.Environment = w.getChangedEnv(ctx, e)
+	releaseNotes, err = w.notesUC.WrapReleaseNotes(feathers.Config.Messages, releaseNotes, pr)
 	if err != nil {
-		log.Errorf("failed to create success status: %v", err)
+		return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to wrap release notes in message template"))
+	}
+
+	err = w.notesUC.UpdateSentReleaseNotes(ctx, feathers.Config.Messages.Subject, releaseNotes, pr)
+	if err != nil {
+		return w.handleError(ctx, domain.ReleaseContext, e, errors.Wrap(err, "failed to update sent release notes"))
 	}
 	return nil
 }
 
 type feathersMeta struct {
 	feathers *models.Feathers
+	warnings []domain.FeathersProblem
 	sha      string
 }
 
-func (w *WebHookUseCase) getFeathers(ctx context.Context, branch string, event *models.PullRequestEventDTO) (*models.Feathers, error) {
+// getFeathers returns the feathers for the branch along with the warnings found when validating them
+func (w *WebHookUseCase) getFeathers(ctx context.Context, branch string, event *models.PullRequestEventDTO) (*models.Feathers, []domain.FeathersProblem, error) {
 	// Get the feathers for the branch and check that it matches the sha
 	meta, ok := w.feathers[event.PullRequestID]
 	if ok && meta.sha == event.SHA {
-		return meta.feathers, nil
+		return meta.feathers, meta.warnings, nil
 	}
 
 	meta = &feathersMeta{
@@ -219,18 +375,18 @@ func (w *WebHookUseCase) getFeathers(ctx context.Context, branch string, event *
 		var errFileNotFound *domain.ErrFileNotFound
 		switch {
 		case errors.As(err, &errFileNotFound):
-			return nil, errors.New("feathers does not exist in branch")
+			return nil, nil, errors.New("feathers does not exist in branch")
 		default:
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	meta.feathers, err = w.featherUC.GetFeathersFromBytes(data)
+	meta.feathers, meta.warnings, err = w.featherUC.GetFeathersFromBytes(ctx, event.RepoOwner, data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	w.feathers[event.PullRequestID] = meta
-	return meta.feathers, nil
+	return meta.feathers, meta.warnings, nil
 }
 
 type prTemplateMeta struct {
@@ -238,7 +394,7 @@ type prTemplateMeta struct {
 	sha         string
 }
 
-func (w *WebHookUseCase) getPRTemplateOrDefault(ctx context.Context, branch string, event *models.PullRequestEventDTO, teamsInFeathers models.Teams) ([]models.ReleaseNote, error) {
+func (w *WebHookUseCase) getPRTemplateOrDefault(ctx context.Context, branch string, event *models.PullRequestEventDTO, feathers *models.Feathers) ([]models.ReleaseNote, error) {
 	// Get the release notes for the branch and check that it matches the sha
 	meta, ok := w.prTemplates[event.PullRequestID]
 	if ok && meta.sha == event.SHA {
@@ -262,7 +418,7 @@ func (w *WebHookUseCase) getPRTemplateOrDefault(ctx context.Context, branch stri
 		}
 	}
 
-	meta.prTemplates, err = w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(string(data[:]), teamsInFeathers)
+	meta.prTemplates, err = w.notesUC.GetReleaseNotesFromMarkdownAndTeamsInFeathers(string(data[:]), feathers)
 	if err != nil {
 		return nil, err
 	}
@@ -270,6 +426,54 @@ func (w *WebHookUseCase) getPRTemplateOrDefault(ctx context.Context, branch stri
 	return meta.prTemplates, nil
 }
 
+// getMissingPathOwners returns the teams that own the paths changed by the pull request but aren't notified by any of
+// its release notes
+func (w *WebHookUseCase) getMissingPathOwners(ctx context.Context, e *models.PullRequestEventDTO, feathers *models.Feathers, notes []models.ReleaseNote) ([]string, error) {
+	if len(feathers.Paths) == 0 {
+		return nil, nil
+	}
+
+	files, err := w.scm.GetFilesChangedFromPR(ctx, e.RepoOwner, e.RepoName, e.PRNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changed files from pr")
+	}
+	filenames := make([]string, 0, len(files))
+	for _, file := range files {
+		filenames = append(filenames, file.GetFilename())
+	}
+
+	owners, err := feathers.GetPathOwners(filenames)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the owners of the changed paths")
+	}
+
+	var notified []string
+	for _, note := range notes {
+		notified = append(notified, note.Teams.GetAllTeamNames()...)
+	}
+	var missing []string
+	for _, owner := range owners {
+		if !utils.ExistsInSlice(owner, notified) {
+			missing = append(missing, owner)
+		}
+	}
+	return missing, nil
+}
+
+// breakdownHash adds the missing teams & the warnings about the feathers to the hash of the release notes. They change
+// with the files in the pull request rather than its body, so the breakdown has to be updated when they change.
+func breakdownHash(notesHash string, missingTeams []string, warnings []domain.FeathersProblem) string {
+	if len(missingTeams) == 0 && len(warnings) == 0 {
+		return notesHash
+	}
+	h := sha256.New()
+	h.Write([]byte(notesHash + strings.Join(missingTeams, ",")))
+	for _, w := range warnings {
+		h.Write([]byte(w.String()))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (w *WebHookUseCase) CleanUp(pullRequestID int64) {
 	delete(w.feathers, pullRequestID)
 }
@@ -282,7 +486,14 @@ func (w *WebHookUseCase) createCommitStatus(ctx context.Context, e *models.PullR
 	return w.scm.CreatePeacockCommitStatus(ctx, e.RepoOwner, e.RepoName, sha, state, context)
 }
 
-func (w *WebHookUseCase) getChangedEnv(files []*github.CommitFile) string {
+// getChangedEnv returns the environment of the helmfiles changed by the PR. The environment is only informational, so if
+// the changed files can't be listed the error is logged & no environment is returned rather than failing the release.
+func (w *WebHookUseCase) getChangedEnv(ctx context.Context, e *models.PullRequestEventDTO) string {
+	files, err := w.scm.GetFilesChangedFromPR(ctx, e.RepoOwner, e.RepoName, e.PRNumber)
+	if err != nil {
+		log.Error(errors.Wrap(err, "failed to get changed files from pr, continuing without an environment"))
+		return ""
+	}
 	for _, file := range files {
 		splitPath := strings.Split(*file.Filename, "/")
 		for index, path := range splitPath {
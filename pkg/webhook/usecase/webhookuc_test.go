@@ -2,6 +2,7 @@ package webhookuc
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/google/go-github/v48/github"
@@ -106,7 +107,8 @@ func TestWebHookUseCase_ValidatePeacock(t *testing.T) {
 		mockSCM := mocks.NewSCM(t)
 		mockNotesUC := mocks.NewReleaseNotesUseCase(t)
 		mockReleaseUC := mocks.NewReleaseUseCase(t)
-		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(), mockReleaseUC)
+		mockOutboxUC := mocks.NewOutboxUseCase(t)
+		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
 		uc.prTemplates = make(map[int64]*prTemplateMeta)
 		mockEvent := mockPullRequestEventDTO
 		mockEvent.Body = prBody
@@ -120,10 +122,10 @@ func TestWebHookUseCase_ValidatePeacock(t *testing.T) {
 		mockSCM.On("CommentOnPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber, mock.Anything).Return(nil).Once()
 		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.SHA, domain.SuccessState, domain.ValidationContext).Return(nil).Once()
 
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, allTeams).Return(mockNotes, nil).Once()
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), allTeams).Return(mockTemplateNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), mockFeathers).Return(mockTemplateNotes, nil).Once()
 		mockNotesUC.On("GenerateHash", mockNotes).Return(mockHash, nil)
-		mockNotesUC.On("GenerateBreakdown", mockNotes, mockHash, 2).Return("", nil)
+		mockNotesUC.On("GenerateBreakdown", mockNotes, mockHash, 2, []string(nil), []domain.FeathersProblem(nil)).Return("", nil)
 
 		err := uc.ValidatePeacock(mockEvent)
 		assert.NoError(t, err)
@@ -133,7 +135,8 @@ func TestWebHookUseCase_ValidatePeacock(t *testing.T) {
 		mockSCM := mocks.NewSCM(t)
 		mockNotesUC := mocks.NewReleaseNotesUseCase(t)
 		mockReleaseUC := mocks.NewReleaseUseCase(t)
-		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(), mockReleaseUC)
+		mockOutboxUC := mocks.NewOutboxUseCase(t)
+		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
 		uc.prTemplates = make(map[int64]*prTemplateMeta)
 		mockEvent := mockPullRequestEventDTO
 		mockEvent.Body = prBody
@@ -143,7 +146,7 @@ func TestWebHookUseCase_ValidatePeacock(t *testing.T) {
 		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".github/pull_request_template.md").Return([]byte(prBody), nil).Once()
 		mockSCM.On("HandleError", mockCTX, domain.ValidationContext, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber, mockEvent.SHA, mockEvent.RepoOwner, mock.Anything).Return(errors.New("release notes cannot be the same as the pull request template")).Once()
 
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, allTeams).Return(mockNotes, nil).Twice()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil).Twice()
 
 		err := uc.ValidatePeacock(mockEvent)
 		assert.Error(t, err)
@@ -153,7 +156,8 @@ func TestWebHookUseCase_ValidatePeacock(t *testing.T) {
 		mockSCM := mocks.NewSCM(t)
 		mockNotesUC := mocks.NewReleaseNotesUseCase(t)
 		mockReleaseUC := mocks.NewReleaseUseCase(t)
-		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(), mockReleaseUC)
+		mockOutboxUC := mocks.NewOutboxUseCase(t)
+		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
 		uc.prTemplates = make(map[int64]*prTemplateMeta)
 		mockEvent := mockPullRequestEventDTO
 		mockEvent.Body = prBody
@@ -167,50 +171,348 @@ func TestWebHookUseCase_ValidatePeacock(t *testing.T) {
 		mockSCM.On("CommentOnPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber, mock.Anything).Return(nil).Once()
 		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.SHA, domain.SuccessState, domain.ValidationContext).Return(nil).Once()
 
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, allTeams).Return(mockNotes, nil).Once()
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), allTeams).Return([]models.ReleaseNote{}, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), mockFeathers).Return([]models.ReleaseNote{}, nil).Once()
 		mockNotesUC.On("GenerateHash", mockNotes).Return(mockHash, nil)
-		mockNotesUC.On("GenerateBreakdown", mockNotes, mockHash, 2).Return("", nil)
+		mockNotesUC.On("GenerateBreakdown", mockNotes, mockHash, 2, []string(nil), []domain.FeathersProblem(nil)).Return("", nil)
 
 		err := uc.ValidatePeacock(mockEvent)
 		assert.NoError(t, err)
 	})
+
+	pathsFeathers := &models.Feathers{
+		Teams: allTeams,
+		Paths: map[string][]string{
+			"infra/**": {infraTeam.Name},
+			"api/**":   {productTeam.Name},
+		},
+		Config: models.Config{
+			Messages: models.Messages{
+				Subject: "Subject",
+			},
+		},
+	}
+	pathsFilesChanged := []*github.CommitFile{
+		{Filename: github.String("api/handlers/main.go")},
+		{Filename: github.String("README.md")},
+	}
+	infraNotes := []models.ReleaseNote{
+		{
+			Teams:   models.Teams{infraTeam},
+			Content: "Hello infra",
+		},
+	}
+
+	t.Run("should suggest the owners of the changed paths that aren't notified", func(t *testing.T) {
+		mockSCM := mocks.NewSCM(t)
+		mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mocks.NewReleaseUseCase(t), mocks.NewOutboxUseCase(t), mocks.NewDeliveryLedgerUseCase(t))
+		mockEvent := mockPullRequestEventDTO
+		mockEvent.Body = prBody
+		pathsFeathersData, _ := yaml.Marshal(pathsFeathers)
+
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.SHA, domain.PendingState, domain.ValidationContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".peacock/feathers.yaml").Return(pathsFeathersData, nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".github/pull_request_template.md").Return(templateContent, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(pathsFilesChanged, nil).Once()
+
+		mockSCM.On("GetPRCommentsByUser", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(nil, nil).Once()
+		mockSCM.On("DeleteUsersComments", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(nil).Once()
+		mockSCM.On("CommentOnPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber, mock.Anything).Return(nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.SHA, domain.SuccessState, domain.ValidationContext).Return(nil).Once()
+
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, pathsFeathers).Return(infraNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), pathsFeathers).Return(mockTemplateNotes, nil).Once()
+		mockNotesUC.On("GenerateHash", infraNotes).Return(mockHash, nil)
+		mockNotesUC.On("GenerateBreakdown", infraNotes, mock.Anything, 2, []string{productTeam.Name}, []domain.FeathersProblem(nil)).Return("", nil)
+
+		err := uc.ValidatePeacock(mockEvent)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail when the owners of the changed paths are required and not notified", func(t *testing.T) {
+		mockSCM := mocks.NewSCM(t)
+		mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+		uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mocks.NewReleaseUseCase(t), mocks.NewOutboxUseCase(t), mocks.NewDeliveryLedgerUseCase(t))
+		mockEvent := mockPullRequestEventDTO
+		mockEvent.Body = prBody
+		requiredFeathers := *pathsFeathers
+		requiredFeathers.Config.Paths.Required = true
+		requiredFeathersData, _ := yaml.Marshal(requiredFeathers)
+
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.SHA, domain.PendingState, domain.ValidationContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".peacock/feathers.yaml").Return(requiredFeathersData, nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".github/pull_request_template.md").Return(templateContent, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(pathsFilesChanged, nil).Once()
+		mockSCM.On("HandleError", mockCTX, domain.ValidationContext, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber, mockEvent.SHA, mockEvent.RepoOwner, mock.MatchedBy(func(err error) bool {
+			return strings.Contains(err.Error(), "### Notify product")
+		})).Return(errors.New("release notes must notify the teams that own the paths changed")).Once()
+
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, &requiredFeathers).Return(infraNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), &requiredFeathers).Return(mockTemplateNotes, nil).Once()
+
+		err := uc.ValidatePeacock(mockEvent)
+		assert.Error(t, err)
+	})
 }
 
 func TestWebHookUseCase_RunPeacock(t *testing.T) {
 	mockSCM := mocks.NewSCM(t)
 	mockNotesUC := mocks.NewReleaseNotesUseCase(t)
 	mockReleaseUC := mocks.NewReleaseUseCase(t)
+	mockOutboxUC := mocks.NewOutboxUseCase(t)
+	mockLedgerUC := mocks.NewDeliveryLedgerUseCase(t)
 
 	cfg := &config.SCM{
 		User: RepoOwner,
 	}
 
-	uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(), mockReleaseUC)
+	uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mockLedgerUC)
+
+	mockEvent := mockPullRequestEventDTO
+	mockEvent.Body = prBody
+	mockFilesChanged := []*github.CommitFile{
+		{
+			Filename: github.String("helmfiles/staging/helmfile.yaml"),
+		},
+	}
+	defaultSHA := "default-SHA"
+	// The environment changed by the PR is included in the messages
+	releasedPR := mockEvent.Summary()
+	releasedPR.Environment = "staging"
 
 	t.Run("Happy Path", func(t *testing.T) {
-		mockEvent := mockPullRequestEventDTO
-		mockEvent.Body = prBody
-		mockFilesChanged := []*github.CommitFile{
-			{
-				Filename: github.String("helmfiles/staging/helmfile.yaml"),
-			},
+		mockSCM.On("GetLatestCommitSHAInBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch).Return(defaultSHA, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.SuccessState, domain.ReleaseContext).Return(nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(mockFilesChanged, nil).Once()
+
+		// The notes wrapped in the message template are sent & recorded, the release keeps the notes from the PR
+		wrappedNotes := make([]models.ReleaseNote, len(mockNotes))
+		for i, n := range mockNotes {
+			wrappedNotes[i] = models.ReleaseNote{Teams: n.Teams, Content: "From peacock:\n" + n.Content}
 		}
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil)
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, releasedPR).Return(wrappedNotes, nil).Once()
+		mockLedgerUC.On("Claim", mockCTX, wrappedNotes, releasedPR).Return(wrappedNotes, nil, nil).Once()
+		mockNotesUC.On("CreateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, wrappedNotes, releasedPR).Return(nil).Once()
+		mockReport := models.DeliveryReport{{MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C02TE2EMTMK", ID: "1"}}}
+		mockOutboxUC.On("Deliver", mockCTX, mockFeathers.Config.Messages.Subject, wrappedNotes, releasedPR).Return(mockReport, nil).Once()
 
-		defaultSHA := "default-SHA"
+		mockReleaseUC.On("SaveRelease", mockCTX, "staging", mockNotes, releasedPR).Return(nil).Once()
+
+		err := uc.RunPeacock(mockEvent)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Redelivered Webhook", func(t *testing.T) {
 		mockSCM.On("GetLatestCommitSHAInBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch).Return(defaultSHA, nil).Once()
 		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext).Return(nil).Once()
 		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(mockFilesChanged, nil).Once()
 
-		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.SuccessState, domain.ReleaseContext).Return(nil).Once()
+		// The infra note has already been delivered so only the product note is sent & the record of the sent notes is
+		// left as it is
+		duplicates := []models.LedgerEntry{{PullRequest: releasedPR, NoteHash: mockHash, NoteKey: "infrastructure", ContactType: models.Slack, Address: "C02TE2EMTMK"}}
+		remaining := mockNotes[1:]
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil)
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, releasedPR).Return(mockNotes, nil).Once()
+		mockLedgerUC.On("Claim", mockCTX, mockNotes, releasedPR).Return(remaining, duplicates, nil).Once()
+		mockOutboxUC.On("Deliver", mockCTX, mockFeathers.Config.Messages.Subject, remaining, releasedPR).Return(nil, nil).Once()
+		mockReleaseUC.On("SaveRelease", mockCTX, "staging", mockNotes, releasedPR).Return(nil).Once()
+
+		mockSCM.On("CreatePeacockCommitStatusWithDescription", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.SuccessState, domain.ReleaseContext, mock.MatchedBy(func(description string) bool {
+			return strings.HasPrefix(description, "Skipped 1 delivery(s) already made")
+		})).Return(nil).Once()
+
+		err := uc.RunPeacock(mockEvent)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Retry Queued", func(t *testing.T) {
+		mockSCM.On("GetLatestCommitSHAInBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch).Return(defaultSHA, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
 		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(mockFilesChanged, nil).Once()
 
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, allTeams).Return(mockNotes, nil)
-		mockNotesUC.On("SendReleaseNotes", mockFeathers.Config.Messages.Subject, mockNotes).Return(nil)
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil)
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, releasedPR).Return(mockNotes, nil).Once()
+		mockLedgerUC.On("Claim", mockCTX, mockNotes, releasedPR).Return(mockNotes, nil, nil).Once()
+		mockNotesUC.On("CreateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, releasedPR).Return(nil).Once()
+		// None of the notes were delivered on the first attempt but the outbox will send them again
+		mockReport := models.DeliveryReport{{
+			MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C02TE2EMTMK"},
+			Err:              errors.New("rate limited"),
+			Retryable:        true,
+			Queued:           true,
+		}}
+		mockOutboxUC.On("Deliver", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, releasedPR).Return(mockReport, nil).Once()
+
+		// The release is saved & the status is left pending rather than failed
+		mockReleaseUC.On("SaveRelease", mockCTX, "staging", mockNotes, releasedPR).Return(nil).Once()
+		mockSCM.On("CreatePeacockCommitStatusWithDescription", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext, "1 delivery(s) failed & will be retried").Return(nil).Once()
+
+		err := uc.RunPeacock(mockEvent)
+		assert.NoError(t, err)
+	})
 
-		mockReleaseUC.On("SaveRelease", mockCTX, "staging", mockNotes, mockPullRequestEventDTO.Summary()).Return(nil).Once()
+	t.Run("Delivery Failed", func(t *testing.T) {
+		mockSCM.On("GetLatestCommitSHAInBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch).Return(defaultSHA, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(mockFilesChanged, nil).Once()
+
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil)
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, releasedPR).Return(mockNotes, nil).Once()
+		mockLedgerUC.On("Claim", mockCTX, mockNotes, releasedPR).Return(mockNotes, nil, nil).Once()
+		mockNotesUC.On("CreateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, releasedPR).Return(nil).Once()
+		mockReport := models.DeliveryReport{{
+			MessageReference: models.MessageReference{ContactType: models.Slack, NoteKey: "infrastructure", Address: "C02TE2EMTMK"},
+			Err:              errors.New("channel_not_found"),
+		}}
+		mockOutboxUC.On("Deliver", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, releasedPR).Return(mockReport, nil).Once()
+
+		// Nothing was delivered or will be, so the release isn't saved & the failure is reported
+		mockSCM.On("HandleError", mockCTX, domain.ReleaseContext, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber, mockEvent.SHA, mockEvent.PROwner, mock.MatchedBy(func(err error) bool {
+			return strings.Contains(err.Error(), "channel_not_found")
+		})).Return(errors.New("failed to send releaseNotes")).Once()
 
 		err := uc.RunPeacock(mockEvent)
+		assert.Error(t, err)
+	})
+
+	t.Run("Changed Files Unavailable", func(t *testing.T) {
+		mockSCM.On("GetLatestCommitSHAInBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch).Return(defaultSHA, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(nil, errors.New("rate limited")).Once()
+
+		// The notes are still sent without an environment, so the release isn't saved
+		unreleasedPR := mockEvent.Summary()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil)
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, unreleasedPR).Return(mockNotes, nil).Once()
+		mockLedgerUC.On("Claim", mockCTX, mockNotes, unreleasedPR).Return(mockNotes, nil, nil).Once()
+		mockNotesUC.On("CreateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, unreleasedPR).Return(nil).Once()
+		mockOutboxUC.On("Deliver", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, unreleasedPR).Return(nil, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, defaultSHA, domain.SuccessState, domain.ReleaseContext).Return(nil).Once()
+
+		err := uc.RunPeacock(mockEvent)
+		assert.NoError(t, err)
+	})
+}
+
+func TestWebHookUseCase_ResendPeacock(t *testing.T) {
+	const admin = "some-admin"
+
+	t.Run("Not Admin", func(t *testing.T) {
+		mockSCM := mocks.NewSCM(t)
+		uc := NewUseCase(&config.SCM{User: RepoOwner}, mockSCM, mocks.NewReleaseNotesUseCase(t), feathers.NewUseCase(&config.Feathers{}, nil), mocks.NewReleaseUseCase(t), mocks.NewOutboxUseCase(t), mocks.NewDeliveryLedgerUseCase(t))
+
+		mockSCM.On("GetUserPermission", mockCTX, RepoOwner, RepoName, "some-user").Return("write", nil).Once()
+		mockSCM.On("CommentOnPR", mockCTX, RepoOwner, RepoName, PRNumber, "@some-user: only repository admins can resend release notes").Return(nil).Once()
+
+		err := uc.ResendPeacock(&models.PullRequestEventDTO{RepoOwner: RepoOwner, RepoName: RepoName, PRNumber: PRNumber}, "some-user")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Not Merged", func(t *testing.T) {
+		mockSCM := mocks.NewSCM(t)
+		uc := NewUseCase(&config.SCM{User: RepoOwner}, mockSCM, mocks.NewReleaseNotesUseCase(t), feathers.NewUseCase(&config.Feathers{}, nil), mocks.NewReleaseUseCase(t), mocks.NewOutboxUseCase(t), mocks.NewDeliveryLedgerUseCase(t))
+
+		mockSCM.On("GetUserPermission", mockCTX, RepoOwner, RepoName, admin).Return(AdminPermission, nil).Once()
+		mockSCM.On("GetPullRequest", mockCTX, RepoOwner, RepoName, PRNumber).Return(&github.PullRequest{Merged: github.Bool(false)}, nil).Once()
+		mockSCM.On("CommentOnPR", mockCTX, RepoOwner, RepoName, PRNumber, "@some-admin: release notes can only be resent once the pull request is merged").Return(nil).Once()
+
+		err := uc.ResendPeacock(&models.PullRequestEventDTO{RepoOwner: RepoOwner, RepoName: RepoName, PRNumber: PRNumber}, admin)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Resent", func(t *testing.T) {
+		mockSCM := mocks.NewSCM(t)
+		mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+		mockReleaseUC := mocks.NewReleaseUseCase(t)
+		mockOutboxUC := mocks.NewOutboxUseCase(t)
+		// The ledger isn't checked when the notes are resent
+		uc := NewUseCase(&config.SCM{User: RepoOwner}, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
+
+		event := &models.PullRequestEventDTO{RepoOwner: RepoOwner, RepoName: RepoName, PRNumber: PRNumber, DefaultBranch: DefaultBranch}
+		defaultSHA := "default-SHA"
+		// The details of the PR are taken from GitHub as the comment event doesn't have them
+		resentPR := models.PullRequestSummary{PRNumber: PRNumber, RepoOwner: RepoOwner, RepoName: RepoName, Title: "Add a feature", Author: RepoOwner}
+		mockSCM.On("GetUserPermission", mockCTX, RepoOwner, RepoName, admin).Return(AdminPermission, nil).Once()
+		mockSCM.On("GetPullRequest", mockCTX, RepoOwner, RepoName, PRNumber).Return(&github.PullRequest{
+			ID:     github.Int64(100),
+			Merged: github.Bool(true),
+			Title:  github.String("Add a feature"),
+			Body:   github.String(prBody),
+			User:   &github.User{Login: github.String(RepoOwner)},
+			Head:   &github.PullRequestBranch{Ref: github.String(Branch), SHA: github.String(SHA)},
+		}, nil).Once()
+		mockSCM.On("GetLatestCommitSHAInBranch", mockCTX, RepoOwner, RepoName, DefaultBranch).Return(defaultSHA, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, RepoOwner, RepoName, defaultSHA, domain.PendingState, domain.ReleaseContext).Return(nil).Once()
+		mockSCM.On("GetFileFromBranch", mockCTX, RepoOwner, RepoName, DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, RepoOwner, RepoName, PRNumber).Return([]*github.CommitFile{}, nil).Once()
+		mockSCM.On("CreatePeacockCommitStatus", mockCTX, RepoOwner, RepoName, defaultSHA, domain.SuccessState, domain.ReleaseContext).Return(nil).Once()
+
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil).Once()
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, resentPR).Return(mockNotes, nil).Once()
+		mockNotesUC.On("CreateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, resentPR).Return(nil).Once()
+		mockOutboxUC.On("Deliver", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, resentPR).Return(nil, nil).Once()
+
+		err := uc.ResendPeacock(event, admin)
+		assert.NoError(t, err)
+		assert.Equal(t, prBody, event.Body)
+		assert.Equal(t, SHA, event.SHA)
+	})
+}
+
+func TestWebHookUseCase_UpdatePeacock(t *testing.T) {
+	mockSCM := mocks.NewSCM(t)
+	mockNotesUC := mocks.NewReleaseNotesUseCase(t)
+	mockReleaseUC := mocks.NewReleaseUseCase(t)
+	mockOutboxUC := mocks.NewOutboxUseCase(t)
+
+	cfg := &config.SCM{
+		User: RepoOwner,
+	}
+
+	uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
+
+	mockFilesChanged := []*github.CommitFile{
+		{
+			Filename: github.String("helmfiles/staging/helmfile.yaml"),
+		},
+	}
+	// The environment is looked up so that the subject is rendered as it was when the notes were sent
+	releasedPR := mockPullRequestEventDTO.Summary()
+	releasedPR.Environment = "staging"
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mockEvent := mockPullRequestEventDTO
+		mockEvent.Body = prBody
+
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(mockFilesChanged, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", prBody, mockFeathers).Return(mockNotes, nil).Once()
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, mockNotes, releasedPR).Return(mockNotes, nil).Once()
+		mockNotesUC.On("UpdateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, mockNotes, releasedPR).Return(nil).Once()
+
+		err := uc.UpdatePeacock(mockEvent)
+		assert.NoError(t, err)
+	})
+
+	t.Run("All Notes Removed", func(t *testing.T) {
+		mockEvent := mockPullRequestEventDTO
+		mockEvent.Body = ""
+
+		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.DefaultBranch, ".peacock/feathers.yaml").Return(mockFeathersData, nil).Once()
+		mockSCM.On("GetFilesChangedFromPR", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.PRNumber).Return(mockFilesChanged, nil).Once()
+		mockNotesUC.On("WrapReleaseNotes", mockFeathers.Config.Messages, []models.ReleaseNote(nil), releasedPR).Return(nil, nil).Once()
+		mockNotesUC.On("UpdateSentReleaseNotes", mockCTX, mockFeathers.Config.Messages.Subject, []models.ReleaseNote(nil), releasedPR).Return(nil).Once()
+
+		err := uc.UpdatePeacock(mockEvent)
 		assert.NoError(t, err)
 	})
 }
@@ -219,12 +521,13 @@ func TestWebHookUseCase_getPRTemplate(t *testing.T) {
 	mockSCM := mocks.NewSCM(t)
 	mockNotesUC := mocks.NewReleaseNotesUseCase(t)
 	mockReleaseUC := mocks.NewReleaseUseCase(t)
+	mockOutboxUC := mocks.NewOutboxUseCase(t)
 
 	cfg := &config.SCM{
 		User: RepoOwner,
 	}
 
-	uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(), mockReleaseUC)
+	uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
 
 	mockEvent := &models.PullRequestEventDTO{
 		PullRequestID: 100,
@@ -250,9 +553,9 @@ func TestWebHookUseCase_getPRTemplate(t *testing.T) {
 		uc.prTemplates = make(map[int64]*prTemplateMeta)
 
 		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".github/pull_request_template.md").Return(templateContent, nil).Once()
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), allTeams).Return(mockTemplateNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), mockFeathers).Return(mockTemplateNotes, nil).Once()
 
-		result, err := uc.getPRTemplateOrDefault(mockCTX, mockEvent.Branch, mockEvent, allTeams)
+		result, err := uc.getPRTemplateOrDefault(mockCTX, mockEvent.Branch, mockEvent, mockFeathers)
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTemplateNotes, result)
@@ -268,7 +571,7 @@ func TestWebHookUseCase_getPRTemplate(t *testing.T) {
 			},
 		}
 
-		result, err := uc.getPRTemplateOrDefault(mockCTX, mockEvent.Branch, mockEvent, allTeams)
+		result, err := uc.getPRTemplateOrDefault(mockCTX, mockEvent.Branch, mockEvent, mockFeathers)
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTemplateNotes, result)
@@ -284,9 +587,9 @@ func TestWebHookUseCase_getPRTemplate(t *testing.T) {
 		}
 
 		mockSCM.On("GetFileFromBranch", mockCTX, mockEvent.RepoOwner, mockEvent.RepoName, mockEvent.Branch, ".github/pull_request_template.md").Return(templateContent, nil).Once()
-		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), allTeams).Return(mockTemplateNotes, nil).Once()
+		mockNotesUC.On("GetReleaseNotesFromMarkdownAndTeamsInFeathers", string(templateContent), mockFeathers).Return(mockTemplateNotes, nil).Once()
 
-		result, err := uc.getPRTemplateOrDefault(mockCTX, mockEvent.Branch, mockEvent, allTeams)
+		result, err := uc.getPRTemplateOrDefault(mockCTX, mockEvent.Branch, mockEvent, mockFeathers)
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockTemplateNotes, result)
@@ -369,12 +672,13 @@ func TestWebHookUseCase_compareReleaseNotesAndTemplate(t *testing.T) {
 			mockSCM := mocks.NewSCM(t)
 			mockNotesUC := mocks.NewReleaseNotesUseCase(t)
 			mockReleaseUC := mocks.NewReleaseUseCase(t)
+			mockOutboxUC := mocks.NewOutboxUseCase(t)
 
 			cfg := &config.SCM{
 				User: RepoOwner,
 			}
 
-			uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(), mockReleaseUC)
+			uc := NewUseCase(cfg, mockSCM, mockNotesUC, feathers.NewUseCase(&config.Feathers{}, nil), mockReleaseUC, mockOutboxUC, mocks.NewDeliveryLedgerUseCase(t))
 			actualEqual := uc.areActualNotesAndTemplatesEqual(tc.a, tc.b)
 			assert.Equal(t, tc.expectedEqual, actualEqual)
 		})
@@ -0,0 +1,205 @@
+// Package webhookverify signs the webhook requests sent by Peacock & lets receivers verify them.
+//
+// Each request has a timestamp & a random nonce in its headers, the signature is an HMAC-SHA256 digest of the
+// timestamp, nonce & body so that none of them can be changed without the signature changing. Receivers reject requests
+// whose timestamp is outside a tolerance window, so a captured request can't be replayed later, and remember the nonces
+// they've seen within the window so that it can't be replayed immediately either.
+package webhookverify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TimestampHeader = "X-Peacock-Timestamp"
+	NonceHeader     = "X-Peacock-Nonce"
+	SignatureHeader = "X-Peacock-Signature"
+
+	// signatureVersion prefixes the signature so that the scheme can be changed without breaking receivers
+	signatureVersion = "v1="
+
+	// DefaultTolerance is how far the timestamp of a request can be from the receiver's clock
+	DefaultTolerance = 5 * time.Minute
+	// DefaultMaxBodySize is the largest body VerifyRequest reads, 1MB is well above the size of a release note & stops a
+	// sender who doesn't know the secret from making the receiver buffer an arbitrarily large body
+	DefaultMaxBodySize = 1 << 20
+)
+
+var (
+	ErrMissingHeaders   = errors.New("request is missing the timestamp, nonce or signature header")
+	ErrInvalidTimestamp = errors.New("request timestamp is outside the tolerance window")
+	ErrInvalidSignature = errors.New("request signature does not match any of the secrets")
+	ErrReplayed         = errors.New("request nonce has already been used")
+	ErrBodyTooLarge     = errors.New("request body is larger than the maximum size")
+)
+
+// Sign returns the signature of a request, an HMAC-SHA256 digest of the timestamp, nonce & body
+func Sign(secret string, timestamp int64, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest adds the timestamp, a new nonce & the signature of the body to the headers of a request
+func SignRequest(req *http.Request, body []byte, secret string, now time.Time) error {
+	nonce, err := newNonce()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate nonce")
+	}
+	timestamp := now.Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(NonceHeader, nonce)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, nonce, body))
+	return nil
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NonceStore remembers the nonces of verified requests. Receivers running more than one instance should share the
+// store between them, e.g. by implementing it with Redis.
+type NonceStore interface {
+	// Add records the nonce until it expires, returning false if it's already been recorded
+	Add(nonce string, expires time.Time) bool
+}
+
+// Verifier checks that requests were sent by Peacock. More than one secret can be given so that the secret can be
+// rotated, requests signed with any of them are accepted.
+type Verifier struct {
+	secrets   []string
+	tolerance time.Duration
+	// Nonces is where the nonces of verified requests are kept, by default they're kept in memory
+	Nonces NonceStore
+	// MaxBodySize is the largest body in bytes VerifyRequest reads, by default DefaultMaxBodySize
+	MaxBodySize int64
+
+	now func() time.Time
+}
+
+// NewVerifier creates a verifier that accepts requests signed with any of the secrets & with a timestamp within the
+// tolerance, if the tolerance is 0 then DefaultTolerance is used
+func NewVerifier(tolerance time.Duration, secrets ...string) *Verifier {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	return &Verifier{
+		secrets:     secrets,
+		tolerance:   tolerance,
+		Nonces:      NewMemoryNonceStore(),
+		MaxBodySize: DefaultMaxBodySize,
+		now:         time.Now,
+	}
+}
+
+// Verify checks the signature, timestamp & nonce in the headers of a request against its body
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	rawTimestamp, nonce, signature := header.Get(TimestampHeader), header.Get(NonceHeader), header.Get(SignatureHeader)
+	if rawTimestamp == "" || nonce == "" || signature == "" {
+		return ErrMissingHeaders
+	}
+
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return errors.Wrap(ErrInvalidTimestamp, "failed to parse timestamp")
+	}
+	sent := time.Unix(timestamp, 0)
+	now := v.now()
+	if sent.Before(now.Add(-v.tolerance)) || sent.After(now.Add(v.tolerance)) {
+		return ErrInvalidTimestamp
+	}
+
+	if !v.matchesSecret(signature, timestamp, nonce, body) {
+		return ErrInvalidSignature
+	}
+
+	// The nonce only needs to be remembered while the timestamp is valid, after that the request is rejected anyway
+	if !v.Nonces.Add(nonce, sent.Add(v.tolerance)) {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// VerifyRequest verifies a request, returning its body. The body of the request is replaced so that it can be read
+// again by the caller. Bodies larger than MaxBodySize are rejected with ErrBodyTooLarge without being read in full.
+func (v *Verifier) VerifyRequest(req *http.Request) ([]byte, error) {
+	defer req.Body.Close()
+	maxBodySize := v.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	// One byte more than the maximum is read so that a body of exactly the maximum size is accepted
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, v.Verify(req.Header, body)
+}
+
+func (v *Verifier) matchesSecret(signature string, timestamp int64, nonce string, body []byte) bool {
+	if !strings.HasPrefix(signature, signatureVersion) {
+		return false
+	}
+	for _, secret := range v.secrets {
+		if hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, nonce, body))) {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryNonceStore creates a NonceStore that keeps the nonces in memory, expired nonces are removed as new ones
+// are added
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{
+		nonces: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+func (s *memoryNonceStore) Add(nonce string, expires time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for n, e := range s.nonces {
+		if now.After(e) {
+			delete(s.nonces, n)
+		}
+	}
+	if _, ok := s.nonces[nonce]; ok {
+		return false
+	}
+	s.nonces[nonce] = expires
+	return true
+}
@@ -0,0 +1,153 @@
+package webhookverify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	body := []byte(`{"body":"<p>Hello infra</p>"}`)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	signed := func(secret string, sentAt time.Time) http.Header {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		require.NoError(t, SignRequest(req, body, secret, sentAt))
+		return req.Header
+	}
+
+	testCases := []struct {
+		name        string
+		header      http.Header
+		body        []byte
+		expectedErr error
+	}{
+		{
+			name:   "Valid",
+			header: signed("new-secret", now),
+			body:   body,
+		},
+		{
+			name:   "RotatedSecret",
+			header: signed("old-secret", now.Add(-time.Minute)),
+			body:   body,
+		},
+		{
+			name:        "UnknownSecret",
+			header:      signed("another-secret", now),
+			body:        body,
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "ModifiedBody",
+			header:      signed("new-secret", now),
+			body:        []byte(`{"body":"<p>Hello product</p>"}`),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			name:        "ExpiredTimestamp",
+			header:      signed("new-secret", now.Add(-10*time.Minute)),
+			body:        body,
+			expectedErr: ErrInvalidTimestamp,
+		},
+		{
+			name:        "FutureTimestamp",
+			header:      signed("new-secret", now.Add(10*time.Minute)),
+			body:        body,
+			expectedErr: ErrInvalidTimestamp,
+		},
+		{
+			name:        "MissingHeaders",
+			header:      http.Header{},
+			body:        body,
+			expectedErr: ErrMissingHeaders,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(0, "new-secret", "old-secret")
+			verifier.now = func() time.Time { return now }
+
+			err := verifier.Verify(tt.header, tt.body)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifier_VerifyRequestReplayed(t *testing.T) {
+	body := `{"body":"<p>Hello infra</p>"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	require.NoError(t, SignRequest(req, []byte(body), "secret", time.Now()))
+	verifier := NewVerifier(0, "secret")
+
+	read, err := verifier.VerifyRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(read))
+
+	// The body can still be read by the receiver but the same request can't be verified again
+	replayed := httptest.NewRequest(http.MethodPost, "/", req.Body)
+	replayed.Header = req.Header
+	_, err = verifier.VerifyRequest(replayed)
+	assert.ErrorIs(t, err, ErrReplayed)
+}
+
+func TestVerifier_VerifyRequestBodySize(t *testing.T) {
+	testCases := []struct {
+		name        string
+		bodySize    int
+		maxBodySize int64
+		expectedErr error
+	}{
+		{
+			name:        "UnderMaximum",
+			bodySize:    10,
+			maxBodySize: 16,
+		},
+		{
+			name:        "AtMaximum",
+			bodySize:    16,
+			maxBodySize: 16,
+		},
+		{
+			name:        "OverMaximum",
+			bodySize:    17,
+			maxBodySize: 16,
+			expectedErr: ErrBodyTooLarge,
+		},
+		{
+			name:        "OverDefaultMaximum",
+			bodySize:    DefaultMaxBodySize + 1,
+			expectedErr: ErrBodyTooLarge,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("a", tt.bodySize)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			require.NoError(t, SignRequest(req, []byte(body), "secret", time.Now()))
+			verifier := NewVerifier(0, "secret")
+			if tt.maxBodySize > 0 {
+				verifier.MaxBodySize = tt.maxBodySize
+			}
+
+			read, err := verifier.VerifyRequest(req)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, body, string(read))
+		})
+	}
+}